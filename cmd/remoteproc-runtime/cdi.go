@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+var cdiOutputDir string
+
+var cdiCmd = &cobra.Command{
+	Use:   "cdi",
+	Short: "Export processors and their RPMsg devices through the Container Device Interface",
+}
+
+var cdiGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Write CDI specs describing each processor and its RPMsg devices",
+	Long: fmt.Sprintf("Write Container Device Interface specs exposing each processor, so a firmware container selects it "+
+		"with --device %s=<processor>, and the RPMsg device nodes of each processor running firmware, so a companion "+
+		"container gets them with --device %s=<processor>.", runtime.ProcessorCDIKind, runtime.RPMsgCDIKind),
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		specPaths, err := runtime.WriteCDISpecs(runtime.NewHost(logger), cdiOutputDir)
+		if err != nil {
+			return err
+		}
+		for _, specPath := range specPaths {
+			fmt.Println(specPath)
+		}
+		return nil
+	},
+}
+
+func init() {
+	cdiGenerateCmd.Flags().StringVar(&cdiOutputDir, "output-dir", runtime.DefaultCDISpecDir, "Directory to write the CDI specs to")
+	cdiCmd.AddCommand(cdiGenerateCmd)
+	rootCmd.AddCommand(cdiCmd)
+}
@@ -6,8 +6,9 @@ import (
 )
 
 var (
-	bundlePath string
-	pidFile    string
+	bundlePath    string
+	pidFile       string
+	consoleSocket string
 )
 
 var createCmd = &cobra.Command{
@@ -20,12 +21,13 @@ var createCmd = &cobra.Command{
 		if bundlePath == "" {
 			bundlePath = "."
 		}
-		return runtime.Create(logger, containerID, bundlePath, pidFile)
+		return runtime.Create(logger, runtime.NewHost(logger), containerID, bundlePath, runtime.CreateOptions{PidFile: pidFile, ConsoleSocket: consoleSocket})
 	},
 }
 
 func init() {
 	createCmd.Flags().StringVar(&bundlePath, "bundle", "", "Override the path to the bundle directory (defaults to the current working directory).")
 	createCmd.Flags().StringVar(&pidFile, "pid-file", "", "File to write the proxy process PID to.")
+	createCmd.Flags().StringVar(&consoleSocket, "console-socket", "", "Path to an AF_UNIX socket which will receive a file descriptor referencing the master end of the console's pseudoterminal.")
 	rootCmd.AddCommand(createCmd)
 }
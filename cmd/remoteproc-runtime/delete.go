@@ -15,7 +15,7 @@ var deleteCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
 		containerID := args[0]
-		return runtime.Delete(logger, containerID, forceDelete)
+		return runtime.Delete(logger, runtime.NewHost(logger), containerID, forceDelete)
 	},
 }
 
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+var detachCmd = &cobra.Command{
+	Use:   "detach <container-id>",
+	Short: "Stop a container, leaving its firmware running",
+	Long:  "Stop a container whose firmware the kernel attached to, detaching the kernel from the firmware rather than stopping it, so a container in attach mode can adopt it later.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		containerID := args[0]
+		return runtime.Detach(runtime.NewHost(logger), containerID)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(detachCmd)
+}
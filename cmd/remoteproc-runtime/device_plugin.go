@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/deviceplugin"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/spf13/cobra"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+var (
+	devicePluginResources    string
+	devicePluginDir          string
+	devicePluginPollInterval time.Duration
+)
+
+var devicePluginCmd = &cobra.Command{
+	Use:   "device-plugin",
+	Short: "Advertise remote processors to the kubelet as extended resources",
+	Long: "Run a Kubernetes device plugin advertising the remote processors of this node, so pods request them " +
+		"as extended resources and the scheduler never places two pods on the same core.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+		defer stop()
+		return deviceplugin.Run(ctx, logger, remoteproc.NewSysfsBackend(logger), deviceplugin.Options{
+			Mode:         deviceplugin.ResourceMode(devicePluginResources),
+			PluginDir:    devicePluginDir,
+			PollInterval: devicePluginPollInterval,
+		})
+	},
+}
+
+func init() {
+	devicePluginCmd.Flags().StringVar(&devicePluginResources, "resources", string(deviceplugin.ResourcePerName), "Advertise a resource per processor name (name), or all processors as one (class)")
+	devicePluginCmd.Flags().StringVar(&devicePluginDir, "plugin-dir", pluginapi.DevicePluginPath, "Directory holding the kubelet's device plugin registration socket")
+	devicePluginCmd.Flags().DurationVar(&devicePluginPollInterval, "poll-interval", 5*time.Second, "How often to check processor health and the kubelet")
+	rootCmd.AddCommand(devicePluginCmd)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <container-id> [<target> [args...]]",
+	Short: "Run a built-in target in a container",
+	Long: `Run a built-in target in a container. Firmware can't run Linux processes, so exec only offers these targets:
+
+  health   Print the firmware's health, exiting with 1 unless it is healthy
+
+The target and its arguments are given on the command line, or read from the args of the
+process spec given with --process, as podman does for health checks.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		containerID := args[0]
+		args = args[1:]
+		if execProcessPath != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("exec takes either --process or a target, not both")
+			}
+			process, err := oci.ReadProcess(execProcessPath)
+			if err != nil {
+				return err
+			}
+			args = oci.UnwrapShell(process.Args)
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("exec needs a target or --process")
+		}
+		target := args[0]
+
+		switch target {
+		case "health":
+			if len(args) > 1 {
+				return fmt.Errorf("exec target health takes no arguments, got %q", args[1:])
+			}
+			health, failure, err := runtime.Health(containerID)
+			if err != nil {
+				return err
+			}
+			fmt.Println(health)
+			if failure != "" {
+				fmt.Println(failure)
+			}
+			if health != oci.HealthHealthy {
+				return fmt.Errorf("firmware is %s", health)
+			}
+			return nil
+		default:
+			return fmt.Errorf("unsupported exec target %q, must be one of: health", target)
+		}
+	},
+}
+
+var execProcessPath string
+
+func init() {
+	execCmd.Flags().StringVar(&execProcessPath, "process", "", "Path to a process spec whose args give the target and its arguments.")
+	// Everything after the target belongs to it, as with any exec.
+	execCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(execCmd)
+}
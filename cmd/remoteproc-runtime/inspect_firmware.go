@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/spf13/cobra"
+)
+
+var inspectFirmwareFormat string
+
+var inspectFirmwareCmd = &cobra.Command{
+	Use:   "inspect-firmware <file>",
+	Short: "Show the resource table of a firmware image",
+	Long:  "Show the resource table of an ELF firmware image: its version and entries, including carveouts, device memory, vdev rings and trace buffers.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if inspectFirmwareFormat != "table" && inspectFirmwareFormat != "json" {
+			return fmt.Errorf("invalid format %q, must be one of: table, json", inspectFirmwareFormat)
+		}
+
+		table, err := remoteproc.ReadResourceTable(args[0])
+		if errors.Is(err, remoteproc.ErrNoResourceTable) {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		if err != nil {
+			return err
+		}
+
+		if inspectFirmwareFormat == "json" {
+			output, err := json.Marshal(table)
+			if err != nil {
+				return fmt.Errorf("failed to marshal resource table: %w", err)
+			}
+			fmt.Println(string(output))
+			return nil
+		}
+		return printResourceTable(table)
+	},
+}
+
+func init() {
+	inspectFirmwareCmd.Flags().StringVar(&inspectFirmwareFormat, "format", "table", "Output format (table, json)")
+	rootCmd.AddCommand(inspectFirmwareCmd)
+}
+
+func printResourceTable(table *remoteproc.ResourceTable) error {
+	fmt.Printf("Resource table version %d, %d entries\n\n", table.Version, len(table.Entries))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ENTRY\tTYPE\tNAME\tDA\tPA\tLEN\tDETAILS")
+	for i, entry := range table.Entries {
+		switch {
+		case entry.Carveout != nil:
+			printMemoryResource(w, i, entry.Type, entry.Carveout)
+		case entry.DevMem != nil:
+			printMemoryResource(w, i, entry.Type, entry.DevMem)
+		case entry.Trace != nil:
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t-\t%#x\t-\n", i, entry.Type, entry.Trace.Name, formatAddress(entry.Trace.DeviceAddress), entry.Trace.Length)
+		case entry.Vdev != nil:
+			vdev := entry.Vdev
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t-\t-\t-\tnotifyid=%d features=%#x config=%d bytes\n", i, entry.Type, vdev.DeviceName(), vdev.NotifyID, vdev.DeviceFeatures, vdev.ConfigLength)
+			for j, vring := range vdev.Vrings {
+				_, _ = fmt.Fprintf(w, "%d.%d\tvring\t-\t%s\t-\t-\tnum=%d align=%#x notifyid=%d\n", i, j, formatAddress(vring.DeviceAddress), vring.Num, vring.Align, vring.NotifyID)
+			}
+		default:
+			_, _ = fmt.Fprintf(w, "%d\t%s\t-\t-\t-\t-\toffset=%#x\n", i, entry.Type, entry.Offset)
+		}
+	}
+	return w.Flush()
+}
+
+func printMemoryResource(w *tabwriter.Writer, index int, resourceType remoteproc.ResourceType, memory *remoteproc.MemoryResource) {
+	_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%#x\tflags=%#x\n", index, resourceType, memory.Name, formatAddress(memory.DeviceAddress), formatAddress(memory.PhysicalAddress), memory.Length, memory.Flags)
+}
+
+func formatAddress(address uint32) string {
+	if address == remoteproc.AddressAny {
+		return "any"
+	}
+	return fmt.Sprintf("%#x", address)
+}
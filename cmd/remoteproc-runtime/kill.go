@@ -12,7 +12,7 @@ import (
 var killCmd = &cobra.Command{
 	Use:   "kill <container-id> [SIGNAL]",
 	Short: "Send a signal to the container process",
-	Long:  "Send a signal to the container process. Supported signals: TERM (15), KILL (9), INT (2). Default is TERM.",
+	Long:  "Send a signal to the container process. Supported signals: TERM (15), KILL (9), INT (2), USR2 (12), which detaches the firmware like the detach command, and HUP (1), QUIT (3) and ALRM (14), which the proxy ignores. Default is TERM.",
 	Args:  cobra.RangeArgs(1, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
@@ -27,7 +27,7 @@ var killCmd = &cobra.Command{
 			}
 		}
 
-		return runtime.Kill(containerID, signal)
+		return runtime.Kill(runtime.NewHost(logger), containerID, signal)
 	},
 }
 
@@ -44,7 +44,15 @@ func parseSignal(input string) (syscall.Signal, error) {
 		return syscall.SIGTERM, nil
 	case "INT", "SIGINT", "2":
 		return syscall.SIGINT, nil
+	case "USR2", "SIGUSR2", "12":
+		return syscall.SIGUSR2, nil
+	case "HUP", "SIGHUP", "1":
+		return syscall.SIGHUP, nil
+	case "QUIT", "SIGQUIT", "3":
+		return syscall.SIGQUIT, nil
+	case "ALRM", "SIGALRM", "14":
+		return syscall.SIGALRM, nil
 	default:
-		return 0, fmt.Errorf("unsupported signal: %s (supported: TERM (15), KILL (9), INT (2))", input)
+		return 0, fmt.Errorf("unsupported signal: %s (supported: TERM (15), KILL (9), INT (2), USR2 (12), HUP (1), QUIT (3), ALRM (14))", input)
 	}
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+var listProcessorsFormat string
+
+var listProcessorsCmd = &cobra.Command{
+	Use:   "list-processors",
+	Short: "List remote processors available on this system",
+	Long:  "List remote processors available on this system, along with their state and the container owning each of them.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if listProcessorsFormat != "table" && listProcessorsFormat != "json" {
+			return fmt.Errorf("invalid format %q, must be one of: table, json", listProcessorsFormat)
+		}
+
+		processors, err := runtime.ListProcessors(logger, runtime.NewHost(logger))
+		if err != nil {
+			return err
+		}
+
+		if listProcessorsFormat == "json" {
+			output, err := json.Marshal(processors)
+			if err != nil {
+				return fmt.Errorf("failed to marshal processors: %w", err)
+			}
+			fmt.Println(string(output))
+			return nil
+		}
+		return printProcessorsTable(processors)
+	},
+}
+
+func init() {
+	listProcessorsCmd.Flags().StringVar(&listProcessorsFormat, "format", "table", "Output format (table, json)")
+	rootCmd.AddCommand(listProcessorsCmd)
+}
+
+func printProcessorsTable(processors []runtime.ProcessorInfo) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "INDEX\tNAME\tSTATE\tFIRMWARE\tCOREDUMP\tRECOVERY\tDEVICE\tOF NODE\tOWNER")
+	for _, p := range processors {
+		owner := "-"
+		if p.Owner != nil {
+			owner = fmt.Sprintf("%s (%s)", p.Owner.ContainerID, p.Owner.Status)
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			p.Index,
+			p.Name,
+			p.State,
+			orDash(p.Firmware),
+			orDash(p.Coredump),
+			orDash(p.Recovery),
+			orDash(p.ParentDevice),
+			orDash(p.DeviceTreeNode),
+			owner,
+		)
+	}
+	return w.Flush()
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
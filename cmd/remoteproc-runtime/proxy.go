@@ -1,17 +1,42 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy"
 	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
 	"github.com/spf13/cobra"
 )
 
-var devicePath string
+var (
+	devicePath           string
+	proxyConsole         bool
+	proxyContainerID     string
+	proxyRPMsgEndpoints  []string
+	proxyRefreshCDI      bool
+	proxyShutdownChannel string
+	proxyShutdownMessage string
+	proxyShutdownTimeout time.Duration
+	proxyHealthInterval  time.Duration
+	proxyHealthChannel   string
+	proxyHealthPing      string
+	proxyHealthTimeout   time.Duration
+	proxyHealthHeartbeat string
+	proxyRestart         string
+	proxyRestartBackoff  time.Duration
+	proxyCoredumpDir     string
+	proxyRecovery        string
+	proxyAttach          bool
+	proxyControlFD       int
+)
 
 var proxyCmd = &cobra.Command{
 	Use:    "proxy",
@@ -19,53 +44,127 @@ var proxyCmd = &cobra.Command{
 	Hidden: true, // Internal command, not for direct user interaction
 	Args:   cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
 		if devicePath == "" {
 			return fmt.Errorf("--device-path is required")
 		}
 
-		// Phase 1: Wait for SIGUSR1 start signal
-		sigCh := make(chan os.Signal, 1)
-		signal.Notify(sigCh, syscall.SIGUSR1, syscall.SIGTERM, syscall.SIGINT)
-
-		sig := <-sigCh
-		if sig == syscall.SIGTERM || sig == syscall.SIGINT {
-			os.Exit(0)
+		endpoints := make([]remoteproc.RPMsgEndpoint, 0, len(proxyRPMsgEndpoints))
+		for _, spec := range proxyRPMsgEndpoints {
+			endpoint, err := remoteproc.ParseRPMsgEndpoint(spec)
+			if err != nil {
+				return err
+			}
+			endpoints = append(endpoints, endpoint)
 		}
 
-		// Phase 2: Start the firmware and wait for its termination or SIGTERM
-		if err := remoteproc.Start(devicePath); err != nil {
-			return fmt.Errorf("failed to start remoteproc: %w", err)
+		var shutdown *proxy.GracefulShutdown
+		if proxyShutdownChannel != "" {
+			message, err := proxy.ParseMessage(proxyShutdownMessage)
+			if err != nil {
+				return err
+			}
+			shutdown = &proxy.GracefulShutdown{
+				Channel: proxyShutdownChannel,
+				Message: message,
+				Timeout: proxyShutdownTimeout,
+			}
 		}
 
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case sig := <-sigCh:
-				if sig == syscall.SIGTERM || sig == syscall.SIGINT {
-					if err := remoteproc.Stop(devicePath); err != nil {
-						fmt.Fprintf(os.Stderr, "failed to stop remoteproc: %v\n", err)
-					}
-					os.Exit(0)
-				}
-			case <-ticker.C:
-				state, err := remoteproc.GetState(devicePath)
+		var healthCheck *proxy.HealthCheck
+		if cmd.Flags().Changed("health-interval") {
+			healthCheck = &proxy.HealthCheck{
+				Interval:     proxyHealthInterval,
+				RPMsgChannel: proxyHealthChannel,
+				Timeout:      proxyHealthTimeout,
+				Heartbeat:    proxyHealthHeartbeat,
+			}
+			if proxyHealthChannel != "" {
+				ping, err := proxy.ParseMessage(proxyHealthPing)
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "failed to get remoteproc state: %v\n", err)
-					continue
+					return err
 				}
-				if state != remoteproc.StateRunning {
-					fmt.Fprintf(os.Stderr, "remoteproc not running, current state: %s\n", state)
-					os.Exit(1)
+				healthCheck.Ping = ping
+			}
+		}
+
+		restart, err := proxy.ParseRestartPolicy(proxyRestart)
+		if err != nil {
+			return err
+		}
+		if restart != nil {
+			restart.Backoff = proxyRestartBackoff
+		}
+
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGTERM, syscall.SIGINT)
+		signal.Notify(sigCh, proxy.IgnoredSignals...)
+
+		processor, err := remoteproc.NewSysfsBackend(logger).Open(devicePath)
+		if err != nil {
+			return fmt.Errorf("failed to open remoteproc: %w", err)
+		}
+
+		opts := proxy.RunOptions{
+			PollInterval:   1 * time.Second,
+			TraceOutput:    os.Stdout,
+			RPMsgEndpoints: endpoints,
+			Shutdown:       shutdown,
+			HealthCheck:    healthCheck,
+			Restart:        restart,
+			CoredumpDir:    proxyCoredumpDir,
+			Recovery:       proxyRecovery,
+			Attach:         proxyAttach,
+		}
+		if proxyRefreshCDI {
+			opts.RPMsgChanged = func() {
+				if _, err := runtime.WriteCDISpecs(runtime.NewHost(logger), runtime.DefaultCDISpecDir); err != nil {
+					logger.Warn("failed to refresh CDI specs", "error", err)
 				}
 			}
 		}
+		if proxyContainerID != "" {
+			opts.PublishStatus = func(status oci.ProxyStatus) error {
+				return oci.WriteProxyStatus(proxyContainerID, status)
+			}
+		}
+		if proxyConsole {
+			// Stdin and stdout are both the container's terminal.
+			opts.Console = os.Stdin
+		}
+		if proxyControlFD >= 0 {
+			controlFile := os.NewFile(uintptr(proxyControlFD), "control")
+			control, err := net.FileListener(controlFile)
+			_ = controlFile.Close()
+			if err != nil {
+				return fmt.Errorf("failed to use control socket: %w", err)
+			}
+			opts.Control = control
+		}
+		return proxy.Run(context.Background(), logger, processor, sigCh, opts)
 	},
 }
 
 func init() {
 	proxyCmd.Flags().StringVar(&devicePath, "device-path", "", "Remoteproc device path (required)")
+	proxyCmd.Flags().BoolVar(&proxyConsole, "console", false, "Bridge stdin, a terminal, to the firmware's RPMsg TTY")
+	proxyCmd.Flags().StringVar(&proxyContainerID, "container-id", "", "Container to report the firmware's status to")
+	proxyCmd.Flags().StringArrayVar(&proxyRPMsgEndpoints, "rpmsg-endpoint", nil, "RPMsg endpoint to create once the firmware is up, as name:src:dst (repeatable)")
+	proxyCmd.Flags().BoolVar(&proxyRefreshCDI, "refresh-cdi", false, "Regenerate the CDI specs whenever the firmware's RPMsg device nodes change")
+	proxyCmd.Flags().StringVar(&proxyShutdownChannel, "shutdown-channel", "", "RPMsg endpoint or channel to ask the firmware to shut down over before stopping it")
+	proxyCmd.Flags().StringVar(&proxyShutdownMessage, "shutdown-message", proxy.DefaultShutdownMessage, "Shutdown message, with Go escape sequences")
+	proxyCmd.Flags().DurationVar(&proxyShutdownTimeout, "shutdown-timeout", proxy.DefaultShutdownTimeout, "How long the firmware gets to shut down before it is stopped")
+	proxyCmd.Flags().DurationVar(&proxyHealthInterval, "health-interval", proxy.DefaultHealthInterval, "Run a health check on the firmware this often")
+	proxyCmd.Flags().StringVar(&proxyHealthChannel, "health-rpmsg-channel", "", "RPMsg endpoint or channel the health check pings the firmware over")
+	proxyCmd.Flags().StringVar(&proxyHealthPing, "health-ping", proxy.DefaultHealthPing, "Health check ping, with Go escape sequences")
+	proxyCmd.Flags().DurationVar(&proxyHealthTimeout, "health-timeout", proxy.DefaultHealthTimeout, "How long the firmware gets to answer a health check ping")
+	proxyCmd.Flags().StringVar(&proxyHealthHeartbeat, "health-heartbeat", "", "Text the firmware must trace between two health checks")
+	proxyCmd.Flags().StringVar(&proxyRestart, "restart", proxy.RestartNo, "Restart firmware that stops by itself: no, on-failure[:max-restarts] or always")
+	proxyCmd.Flags().DurationVar(&proxyRestartBackoff, "restart-backoff", proxy.DefaultRestartBackoff, "Delay before restarting the firmware, doubling with each restart in a row")
+	proxyCmd.Flags().StringVar(&proxyCoredumpDir, "coredump-dir", "", "Directory to save coredumps of the crashed firmware to")
+	proxyCmd.Flags().StringVar(&proxyRecovery, "recovery", "", "Recovery mode set on the processor, enabled, disabled or recover-once, to wait for the kernel to recover crashed firmware under")
+	proxyCmd.Flags().BoolVar(&proxyAttach, "attach", false, "Adopt the firmware already running on the processor, attaching to it if detached, rather than boot it")
+	proxyCmd.Flags().IntVar(&proxyControlFD, "control-fd", -1, "Inherited file descriptor of the listening control socket to serve")
 	_ = proxyCmd.MarkFlagRequired("device-path")
 	rootCmd.AddCommand(proxyCmd)
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+var rpmsgFormat string
+
+var rpmsgCmd = &cobra.Command{
+	Use:   "rpmsg <container-id>",
+	Short: "List the RPMsg channels of a running container",
+	Long:  "List the RPMsg channels announced by a running container's firmware, with their endpoint addresses, bound driver and device node.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if rpmsgFormat != "table" && rpmsgFormat != "json" {
+			return fmt.Errorf("invalid format %q, must be one of: table, json", rpmsgFormat)
+		}
+
+		devices, err := runtime.RPMsgDevices(runtime.NewHost(logger), args[0])
+		if err != nil {
+			return err
+		}
+
+		if rpmsgFormat == "json" {
+			output, err := json.Marshal(devices)
+			if err != nil {
+				return fmt.Errorf("failed to marshal RPMsg devices: %w", err)
+			}
+			fmt.Println(string(output))
+			return nil
+		}
+		return printRPMsgDevices(devices)
+	},
+}
+
+func init() {
+	rpmsgCmd.Flags().StringVar(&rpmsgFormat, "format", "table", "Output format (table, json)")
+	rootCmd.AddCommand(rpmsgCmd)
+}
+
+func printRPMsgDevices(devices []remoteproc.RPMsgDevice) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "CHANNEL\tSRC\tDST\tDRIVER\tDEVICE NODE\tNAME")
+	for _, d := range devices {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.Channel,
+			formatAddress(d.Src),
+			formatAddress(d.Dst),
+			orDash(d.Driver),
+			orDash(d.DeviceNode),
+			d.Name,
+		)
+	}
+	return w.Flush()
+}
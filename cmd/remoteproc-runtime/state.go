@@ -16,7 +16,7 @@ var stateCmd = &cobra.Command{
 		cmd.SilenceUsage = true
 		containerID := args[0]
 
-		state, err := runtime.State(containerID)
+		state, err := runtime.LiveState(logger, runtime.NewHost(logger), containerID)
 		if err != nil {
 			return err
 		}
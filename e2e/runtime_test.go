@@ -46,7 +46,7 @@ func TestRuntime(t *testing.T) {
 		bundlePath, err := generateBundle(t, env, remoteprocName)
 		require.NoError(t, err)
 
-		_, stderr, err := installedRuntime.Run(
+		stderr, err := installedRuntime.RunDiscardingStdout(
 			"create",
 			"--bundle", bundlePath,
 			containerName)
@@ -83,7 +83,7 @@ func TestRuntime(t *testing.T) {
 		require.NoError(t, err)
 
 		expectedErrorSubstring := "remote processor other-processor does not exist, available remote processors: "
-		_, stderr, err := installedRuntime.Run("create", "--bundle", bundlePath, containerName)
+		stderr, err := installedRuntime.RunDiscardingStdout("create", "--bundle", bundlePath, containerName)
 		assert.ErrorContains(t, err, expectedErrorSubstring, "error doesn't contain: %s: stderr: %s", expectedErrorSubstring, stderr)
 		assert.ErrorContains(t, err, processorName, "error doesn't contain expected processor name: %s: stderr: %s", processorName, stderr)
 	})
@@ -101,7 +101,7 @@ func TestRuntime(t *testing.T) {
 		bundlePath, err := generateBundle(t, env, remoteprocName)
 		require.NoError(t, err)
 
-		_, stderr, err := installedRuntime.Run("create", "--bundle", bundlePath, containerName)
+		stderr, err := installedRuntime.RunDiscardingStdout("create", "--bundle", bundlePath, containerName)
 		require.NoError(t, err, "stderr: %s", stderr)
 
 		pid, err := getContainerPid(installedRuntime, containerName)
@@ -130,7 +130,7 @@ func TestRuntime(t *testing.T) {
 		require.NoError(t, err)
 		pidFile := filepath.Join(bundlePath, "container.pid")
 
-		_, stderr, err := installedRuntime.Run(
+		stderr, err := installedRuntime.RunDiscardingStdout(
 			"create",
 			"--bundle", bundlePath,
 			"--pid-file", pidFile,
@@ -165,7 +165,7 @@ func TestRuntime(t *testing.T) {
 				specs.LinuxNamespace{Type: specs.MountNamespace},
 			)
 			require.NoError(t, err)
-			_, stderr, err := installedRuntimeSudo.Run(
+			stderr, err := installedRuntimeSudo.RunDiscardingStdout(
 				"create",
 				"--bundle", bundlePath,
 				containerName)
@@ -202,7 +202,7 @@ func TestRuntime(t *testing.T) {
 				specs.LinuxNamespace{Type: specs.MountNamespace},
 			)
 			require.NoError(t, err)
-			_, stderr, err := installedRuntime.Run(
+			stderr, err := installedRuntime.RunDiscardingStdout(
 				"create",
 				"--bundle", bundlePath,
 				containerName)
@@ -236,7 +236,7 @@ func TestRuntime(t *testing.T) {
 		bundlePath, err := generateBundle(t, env, remoteprocName)
 		require.NoError(t, err)
 
-		_, stderr, err := installedRuntime.Run(
+		stderr, err := installedRuntime.RunDiscardingStdout(
 			"create",
 			"--bundle", bundlePath,
 			containerName)
@@ -352,8 +352,12 @@ func generateBundle(t *testing.T, env testenv.Env, remoteprocName string, namesp
 		return "", err
 	}
 
+	firmware, err := os.ReadFile("../testdata/bundle/rootfs/hello_world_cm33.elf")
+	if err != nil {
+		return "", err
+	}
 	firmwarePath := filepath.Join(bundlePathOnHost, bundleRoot, firmwareName)
-	if err := os.WriteFile(firmwarePath, []byte("pretend binary"), 0o644); err != nil {
+	if err := os.WriteFile(firmwarePath, firmware, 0o644); err != nil {
 		return "", err
 	}
 
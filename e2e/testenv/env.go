@@ -52,6 +52,15 @@ func (b InstalledBin) Run(args ...string) (stdout, stderr string, err error) {
 	return b.env.RunCommand(b.pathToBin, args...)
 }
 
+// discardStdoutScript runs its arguments with stdout sent to /dev/null. Processes started by
+// `create` inherit its stdout, so capturing it would block until the container exits.
+const discardStdoutScript = `exec "$@" >/dev/null`
+
+func (b InstalledBin) RunDiscardingStdout(args ...string) (stderr string, err error) {
+	_, stderr, err = b.env.RunCommand("sh", append([]string{"-c", discardStdoutScript, "sh", b.pathToBin}, args...)...)
+	return stderr, err
+}
+
 func (b InstalledBin) Command(args ...string) *exec.Cmd {
 	return b.env.Command(b.pathToBin, args...)
 }
@@ -72,3 +81,8 @@ func NewSudo(b InstalledBin) Sudo {
 func (s Sudo) Run(args ...string) (stdout, stderr string, err error) {
 	return s.env.RunCommand("sudo", append([]string{s.pathToBin}, args...)...)
 }
+
+func (s Sudo) RunDiscardingStdout(args ...string) (stderr string, err error) {
+	_, stderr, err = s.env.RunCommand("sudo", append([]string{"sh", "-c", discardStdoutScript, "sh", s.pathToBin}, args...)...)
+	return stderr, err
+}
@@ -0,0 +1,134 @@
+// Package deviceplugin advertises remote processors to the kubelet as extended resources,
+// implementing the Kubernetes device plugin API.
+package deviceplugin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+// ResourceMode decides how processors are grouped into resources.
+type ResourceMode string
+
+const (
+	// ResourcePerName advertises a resource for each processor name, e.g. arm.com/remoteproc-m33,
+	// so pods ask for a specific kind of core.
+	ResourcePerName ResourceMode = "name"
+	// ResourcePerClass advertises every processor as arm.com/remoteproc, so pods take any core.
+	ResourcePerClass ResourceMode = "class"
+
+	resourceDomain = "arm.com"
+	resourceClass  = "remoteproc"
+)
+
+// Options configures Run.
+type Options struct {
+	Mode ResourceMode
+	// PluginDir holds the kubelet's registration socket, where the plugin sockets are created too.
+	PluginDir string
+	// PollInterval is how often processor health and the plugin sockets are checked.
+	PollInterval time.Duration
+}
+
+// Run serves a device plugin for each resource until ctx is cancelled, registering them with
+// the kubelet, and again whenever the kubelet restarts.
+func Run(ctx context.Context, logger *slog.Logger, backend remoteproc.Backend, opts Options) error {
+	resources, err := discoverResources(backend, opts.Mode)
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		return fmt.Errorf("no remote processors to advertise")
+	}
+
+	plugins := make([]*plugin, 0, len(resources))
+	for _, resource := range resources {
+		plugins = append(plugins, newPlugin(logger, resource, opts))
+	}
+	defer func() {
+		for _, p := range plugins {
+			p.stop()
+		}
+	}()
+
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		for _, p := range plugins {
+			// The kubelet removes the plugin sockets when it restarts, expecting plugins to
+			// come back and register again.
+			if p.serving() {
+				continue
+			}
+			p.stop()
+			if err := p.start(); err != nil {
+				logger.Warn("failed to register device plugin", "resource", p.resource.name, "error", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			for _, p := range plugins {
+				p.refreshHealth()
+			}
+		}
+	}
+}
+
+// resource is an extended resource and the processors handed out as its devices.
+type resource struct {
+	name       string
+	processors []remoteproc.Processor
+}
+
+var invalidResourceChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+func discoverResources(backend remoteproc.Backend, mode ResourceMode) ([]resource, error) {
+	processors, err := backend.Processors()
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case ResourcePerClass:
+		if len(processors) == 0 {
+			return nil, nil
+		}
+		return []resource{{name: resourceDomain + "/" + resourceClass, processors: processors}}, nil
+	case ResourcePerName:
+		byName := map[string][]remoteproc.Processor{}
+		for _, processor := range processors {
+			name := resourceDomain + "/" + resourceClass + "-" + invalidResourceChars.ReplaceAllString(processor.Name(), "-")
+			byName[name] = append(byName[name], processor)
+		}
+		resources := make([]resource, 0, len(byName))
+		for _, name := range slices.Sorted(maps.Keys(byName)) {
+			resources = append(resources, resource{name: name, processors: byName[name]})
+		}
+		return resources, nil
+	default:
+		return nil, fmt.Errorf("invalid resource mode %q, must be one of: %s, %s", mode, ResourcePerName, ResourcePerClass)
+	}
+}
+
+// deviceID identifies a processor by its sysfs device directory, e.g. remoteproc0.
+func deviceID(processor remoteproc.Processor) string {
+	return filepath.Base(processor.DevicePath())
+}
+
+func deviceHealth(processor remoteproc.Processor) string {
+	state, err := processor.State()
+	if err != nil || state == remoteproc.StateCrashed || state == remoteproc.StateInvalid {
+		return pluginapi.Unhealthy
+	}
+	return pluginapi.Healthy
+}
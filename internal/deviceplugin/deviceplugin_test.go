@@ -0,0 +1,223 @@
+package deviceplugin_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/deviceplugin"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc/remoteproctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("registers a resource per processor name", func(t *testing.T) {
+		backend := remoteproctest.NewBackend(t.TempDir())
+		backend.AddProcessor("m33")
+		backend.AddProcessor("dsp")
+		backend.AddProcessor("dsp")
+		kubelet := startKubelet(t)
+
+		runPlugin(t, backend, kubelet, deviceplugin.ResourcePerName)
+
+		registrations := kubelet.waitForRegistrations(t, 2)
+		assert.ElementsMatch(t, []string{"arm.com/remoteproc-dsp", "arm.com/remoteproc-m33"}, resourceNames(registrations))
+		dsp := kubelet.dial(t, "arm.com/remoteproc-dsp")
+		assert.Equal(t, map[string]string{"remoteproc1": pluginapi.Healthy, "remoteproc2": pluginapi.Healthy}, listDevices(t, dsp))
+	})
+
+	t.Run("registers every processor as one resource per class", func(t *testing.T) {
+		backend := remoteproctest.NewBackend(t.TempDir())
+		backend.AddProcessor("m33")
+		backend.AddProcessor("dsp")
+		kubelet := startKubelet(t)
+
+		runPlugin(t, backend, kubelet, deviceplugin.ResourcePerClass)
+
+		registrations := kubelet.waitForRegistrations(t, 1)
+		assert.Equal(t, "arm.com/remoteproc", registrations[0].ResourceName)
+		assert.Equal(t, pluginapi.Version, registrations[0].Version)
+		plugin := kubelet.dial(t, "arm.com/remoteproc")
+		assert.Equal(t, map[string]string{"remoteproc0": pluginapi.Healthy, "remoteproc1": pluginapi.Healthy}, listDevices(t, plugin))
+	})
+
+	t.Run("reports crashed processors as unhealthy", func(t *testing.T) {
+		backend := remoteproctest.NewBackend(t.TempDir())
+		processor := backend.AddProcessor("m33")
+		kubelet := startKubelet(t)
+		runPlugin(t, backend, kubelet, deviceplugin.ResourcePerName)
+		kubelet.waitForRegistrations(t, 1)
+		stream, err := kubelet.dial(t, "arm.com/remoteproc-m33").ListAndWatch(context.Background(), &pluginapi.Empty{})
+		require.NoError(t, err)
+		first, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, pluginapi.Healthy, first.Devices[0].Health)
+
+		processor.Crash()
+
+		second, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, pluginapi.Unhealthy, second.Devices[0].Health)
+	})
+
+	t.Run("allocates the processor selection to containers", func(t *testing.T) {
+		backend := remoteproctest.NewBackend(t.TempDir())
+		backend.AddProcessor("dsp")
+		backend.AddProcessor("dsp")
+		kubelet := startKubelet(t)
+		runPlugin(t, backend, kubelet, deviceplugin.ResourcePerName)
+		kubelet.waitForRegistrations(t, 1)
+		plugin := kubelet.dial(t, "arm.com/remoteproc-dsp")
+
+		response, err := plugin.Allocate(context.Background(), &pluginapi.AllocateRequest{
+			ContainerRequests: []*pluginapi.ContainerAllocateRequest{{DevicesIDs: []string{"remoteproc1"}}},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, response.ContainerResponses, 1)
+		assert.Equal(t, map[string]string{"REMOTEPROC_NAME": "dsp", "REMOTEPROC_INDEX": "1"}, response.ContainerResponses[0].Envs)
+		assert.Equal(t, map[string]string{"remoteproc.name": "dsp", "remoteproc.index": "1"}, response.ContainerResponses[0].Annotations)
+	})
+
+	t.Run("refuses to put several processors in one container", func(t *testing.T) {
+		backend := remoteproctest.NewBackend(t.TempDir())
+		backend.AddProcessor("dsp")
+		backend.AddProcessor("dsp")
+		kubelet := startKubelet(t)
+		runPlugin(t, backend, kubelet, deviceplugin.ResourcePerName)
+		kubelet.waitForRegistrations(t, 1)
+		plugin := kubelet.dial(t, "arm.com/remoteproc-dsp")
+
+		_, err := plugin.Allocate(context.Background(), &pluginapi.AllocateRequest{
+			ContainerRequests: []*pluginapi.ContainerAllocateRequest{{DevicesIDs: []string{"remoteproc0", "remoteproc1"}}},
+		})
+
+		assert.ErrorContains(t, err, "a container runs on exactly one remote processor, 2 requested")
+	})
+
+	t.Run("registers again when the kubelet restarts", func(t *testing.T) {
+		backend := remoteproctest.NewBackend(t.TempDir())
+		backend.AddProcessor("m33")
+		kubelet := startKubelet(t)
+		runPlugin(t, backend, kubelet, deviceplugin.ResourcePerName)
+		kubelet.waitForRegistrations(t, 1)
+
+		// A restarting kubelet wipes the plugin sockets.
+		require.NoError(t, os.Remove(filepath.Join(kubelet.dir, "arm.com_remoteproc-m33.sock")))
+
+		kubelet.waitForRegistrations(t, 2)
+	})
+}
+
+type fakeKubelet struct {
+	pluginapi.UnimplementedRegistrationServer
+	dir string
+
+	mu            sync.Mutex
+	registrations []*pluginapi.RegisterRequest
+}
+
+func (k *fakeKubelet) Register(_ context.Context, request *pluginapi.RegisterRequest) (*pluginapi.Empty, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.registrations = append(k.registrations, request)
+	return &pluginapi.Empty{}, nil
+}
+
+func (k *fakeKubelet) waitForRegistrations(t *testing.T, count int) []*pluginapi.RegisterRequest {
+	t.Helper()
+	var registrations []*pluginapi.RegisterRequest
+	require.Eventually(t, func() bool {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		registrations = append([]*pluginapi.RegisterRequest{}, k.registrations...)
+		return len(registrations) >= count
+	}, 5*time.Second, time.Millisecond)
+	return registrations
+}
+
+// dial connects to the plugin registered for resourceName, as the kubelet does.
+func (k *fakeKubelet) dial(t *testing.T, resourceName string) pluginapi.DevicePluginClient {
+	t.Helper()
+	k.mu.Lock()
+	var endpoint string
+	for _, registration := range k.registrations {
+		if registration.ResourceName == resourceName {
+			endpoint = registration.Endpoint
+		}
+	}
+	k.mu.Unlock()
+	require.NotEmpty(t, endpoint, "%s isn't registered", resourceName)
+	conn, err := grpc.NewClient("unix://"+filepath.Join(k.dir, endpoint), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return pluginapi.NewDevicePluginClient(conn)
+}
+
+func startKubelet(t *testing.T) *fakeKubelet {
+	t.Helper()
+	// Unix socket paths are limited to about 100 bytes, too short for t.TempDir().
+	dir, err := os.MkdirTemp("", "kubelet")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	listener, err := net.Listen("unix", filepath.Join(dir, "kubelet.sock"))
+	require.NoError(t, err)
+	kubelet := &fakeKubelet{dir: dir}
+	server := grpc.NewServer()
+	pluginapi.RegisterRegistrationServer(server, kubelet)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+	return kubelet
+}
+
+func runPlugin(t *testing.T, backend *remoteproctest.Backend, kubelet *fakeKubelet, mode deviceplugin.ResourceMode) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := deviceplugin.Run(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), backend, deviceplugin.Options{
+			Mode:         mode,
+			PluginDir:    kubelet.dir,
+			PollInterval: 10 * time.Millisecond,
+		})
+		assert.NoError(t, err)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func listDevices(t *testing.T, plugin pluginapi.DevicePluginClient) map[string]string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := plugin.ListAndWatch(ctx, &pluginapi.Empty{})
+	require.NoError(t, err)
+	response, err := stream.Recv()
+	require.NoError(t, err)
+	health := map[string]string{}
+	for _, device := range response.Devices {
+		health[device.ID] = device.Health
+	}
+	return health
+}
+
+func resourceNames(registrations []*pluginapi.RegisterRequest) []string {
+	names := make([]string, len(registrations))
+	for i, registration := range registrations {
+		names[i] = registration.ResourceName
+	}
+	return names
+}
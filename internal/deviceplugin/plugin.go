@@ -0,0 +1,227 @@
+package deviceplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+const registerTimeout = 5 * time.Second
+
+// plugin serves the device plugin API for a single resource.
+type plugin struct {
+	logger    *slog.Logger
+	resource  resource
+	pluginDir string
+
+	mu       sync.Mutex
+	server   *grpc.Server
+	health   map[string]string
+	watchers map[chan struct{}]struct{}
+}
+
+var _ pluginapi.DevicePluginServer = (*plugin)(nil)
+
+func newPlugin(logger *slog.Logger, resource resource, opts Options) *plugin {
+	p := &plugin{
+		logger:    logger.With("resource", resource.name),
+		resource:  resource,
+		pluginDir: opts.PluginDir,
+		health:    map[string]string{},
+		watchers:  map[chan struct{}]struct{}{},
+	}
+	for _, processor := range resource.processors {
+		p.health[deviceID(processor)] = deviceHealth(processor)
+	}
+	return p
+}
+
+func (p *plugin) socketPath() string {
+	return filepath.Join(p.pluginDir, strings.ReplaceAll(p.resource.name, "/", "_")+".sock")
+}
+
+// start serves the plugin on its socket and registers it with the kubelet.
+func (p *plugin) start() error {
+	socketPath := p.socketPath()
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket %s: %w", socketPath, err)
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	server := grpc.NewServer()
+	pluginapi.RegisterDevicePluginServer(server, p)
+	go func() { _ = server.Serve(listener) }()
+	p.mu.Lock()
+	p.server = server
+	p.mu.Unlock()
+
+	if err := p.register(); err != nil {
+		p.stop()
+		return err
+	}
+	p.logger.Info("registered device plugin", "devices", len(p.resource.processors))
+	return nil
+}
+
+func (p *plugin) register() error {
+	conn, err := grpc.NewClient("unix://"+filepath.Join(p.pluginDir, filepath.Base(pluginapi.KubeletSocket)),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("failed to connect to kubelet: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	ctx, cancel := context.WithTimeout(context.Background(), registerTimeout)
+	defer cancel()
+	_, err = pluginapi.NewRegistrationClient(conn).Register(ctx, &pluginapi.RegisterRequest{
+		Version:      pluginapi.Version,
+		Endpoint:     filepath.Base(p.socketPath()),
+		ResourceName: p.resource.name,
+		Options:      &pluginapi.DevicePluginOptions{},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register with kubelet: %w", err)
+	}
+	return nil
+}
+
+// serving reports whether the plugin is up and its socket still there.
+func (p *plugin) serving() bool {
+	p.mu.Lock()
+	server := p.server
+	p.mu.Unlock()
+	if server == nil {
+		return false
+	}
+	_, err := os.Stat(p.socketPath())
+	return err == nil
+}
+
+func (p *plugin) stop() {
+	p.mu.Lock()
+	server := p.server
+	p.server = nil
+	p.mu.Unlock()
+	if server == nil {
+		return
+	}
+	server.Stop()
+	_ = os.Remove(p.socketPath())
+}
+
+// refreshHealth rereads the state of the processors and notifies ListAndWatch of changes.
+func (p *plugin) refreshHealth() {
+	health := map[string]string{}
+	for _, processor := range p.resource.processors {
+		health[deviceID(processor)] = deviceHealth(processor)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if maps.Equal(health, p.health) {
+		return
+	}
+	p.health = health
+	for watcher := range p.watchers {
+		select {
+		case watcher <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *plugin) devices() []*pluginapi.Device {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	devices := make([]*pluginapi.Device, 0, len(p.resource.processors))
+	for _, processor := range p.resource.processors {
+		id := deviceID(processor)
+		devices = append(devices, &pluginapi.Device{ID: id, Health: p.health[id]})
+	}
+	return devices
+}
+
+func (p *plugin) GetDevicePluginOptions(context.Context, *pluginapi.Empty) (*pluginapi.DevicePluginOptions, error) {
+	return &pluginapi.DevicePluginOptions{}, nil
+}
+
+func (p *plugin) ListAndWatch(_ *pluginapi.Empty, stream pluginapi.DevicePlugin_ListAndWatchServer) error {
+	changed := make(chan struct{}, 1)
+	p.mu.Lock()
+	p.watchers[changed] = struct{}{}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.watchers, changed)
+		p.mu.Unlock()
+	}()
+
+	for {
+		if err := stream.Send(&pluginapi.ListAndWatchResponse{Devices: p.devices()}); err != nil {
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+func (p *plugin) GetPreferredAllocation(context.Context, *pluginapi.PreferredAllocationRequest) (*pluginapi.PreferredAllocationResponse, error) {
+	return &pluginapi.PreferredAllocationResponse{}, nil
+}
+
+// Allocate hands each container the selection of its processor, both as the environment the
+// arm.com/remoteproc CDI devices set and as annotations.
+func (p *plugin) Allocate(_ context.Context, request *pluginapi.AllocateRequest) (*pluginapi.AllocateResponse, error) {
+	response := &pluginapi.AllocateResponse{}
+	for _, containerRequest := range request.ContainerRequests {
+		if len(containerRequest.DevicesIDs) != 1 {
+			return nil, fmt.Errorf("a container runs on exactly one remote processor, %d requested", len(containerRequest.DevicesIDs))
+		}
+		processor, err := p.processor(containerRequest.DevicesIDs[0])
+		if err != nil {
+			return nil, err
+		}
+		info, err := processor.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe remote processor %s: %w", processor.Name(), err)
+		}
+		index := strconv.Itoa(info.Index)
+		response.ContainerResponses = append(response.ContainerResponses, &pluginapi.ContainerAllocateResponse{
+			Envs:        map[string]string{oci.EnvName: info.Name, oci.EnvIndex: index},
+			Annotations: map[string]string{oci.SpecName: info.Name, oci.SpecIndex: index},
+		})
+	}
+	return response, nil
+}
+
+func (p *plugin) PreStartContainer(context.Context, *pluginapi.PreStartContainerRequest) (*pluginapi.PreStartContainerResponse, error) {
+	return &pluginapi.PreStartContainerResponse{}, nil
+}
+
+func (p *plugin) processor(id string) (remoteproc.Processor, error) {
+	for _, processor := range p.resource.processors {
+		if deviceID(processor) == id {
+			return processor, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown device %s", id)
+}
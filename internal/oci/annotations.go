@@ -2,28 +2,167 @@ package oci
 
 import (
 	"fmt"
+	"maps"
+	"strings"
 
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
 const (
-	SpecName = "remoteproc.name"
+	SpecName           = "remoteproc.name"
+	SpecNameGlob       = "remoteproc.name-glob"
+	SpecNameRegex      = "remoteproc.name-regex"
+	SpecDeviceTreeNode = "remoteproc.of-node"
+	SpecParentDevice   = "remoteproc.device"
+	SpecIndex          = "remoteproc.index"
+	SpecPool           = "remoteproc.pool"
+	SpecArch           = "remoteproc.arch"
+	SpecResourceTable  = "remoteproc.resource-table"
+	SpecRPMsgEndpoints = "remoteproc.rpmsg.endpoints"
+	SpecCDIRefresh     = "remoteproc.cdi.refresh"
+	// SpecShutdownChannel names the RPMsg endpoint or channel a shutdown message is sent over
+	// before the processor is stopped, enabling the graceful shutdown handshake. Its grace
+	// period, SpecShutdownTimeout, is recorded in the state for kill to wait for.
+	SpecShutdownChannel = "remoteproc.shutdown.channel"
+	SpecShutdownMessage = "remoteproc.shutdown.message"
+	SpecShutdownTimeout = "remoteproc.shutdown.timeout"
+	// SpecReadyRunningFor, SpecReadyRPMsgChannel and SpecReadyTrace are readiness conditions
+	// start waits for, at most SpecReadyTimeout. They are recorded in the state as is.
+	SpecReadyRunningFor   = "remoteproc.ready.running-for"
+	SpecReadyRPMsgChannel = "remoteproc.ready.rpmsg-channel"
+	SpecReadyTrace        = "remoteproc.ready.trace"
+	SpecReadyTimeout      = "remoteproc.ready.timeout"
+	// SpecHealthInterval, SpecHealthRPMsgChannel, SpecHealthPing, SpecHealthTimeout and
+	// SpecHealthHeartbeat configure the health check the proxy runs; setting any enables it.
+	SpecHealthInterval     = "remoteproc.health.interval"
+	SpecHealthRPMsgChannel = "remoteproc.health.rpmsg-channel"
+	SpecHealthPing         = "remoteproc.health.ping"
+	SpecHealthTimeout      = "remoteproc.health.timeout"
+	SpecHealthHeartbeat    = "remoteproc.health.heartbeat"
+	// SpecRestartPolicy has the proxy restart firmware that stops by itself, waiting
+	// SpecRestartBackoff before the first restart in a row.
+	SpecRestartPolicy  = "remoteproc.restart"
+	SpecRestartBackoff = "remoteproc.restart.backoff"
+	// SpecCoredump sets the processor's coredump mode; unless disabled, the proxy saves the
+	// dumps of crashed firmware to SpecCoredumpDir, or else the container's state directory.
+	SpecCoredump    = "remoteproc.coredump"
+	SpecCoredumpDir = "remoteproc.coredump.dir"
+	// SpecRecovery sets whether the kernel recovers crashed firmware: enabled, disabled, or
+	// recover-once, for the first crash only.
+	SpecRecovery = "remoteproc.recovery"
+	// SpecAttach set to true adopts the firmware already running on the processor, e.g. booted
+	// by the bootloader, rather than load the container's. It is recorded in the state as is.
+	SpecAttach = "remoteproc.attach"
 
 	StateDriverPath   = "remoteproc.driver-path"
 	StateFirmwarePath = "remoteproc.firmware-path"
 
 	OptionalStateStoredFirmwarePath = "remoteproc.stored-firmware-path"
+
+	OptionalStateResourceTableVersion   = "remoteproc.resource-table.version"
+	OptionalStateResourceTableCarveouts = "remoteproc.resource-table.carveouts"
+	OptionalStateResourceTableDevMems   = "remoteproc.resource-table.devmems"
+	OptionalStateResourceTableTraces    = "remoteproc.resource-table.traces"
+	OptionalStateResourceTableVdevs     = "remoteproc.resource-table.vdevs"
+
+	// OptionalStateRPMsgDevices lists the RPMsg channels of a running container's firmware.
+	OptionalStateRPMsgDevices = "remoteproc.rpmsg.devices"
+	// OptionalStateRPMsgEndpoints lists the endpoints created from SpecRPMsgEndpoints.
+	OptionalStateRPMsgEndpoints = "remoteproc.rpmsg.endpoint-devices"
+	// OptionalStateHealth is the outcome of the last health check, one of the Health values.
+	OptionalStateHealth = "remoteproc.health.status"
+	// OptionalStateHealthFailure explains why the last health check failed.
+	OptionalStateHealthFailure = "remoteproc.health.failure"
+	// OptionalStateRestartCount is how often the proxy restarted the firmware.
+	OptionalStateRestartCount = "remoteproc.restart.count"
+	// OptionalStateRestartLastCrash is when the firmware last crashed, in RFC 3339 format.
+	OptionalStateRestartLastCrash = "remoteproc.restart.last-crash"
+	// OptionalStateCoredump is the path of the last coredump the proxy saved.
+	OptionalStateCoredump = "remoteproc.coredump.path"
+	// OptionalStateCoredumpCount is how many coredumps the proxy saved.
+	OptionalStateCoredumpCount = "remoteproc.coredump.count"
+	// OptionalStateCrashReason, OptionalStateCrashType, OptionalStateCrashTime and
+	// OptionalStateCrashKernelLog describe the last crash of the firmware: what the kernel said
+	// about it, the crash type it reported, when, and the processor's kernel log leading to it.
+	OptionalStateCrashReason    = "remoteproc.crash.reason"
+	OptionalStateCrashType      = "remoteproc.crash.type"
+	OptionalStateCrashTime      = "remoteproc.crash.time"
+	OptionalStateCrashKernelLog = "remoteproc.crash.kernel-log"
+	// OptionalStateDetachTime is when the container detached from its firmware, left running
+	// for a container to attach to, in RFC 3339 format.
+	OptionalStateDetachTime = "remoteproc.detach.time"
+)
+
+// Health values of OptionalStateHealth, named like Docker's.
+const (
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// Environment variables injected by the arm.com/remoteproc CDI devices, standing in for the
+// selector annotations, since CDI can't set annotations.
+const (
+	EnvName  = "REMOTEPROC_NAME"
+	EnvIndex = "REMOTEPROC_INDEX"
 )
 
+// SpecSelectors lists the annotations selecting the target processor; at least one is required.
+var SpecSelectors = []string{SpecName, SpecNameGlob, SpecNameRegex, SpecDeviceTreeNode, SpecParentDevice, SpecIndex}
+
+// envSelectors maps the environment variables CDI devices inject to the annotations they stand for.
+var envSelectors = map[string]string{
+	EnvName:  SpecName,
+	EnvIndex: SpecIndex,
+}
+
 func validateSpecAnnotations(spec *specs.Spec) error {
-	err := validateAnnotationsExist(spec.Annotations, SpecName)
-	if err != nil {
-		return fmt.Errorf("invalid container specification: %w", err)
+	for _, key := range SpecSelectors {
+		if _, ok := spec.Annotations[key]; ok {
+			return nil
+		}
 	}
+	return fmt.Errorf("invalid container specification: missing %s in annotations (or one of %s, or an arm.com/remoteproc CDI device)", SpecName, strings.Join(SpecSelectors[1:], ", "))
+}
+
+// applyEnvSelectors turns the processor selection of a CDI device into annotations, unless the
+// container selects its processor through annotations already.
+func applyEnvSelectors(spec *specs.Spec) error {
+	if spec.Process == nil {
+		return nil
+	}
+	for _, key := range SpecSelectors {
+		if _, ok := spec.Annotations[key]; ok {
+			return nil
+		}
+	}
+	selectors := map[string]string{}
+	for _, env := range spec.Process.Env {
+		name, value, _ := strings.Cut(env, "=")
+		annotation, ok := envSelectors[name]
+		if !ok {
+			continue
+		}
+		if existing, ok := selectors[annotation]; ok && existing != value {
+			return fmt.Errorf("invalid container specification: several processors requested through CDI devices (%s=%s and %s=%s)", name, existing, name, value)
+		}
+		selectors[annotation] = value
+	}
+	if len(selectors) == 0 {
+		return nil
+	}
+	if spec.Annotations == nil {
+		spec.Annotations = map[string]string{}
+	}
+	maps.Copy(spec.Annotations, selectors)
 	return nil
 }
 
 func validateStateAnnotations(state *specs.State) error {
+	// Containers attached to running firmware have no firmware file of their own.
+	if state.Annotations[SpecAttach] == "true" {
+		return validateAnnotationsExist(state.Annotations, StateDriverPath)
+	}
 	return validateAnnotationsExist(state.Annotations, StateDriverPath, StateFirmwarePath)
 }
 
@@ -0,0 +1,32 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+const controlSocketsDirName = "control"
+
+// ControlSocketPath returns where the container's proxy listens for control requests. Socket
+// paths are limited to 108 bytes, which a container ID of 64 alone nearly fills, so sockets are
+// named after a hash of it, in a directory of their own.
+func ControlSocketPath(containerID string) (string, error) {
+	sum := sha256.Sum256([]byte(containerID))
+	return runtimePath(controlSocketsDirName, hex.EncodeToString(sum[:8])+".sock")
+}
+
+// RemoveControlSocket removes the container's control socket, which outlives its proxy.
+func RemoveControlSocket(containerID string) error {
+	socketPath, err := ControlSocketPath(containerID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove control socket: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package oci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+const claimLockFileName = "claim.lock"
+
+// LockClaims takes an exclusive lock shared by every runtime invocation, so that choosing a
+// processor and recording that choice in the container state happen atomically with respect
+// to concurrent creates. Call the returned function to release the lock.
+func LockClaims() (func(), error) {
+	lockFilePath, err := runtimePath(claimLockFileName)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(lockFilePath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	f, err := os.OpenFile(lockFilePath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", lockFilePath, err)
+	}
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", lockFilePath, err)
+	}
+	return func() {
+		_ = unix.Flock(int(f.Fd()), unix.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
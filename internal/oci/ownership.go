@@ -0,0 +1,128 @@
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const processorLocksDirName = "processors"
+
+// ProcessorOwnedError reports a processor whose ownership lock is held by a live proxy.
+type ProcessorOwnedError struct {
+	DevicePath string
+	Owner      string
+}
+
+func (e *ProcessorOwnedError) Error() string {
+	return fmt.Sprintf("processor %s is owned by container %s", e.DevicePath, e.Owner)
+}
+
+// ProcessorLock is an exclusive claim on a processor. It is backed by a per-device lock file
+// recording the owning container, and held via flock for as long as any process keeps File open.
+// Handing File to the proxy ties ownership to the proxy's lifetime, so a dead proxy's claim can
+// be told apart from a live one.
+type ProcessorLock struct {
+	file          *os.File
+	path          string
+	previousOwner string
+}
+
+// TryLockProcessor takes the ownership lock of devicePath without blocking. It returns
+// *ProcessorOwnedError if another process holds the lock.
+func TryLockProcessor(devicePath string) (*ProcessorLock, error) {
+	lockFilePath, err := processorLockPath(devicePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(lockFilePath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create processor locks directory: %w", err)
+	}
+	f, err := os.OpenFile(lockFilePath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", lockFilePath, err)
+	}
+	lockErr := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if lockErr != nil && !errors.Is(lockErr, unix.EWOULDBLOCK) {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", lockFilePath, lockErr)
+	}
+	owner, err := readOwner(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if lockErr != nil {
+		_ = f.Close()
+		return nil, &ProcessorOwnedError{DevicePath: devicePath, Owner: owner}
+	}
+	return &ProcessorLock{file: f, path: lockFilePath, previousOwner: owner}, nil
+}
+
+// PreviousOwner returns the container recorded in the lock file when it was taken. A non-empty
+// value means the lock was left behind by a proxy that is no longer running.
+func (l *ProcessorLock) PreviousOwner() string {
+	return l.previousOwner
+}
+
+// Claim records containerID as the processor's owner.
+func (l *ProcessorLock) Claim(containerID string) error {
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate lock file %s: %w", l.path, err)
+	}
+	if _, err := l.file.WriteAt([]byte(containerID), 0); err != nil {
+		return fmt.Errorf("failed to write lock file %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// File returns the open lock file; the lock stays held while any duplicate of it is open.
+func (l *ProcessorLock) File() *os.File {
+	return l.file
+}
+
+func (l *ProcessorLock) Close() {
+	_ = l.file.Close()
+}
+
+// ReleaseProcessor removes the lock file of devicePath if it still names containerID as owner.
+// Callers must hold LockClaims so a concurrent claim can't slip in between check and removal.
+func ReleaseProcessor(devicePath string, containerID string) error {
+	lockFilePath, err := processorLockPath(devicePath)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(lockFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read lock file %s: %w", lockFilePath, err)
+	}
+	if strings.TrimSpace(string(content)) != containerID {
+		return nil
+	}
+	if err := os.Remove(lockFilePath); err != nil {
+		return fmt.Errorf("failed to remove lock file %s: %w", lockFilePath, err)
+	}
+	return nil
+}
+
+func processorLockPath(devicePath string) (string, error) {
+	name := strings.ReplaceAll(strings.TrimPrefix(filepath.Clean(devicePath), "/"), "/", "_") + ".lock"
+	return runtimePath(processorLocksDirName, name)
+}
+
+func readOwner(f *os.File) (string, error) {
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to read lock file %s: %w", f.Name(), err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
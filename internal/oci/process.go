@@ -0,0 +1,37 @@
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// ReadProcess reads the process spec an engine passes to exec with --process, as podman does
+// for health checks.
+func ReadProcess(processPath string) (*specs.Process, error) {
+	content, err := os.ReadFile(processPath)
+	if err != nil {
+		return nil, err
+	}
+	var process specs.Process
+	if err := json.Unmarshal(content, &process); err != nil {
+		return nil, fmt.Errorf("invalid process spec %s: %w", processPath, err)
+	}
+	if len(process.Args) == 0 {
+		return nil, fmt.Errorf("process spec %s has no arguments", processPath)
+	}
+	return &process, nil
+}
+
+// UnwrapShell returns the command a shell form exec, e.g. Docker's --health-cmd, would have a
+// shell run, there being no shell to run it.
+func UnwrapShell(args []string) []string {
+	if len(args) == 3 && path.Base(args[0]) == "sh" && args[1] == "-c" {
+		return strings.Fields(args[2])
+	}
+	return args
+}
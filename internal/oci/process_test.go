@@ -0,0 +1,55 @@
+package oci_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadProcess(t *testing.T) {
+	t.Run("it returns the process spec passed to exec", func(t *testing.T) {
+		processPath := writeProcess(t, `{"args": ["health"], "cwd": "/"}`)
+
+		process, err := oci.ReadProcess(processPath)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"health"}, process.Args)
+	})
+
+	t.Run("it errors if the process has no arguments", func(t *testing.T) {
+		processPath := writeProcess(t, `{"cwd": "/"}`)
+
+		_, err := oci.ReadProcess(processPath)
+
+		assert.ErrorContains(t, err, "has no arguments")
+	})
+
+	t.Run("it errors if the process spec isn't JSON", func(t *testing.T) {
+		processPath := writeProcess(t, "health")
+
+		_, err := oci.ReadProcess(processPath)
+
+		assert.ErrorContains(t, err, "invalid process spec")
+	})
+}
+
+func TestUnwrapShell(t *testing.T) {
+	t.Run("it returns the command a shell would run", func(t *testing.T) {
+		assert.Equal(t, []string{"health"}, oci.UnwrapShell([]string{"/bin/sh", "-c", "health"}))
+	})
+
+	t.Run("it leaves exec form arguments alone", func(t *testing.T) {
+		assert.Equal(t, []string{"health"}, oci.UnwrapShell([]string{"health"}))
+	})
+}
+
+func writeProcess(t *testing.T, content string) string {
+	t.Helper()
+	processPath := filepath.Join(t.TempDir(), "process.json")
+	require.NoError(t, os.WriteFile(processPath, []byte(content), 0o644))
+	return processPath
+}
@@ -19,6 +19,9 @@ func ReadSpec(bundlePath string) (*specs.Spec, error) {
 	if err := json.NewDecoder(f).Decode(&s); err != nil {
 		return nil, err
 	}
+	if err := applyEnvSelectors(&s); err != nil {
+		return nil, err
+	}
 	if err := validateSpecAnnotations(&s); err != nil {
 		return nil, err
 	}
@@ -9,6 +9,7 @@ import (
 	"github.com/arm/remoteproc-runtime/internal/oci"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestReadSpec(t *testing.T) {
@@ -29,6 +30,47 @@ func TestReadSpec(t *testing.T) {
 
 		assert.NoError(t, err)
 	})
+
+	t.Run("it accepts alternative processor selectors in place of remoteproc.name", func(t *testing.T) {
+		bundlePath := generateBundle(t, &specs.Spec{
+			Annotations: map[string]string{
+				"remoteproc.of-node": "/soc/m33@4c000000",
+			},
+		})
+		_, err := oci.ReadSpec(bundlePath)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it selects the processor injected by a CDI device", func(t *testing.T) {
+		bundlePath := generateBundle(t, &specs.Spec{
+			Process: &specs.Process{Env: []string{"PATH=/bin", "REMOTEPROC_NAME=dsp", "REMOTEPROC_INDEX=2"}},
+		})
+		spec, err := oci.ReadSpec(bundlePath)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"remoteproc.name": "dsp", "remoteproc.index": "2"}, spec.Annotations)
+	})
+
+	t.Run("it prefers selector annotations over CDI devices", func(t *testing.T) {
+		bundlePath := generateBundle(t, &specs.Spec{
+			Process:     &specs.Process{Env: []string{"REMOTEPROC_NAME=dsp"}},
+			Annotations: map[string]string{"remoteproc.of-node": "/soc/m33@4c000000"},
+		})
+		spec, err := oci.ReadSpec(bundlePath)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"remoteproc.of-node": "/soc/m33@4c000000"}, spec.Annotations)
+	})
+
+	t.Run("it errors if CDI devices of several processors are requested", func(t *testing.T) {
+		bundlePath := generateBundle(t, &specs.Spec{
+			Process: &specs.Process{Env: []string{"REMOTEPROC_NAME=dsp", "REMOTEPROC_NAME=m33"}},
+		})
+		_, err := oci.ReadSpec(bundlePath)
+
+		assert.ErrorContains(t, err, "several processors requested through CDI devices (REMOTEPROC_NAME=dsp and REMOTEPROC_NAME=m33)")
+	})
 }
 
 func generateBundle(t *testing.T, spec *specs.Spec) string {
@@ -2,9 +2,12 @@ package oci
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/arm/remoteproc-runtime/internal/userdirs"
@@ -13,6 +16,9 @@ import (
 
 const (
 	stateFileName = "state.json"
+	// runtimeDirName holds the runtime's own files, next to the containers' state directories.
+	// Container IDs can't start with a dot, so no container takes it.
+	runtimeDirName = ".runtime"
 )
 
 var (
@@ -32,6 +38,32 @@ func getStateDir() (string, error) {
 	return cachedStateDir, cachedStateDirErr
 }
 
+// ValidateContainerID rejects IDs that can't name a directory of the state root of their own.
+func ValidateContainerID(containerID string) error {
+	if containerID == "" || strings.HasPrefix(containerID, ".") || strings.ContainsRune(containerID, filepath.Separator) {
+		return fmt.Errorf("invalid container ID %q: must not be empty, start with a dot or contain a slash", containerID)
+	}
+	return nil
+}
+
+// runtimePath returns the path of one of the runtime's own files in the state root.
+func runtimePath(segments ...string) (string, error) {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{stateDir, runtimeDirName}, segments...)...), nil
+}
+
+// StateDir returns the directory holding the container's state.
+func StateDir(containerID string) (string, error) {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, containerID), nil
+}
+
 func NewState(containerID string, bundlePath string) *specs.State {
 	return &specs.State{
 		Version:     specs.Version,
@@ -98,6 +130,34 @@ func ReadState(containerID string) (*specs.State, error) {
 	return &s, nil
 }
 
+// ListStates returns the state of every container known to the runtime, skipping
+// entries whose state can't be read.
+func ListStates() ([]*specs.State, error) {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(stateDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state directory %s: %w", stateDir, err)
+	}
+	states := []*specs.State{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		state, err := ReadState(entry.Name())
+		if err != nil {
+			continue
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
 func RemoveState(containerID string) error {
 	stateDir, err := getStateDir()
 	if err != nil {
@@ -107,5 +167,5 @@ func RemoveState(containerID string) error {
 	if err := os.RemoveAll(containerStateDir); err != nil {
 		return fmt.Errorf("cannot remove container state dir: %w", err)
 	}
-	return nil
+	return RemoveControlSocket(containerID)
 }
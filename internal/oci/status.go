@@ -0,0 +1,70 @@
+package oci
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const proxyStatusFileName = "status.json"
+
+// ProxyStatus is what a container's proxy reports about the firmware it runs. The proxy keeps
+// it in a file of its own next to the state, which only the runtime commands write.
+type ProxyStatus struct {
+	// Annotations are merged into the container's state annotations.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// RPMsgEndpoints are the device nodes of the endpoints the proxy created.
+	RPMsgEndpoints []string `json:"rpmsgEndpoints,omitempty"`
+}
+
+func WriteProxyStatus(containerID string, status ProxyStatus) error {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return err
+	}
+	statusJSON, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal proxy status to JSON: %w", err)
+	}
+	if err := atomicWrite(filepath.Join(stateDir, containerID, proxyStatusFileName), statusJSON); err != nil {
+		return fmt.Errorf("failed to write proxy status file: %w", err)
+	}
+	return nil
+}
+
+// ReadProxyStatus returns the status last reported by the container's proxy, which is empty
+// if the proxy hasn't reported anything.
+func ReadProxyStatus(containerID string) (ProxyStatus, error) {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return ProxyStatus{}, err
+	}
+	statusFilePath := filepath.Join(stateDir, containerID, proxyStatusFileName)
+	content, err := os.ReadFile(statusFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ProxyStatus{}, nil
+	}
+	if err != nil {
+		return ProxyStatus{}, fmt.Errorf("failed to read proxy status file: %w", err)
+	}
+	var status ProxyStatus
+	if err := json.Unmarshal(content, &status); err != nil {
+		return ProxyStatus{}, fmt.Errorf("failed to parse proxy status file %s: %w", statusFilePath, err)
+	}
+	return status, nil
+}
+
+// RemoveProxyStatus removes the status a previous proxy of the container reported, if any.
+func RemoveProxyStatus(containerID string) error {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(stateDir, containerID, proxyStatusFileName)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove proxy status file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"sync"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// consoleBridge connects the container's terminal to the firmware's RPMsg TTY. The TTY only
+// appears once the firmware has announced its rpmsg-tty endpoint, and goes away with the
+// firmware, so it is looked for on every poll while none is connected.
+type consoleBridge struct {
+	logger    *slog.Logger
+	processor remoteproc.Processor
+	console   io.ReadWriter
+
+	inputOnce sync.Once
+	mu        sync.Mutex
+	tty       io.ReadWriteCloser
+}
+
+func newConsoleBridge(logger *slog.Logger, processor remoteproc.Processor, console io.ReadWriter) *consoleBridge {
+	return &consoleBridge{logger: logger, processor: processor, console: console}
+}
+
+// poll connects the first RPMsg TTY of the firmware if none is connected yet.
+func (b *consoleBridge) poll() {
+	if b.console == nil {
+		return
+	}
+	b.inputOnce.Do(func() { go b.forwardInput() })
+
+	b.mu.Lock()
+	connected := b.tty != nil
+	b.mu.Unlock()
+	if connected {
+		return
+	}
+	names, err := b.processor.TTYs()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		b.logger.Debug("failed to list RPMsg TTYs", "error", err)
+	}
+	if len(names) == 0 {
+		return
+	}
+	tty, err := b.processor.OpenTTY(names[0])
+	if err != nil {
+		b.logger.Debug("failed to open RPMsg TTY", "tty", names[0], "error", err)
+		return
+	}
+	b.logger.Debug("connected console", "tty", names[0])
+	b.mu.Lock()
+	b.tty = tty
+	b.mu.Unlock()
+	go b.forwardOutput(tty)
+}
+
+// forwardOutput copies the firmware's output to the console until the TTY goes away.
+func (b *consoleBridge) forwardOutput(tty io.ReadWriteCloser) {
+	_, err := io.Copy(b.console, tty)
+	b.logger.Debug("console disconnected", "error", err)
+	b.mu.Lock()
+	if b.tty == tty {
+		b.tty = nil
+	}
+	b.mu.Unlock()
+	_ = tty.Close()
+}
+
+// forwardInput copies console input to the connected TTY. Input typed while no TTY is
+// connected is dropped, as it would be on a serial line without a listener.
+func (b *consoleBridge) forwardInput() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := b.console.Read(buf)
+		if n > 0 {
+			b.mu.Lock()
+			tty := b.tty
+			b.mu.Unlock()
+			if tty != nil {
+				_, _ = tty.Write(buf[:n])
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// close disconnects the TTY, which ends forwardOutput.
+func (b *consoleBridge) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tty != nil {
+		_ = b.tty.Close()
+		b.tty = nil
+	}
+}
@@ -0,0 +1,87 @@
+package proxy_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc/remoteproctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBridgesConsole(t *testing.T) {
+	t.Run("connects the console to the firmware's RPMsg TTY", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddTTY("ttyRPMSG0")
+		console := newTestConsole(t)
+		startProxy(t, processor, proxy.RunOptions{Console: console})
+		waitUntilTTYOpen(t, processor, "ttyRPMSG0")
+
+		require.NoError(t, processor.WriteTTY("ttyRPMSG0", "uart:~$ "))
+		assertOutput(t, console.output, "uart:~$ ")
+		_, err := console.input.Write([]byte("help\r"))
+		require.NoError(t, err)
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.Equal(c, "help\r", processor.TTYInput("ttyRPMSG0"))
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("reconnects once the firmware comes back", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddTTY("ttyRPMSG0")
+		console := newTestConsole(t)
+		startProxy(t, processor, proxy.RunOptions{Console: console})
+		waitUntilTTYOpen(t, processor, "ttyRPMSG0")
+
+		// Recovery reboots a crashed firmware behind the proxy's back.
+		processor.Crash()
+		require.NoError(t, processor.Stop())
+		require.NoError(t, processor.Start())
+		waitUntilTTYOpen(t, processor, "ttyRPMSG0")
+
+		require.NoError(t, processor.WriteTTY("ttyRPMSG0", "booted again\r\n"))
+		assertOutput(t, console.output, "booted again\r\n")
+	})
+
+	t.Run("leaves the TTY alone without a console", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddTTY("ttyRPMSG0")
+		startProxy(t, processor, proxy.RunOptions{})
+
+		time.Sleep(50 * time.Millisecond)
+
+		assert.False(t, processor.TTYOpen("ttyRPMSG0"))
+	})
+}
+
+type testConsole struct {
+	input  *io.PipeWriter
+	reader *io.PipeReader
+	output *lockedBuffer
+}
+
+func (c *testConsole) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+func (c *testConsole) Write(p []byte) (int, error) {
+	return c.output.Write(p)
+}
+
+// newTestConsole returns a console whose input is closed once the test ends.
+func newTestConsole(t *testing.T) *testConsole {
+	t.Helper()
+	reader, input := io.Pipe()
+	t.Cleanup(func() { _ = input.Close() })
+	return &testConsole{input: input, reader: reader, output: &lockedBuffer{}}
+}
+
+func waitUntilTTYOpen(t *testing.T, processor *remoteproctest.Processor, name string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return processor.TTYOpen(name)
+	}, time.Second, time.Millisecond)
+}
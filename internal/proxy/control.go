@@ -0,0 +1,198 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// Commands the proxy serves on its control socket, standing in for the lifecycle signals while
+// replying with the outcome.
+const (
+	// ControlStart starts the processor, like SIGUSR1.
+	ControlStart = "start"
+	// ControlStop stops the processor and has the proxy exit, like SIGTERM.
+	ControlStop = "stop"
+	// ControlDetach detaches the processor and has the proxy exit, like SIGUSR2.
+	ControlDetach = "detach"
+	// ControlStatus reports the processor's state along with the proxy's status.
+	ControlStatus = "status"
+)
+
+const (
+	// startTimeout bounds booting the firmware, which the kernel loads synchronously.
+	startTimeout = time.Minute
+	// detachTimeout bounds detaching from the firmware.
+	detachTimeout = 5 * time.Second
+)
+
+var controlCommands = []string{ControlStart, ControlStop, ControlDetach, ControlStatus}
+
+var (
+	// ErrNoControlSocket is returned for containers whose proxy doesn't serve a control socket.
+	ErrNoControlSocket = errors.New("proxy has no control socket")
+	// ErrProxyExited is returned once the proxy is gone, leaving its control socket behind.
+	ErrProxyExited = errors.New("proxy exited")
+)
+
+type controlRequest struct {
+	Command string `json:"command"`
+}
+
+// ControlReply answers a control request.
+type ControlReply struct {
+	// Error tells why the request failed; empty if it succeeded.
+	Error string `json:"error,omitempty"`
+	// State is the processor's state as the proxy replied.
+	State remoteproc.State `json:"state,omitempty"`
+	// Status is what the proxy reports about the firmware; nil until it started the processor.
+	Status *oci.ProxyStatus `json:"status,omitempty"`
+}
+
+// controlCall is a control request handed over to Run, which answers it on reply.
+type controlCall struct {
+	command string
+	reply   chan ControlReply
+}
+
+func (c controlCall) answer(processor remoteproc.Processor, status *statusPublisher, err error) {
+	var reply ControlReply
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	if state, err := processor.State(); err == nil {
+		reply.State = state
+	}
+	if status != nil {
+		current := status.current()
+		reply.Status = &current
+	}
+	c.reply <- reply
+}
+
+// ListenControl creates the container's control socket, returning it as a file for the proxy
+// to inherit. Created before the proxy runs, the socket accepts requests as soon as create
+// returns, queueing them until the proxy gets to them. It stays in place once the proxy is gone,
+// until the container is deleted.
+func ListenControl(containerID string) (*os.File, error) {
+	socketPath, err := oci.ControlSocketPath(containerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create control socket directory: %w", err)
+	}
+	// Left behind by a deleted container of the same ID whose state was removed by hand.
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale control socket: %w", err)
+	}
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	listener.SetUnlinkOnClose(false)
+	defer func() { _ = listener.Close() }()
+	file, err := listener.File()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get control socket file: %w", err)
+	}
+	return file, nil
+}
+
+// serveControl reads one request from each connection accepted on listener and hands it over
+// to calls, until done is closed.
+func serveControl(logger *slog.Logger, listener net.Listener, calls chan<- controlCall, done <-chan struct{}) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-done:
+			default:
+				logger.Error("failed to accept control connection", "error", err)
+			}
+			return
+		}
+		go func() {
+			defer func() { _ = conn.Close() }()
+			reply := handleControl(conn, calls, done)
+			if err := json.NewEncoder(conn).Encode(reply); err != nil {
+				logger.Debug("failed to reply to control request", "error", err)
+			}
+		}()
+	}
+}
+
+func handleControl(conn net.Conn, calls chan<- controlCall, done <-chan struct{}) ControlReply {
+	var request controlRequest
+	if err := json.NewDecoder(conn).Decode(&request); err != nil {
+		return ControlReply{Error: fmt.Sprintf("invalid control request: %v", err)}
+	}
+	if !slices.Contains(controlCommands, request.Command) {
+		return ControlReply{Error: fmt.Sprintf("unknown control command %q", request.Command)}
+	}
+	call := controlCall{command: request.Command, reply: make(chan ControlReply, 1)}
+	select {
+	case calls <- call:
+	case <-done:
+		return ControlReply{Error: ErrProxyExited.Error()}
+	}
+	select {
+	case reply := <-call.reply:
+		return reply
+	case <-done:
+		return ControlReply{Error: ErrProxyExited.Error()}
+	}
+}
+
+// SendControl sends command to the container's proxy and waits at most timeout for its reply,
+// returning the error it replied with, if any.
+func SendControl(containerID string, command string, timeout time.Duration) (ControlReply, error) {
+	socketPath, err := oci.ControlSocketPath(containerID)
+	if err != nil {
+		return ControlReply{}, err
+	}
+	conn, err := net.DialTimeout("unix", socketPath, timeout)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ControlReply{}, ErrNoControlSocket
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ControlReply{}, ErrProxyExited
+	}
+	if err != nil {
+		return ControlReply{}, fmt.Errorf("failed to connect to proxy: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return ControlReply{}, fmt.Errorf("failed to set control deadline: %w", err)
+	}
+	if err := json.NewEncoder(conn).Encode(controlRequest{Command: command}); err != nil {
+		return ControlReply{}, fmt.Errorf("failed to send %s request: %w", command, err)
+	}
+	var reply ControlReply
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil {
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return ControlReply{}, fmt.Errorf("proxy didn't reply to %s within %s", command, timeout)
+		}
+		// The proxy died before replying.
+		return ControlReply{}, fmt.Errorf("no reply to %s: %w", command, ErrProxyExited)
+	}
+	switch reply.Error {
+	case "":
+		return reply, nil
+	case ErrProxyExited.Error():
+		return reply, ErrProxyExited
+	default:
+		return reply, errors.New(reply.Error)
+	}
+}
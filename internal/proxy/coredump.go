@@ -0,0 +1,130 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// coredumpMetadata describes a saved coredump, in a JSON file next to it.
+type coredumpMetadata struct {
+	Processor  string    `json:"processor"`
+	DevicePath string    `json:"devicePath"`
+	Firmware   string    `json:"firmware,omitempty"`
+	SavedAt    time.Time `json:"savedAt"`
+	Size       int64     `json:"size"`
+	Coredump   string    `json:"coredump"`
+}
+
+// coredumpCollector saves the dumps the kernel makes of the crashed firmware to dir, before they
+// expire, and publishes where the last one went.
+type coredumpCollector struct {
+	logger    *slog.Logger
+	processor remoteproc.Processor
+	status    *statusPublisher
+	dir       string
+	saved     int
+}
+
+func newCoredumpCollector(logger *slog.Logger, processor remoteproc.Processor, status *statusPublisher, dir string) *coredumpCollector {
+	return &coredumpCollector{
+		logger:    logger,
+		processor: processor,
+		status:    status,
+		dir:       dir,
+	}
+}
+
+// poll saves the dump the kernel holds for the processor, if any. The kernel makes it while
+// recovering the processor, whatever state the proxy last saw it in.
+func (c *coredumpCollector) poll() {
+	if c.dir == "" {
+		return
+	}
+	dump, err := c.processor.OpenCoredump()
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		c.logger.Warn("failed to open coredump", "error", err)
+		return
+	}
+	// Closing dismisses the dump, saved or not, so the next one isn't mistaken for it.
+	defer func() {
+		if err := dump.Close(); err != nil {
+			c.logger.Warn("failed to dismiss coredump", "error", err)
+		}
+	}()
+	path, err := c.save(dump)
+	if err != nil {
+		c.logger.Error("failed to save coredump", "error", err)
+		return
+	}
+	c.logger.Info("saved firmware coredump", "path", path)
+	c.status.update(func(status *oci.ProxyStatus) {
+		status.Annotations[oci.OptionalStateCoredump] = path
+		status.Annotations[oci.OptionalStateCoredumpCount] = strconv.Itoa(c.saved)
+	})
+}
+
+// started forgets where the coredump of the previous run of firmware which was started again
+// went. The count keeps covering all runs.
+func (c *coredumpCollector) started() {
+	if c.saved == 0 {
+		return
+	}
+	c.status.update(func(status *oci.ProxyStatus) {
+		delete(status.Annotations, oci.OptionalStateCoredump)
+	})
+}
+
+func (c *coredumpCollector) save(dump io.Reader) (string, error) {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create coredump directory: %w", err)
+	}
+	savedAt := time.Now().UTC()
+	name := fmt.Sprintf("coredump-%s-%d", savedAt.Format("20060102T150405Z"), c.saved+1)
+	path := filepath.Join(c.dir, name+".core")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	size, err := io.Copy(file, dump)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	c.saved++
+
+	metadata := coredumpMetadata{
+		Processor:  c.processor.Name(),
+		DevicePath: c.processor.DevicePath(),
+		SavedAt:    savedAt,
+		Size:       size,
+		Coredump:   path,
+	}
+	if info, err := c.processor.Info(); err == nil {
+		metadata.Firmware = info.Firmware
+	}
+	metadataJSON, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal coredump metadata to JSON: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, name+".json"), metadataJSON, 0o644); err != nil {
+		c.logger.Warn("failed to write coredump metadata", "error", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,77 @@
+package proxy_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSavesCoredumps(t *testing.T) {
+	t.Run("saves the kernel's coredump with its metadata and dismisses it", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		dir := filepath.Join(t.TempDir(), "crashes")
+		status := startProxy(t, processor, proxy.RunOptions{CoredumpDir: dir}).status
+
+		processor.AddCoredump([]byte("ELF core"))
+
+		var path string
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			path = status.last().Annotations[oci.OptionalStateCoredump]
+			assert.NotEmpty(c, path)
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, dir, filepath.Dir(path))
+		assert.Equal(t, "1", status.last().Annotations[oci.OptionalStateCoredumpCount])
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "ELF core", string(content))
+		assert.Equal(t, 0, processor.Coredumps())
+
+		metadataJSON, err := os.ReadFile(path[:len(path)-len(".core")] + ".json")
+		require.NoError(t, err)
+		var metadata map[string]any
+		require.NoError(t, json.Unmarshal(metadataJSON, &metadata))
+		assert.Equal(t, "m33", metadata["processor"])
+		assert.Equal(t, "firmware.elf", metadata["firmware"])
+		assert.Equal(t, float64(len("ELF core")), metadata["size"])
+		assert.Equal(t, path, metadata["coredump"])
+	})
+
+	t.Run("forgets the last coredump once the firmware was restarted", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		status := startProxy(t, processor, proxy.RunOptions{
+			CoredumpDir: t.TempDir(),
+			Restart:     &proxy.RestartPolicy{Mode: proxy.RestartOnFailure, Backoff: time.Millisecond},
+		}).status
+
+		processor.AddCoredump([]byte("ELF core"))
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.Contains(c, status.last().Annotations, oci.OptionalStateCoredump)
+		}, time.Second, time.Millisecond)
+		processor.Crash()
+
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			annotations := status.last().Annotations
+			assert.Equal(c, "1", annotations[oci.OptionalStateRestartCount])
+			assert.NotContains(c, annotations, oci.OptionalStateCoredump)
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, "1", status.last().Annotations[oci.OptionalStateCoredumpCount])
+	})
+
+	t.Run("leaves coredumps alone without a directory", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		status := startProxy(t, processor, proxy.RunOptions{}).status
+
+		processor.AddCoredump([]byte("ELF core"))
+		time.Sleep(50 * time.Millisecond)
+
+		assert.Equal(t, 1, processor.Coredumps())
+		assert.NotContains(t, status.last().Annotations, oci.OptionalStateCoredump)
+	})
+}
@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// RecoverOnce is a recovery mode of RunOptions.Recovery, next to the kernel's, letting the
+// kernel recover the firmware from its first crash only.
+const RecoverOnce = "recover-once"
+
+// RecoveryModes lists the modes RunOptions.Recovery takes.
+var RecoveryModes = []string{remoteproc.RecoveryEnabled, remoteproc.RecoveryDisabled, RecoverOnce}
+
+const (
+	// recoveryGrace is how long the firmware may stay crashed while the kernel recovers it.
+	recoveryGrace = 10 * time.Second
+	// crashKernelLogLines bounds the kernel log recorded along with a crash.
+	crashKernelLogLines = 10
+)
+
+// crashDetected matches the message the kernel logs when firmware crashes, capturing its type,
+// e.g. "remoteproc remoteproc0: crash detected in m33: type watchdog".
+var crashDetected = regexp.MustCompile(`crash detected in .+: type (.+)$`)
+
+// crashReporter follows the processor's kernel log to tell why the firmware crashed, publishing
+// it, and keeps track of the kernel recovering the firmware.
+type crashReporter struct {
+	logger    *slog.Logger
+	processor remoteproc.Processor
+	status    *statusPublisher
+	recovery  string
+
+	disabled bool
+	recent   []string
+	// crashedAt is when the processor was first seen crashed, zero while it isn't.
+	crashedAt time.Time
+	// crashed is set from a crash until the firmware runs again, reported once the crash is.
+	crashed  bool
+	reported bool
+	// recovered is set once RecoverOnce has been used up.
+	recovered bool
+}
+
+func newCrashReporter(logger *slog.Logger, processor remoteproc.Processor, status *statusPublisher, recovery string) *crashReporter {
+	r := &crashReporter{
+		logger:    logger,
+		processor: processor,
+		status:    status,
+		recovery:  recovery,
+	}
+	// Whatever was logged before the firmware started doesn't explain its crashes.
+	if _, err := processor.ReadKernelMessages(); err != nil {
+		logger.Debug("not following kernel log", "error", err)
+		r.disabled = true
+	}
+	return r
+}
+
+// poll reports the crashes the kernel logged since the previous poll, state being the
+// processor's current state. It reports whether the kernel has just recovered the firmware.
+func (r *crashReporter) poll(state remoteproc.State) bool {
+	if !r.disabled {
+		messages, err := r.processor.ReadKernelMessages()
+		if err != nil {
+			r.logger.Debug("not following kernel log", "error", err)
+			r.disabled = true
+		}
+		for _, message := range messages {
+			r.recent = append(r.recent, message)
+			if len(r.recent) > crashKernelLogLines {
+				r.recent = r.recent[len(r.recent)-crashKernelLogLines:]
+			}
+			if match := crashDetected.FindStringSubmatch(message); match != nil {
+				r.crashed = true
+				r.report(match[1], deviceMessage(message))
+			}
+		}
+	}
+
+	switch state {
+	case remoteproc.StateCrashed:
+		r.crashed = true
+		if r.crashedAt.IsZero() {
+			r.crashedAt = time.Now()
+		}
+	case remoteproc.StateRunning, remoteproc.StateAttached:
+		if !r.crashed {
+			return false
+		}
+		// Running again after a crash, the kernel has recovered the firmware.
+		r.started()
+		if r.recovery == RecoverOnce && !r.recovered {
+			r.recovered = true
+			if err := r.processor.SetRecovery(remoteproc.RecoveryDisabled); err != nil {
+				r.logger.Error("failed to disable recovery", "error", err)
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// started forgets about the previous crash of firmware which was started again, so it isn't
+// taken for a crash of the new run.
+func (r *crashReporter) started() {
+	r.crashedAt = time.Time{}
+	r.crashed = false
+	if r.reported {
+		r.status.update(func(status *oci.ProxyStatus) {
+			delete(status.Annotations, oci.OptionalStateCrashReason)
+			delete(status.Annotations, oci.OptionalStateCrashType)
+			delete(status.Annotations, oci.OptionalStateCrashTime)
+			delete(status.Annotations, oci.OptionalStateCrashKernelLog)
+		})
+	}
+	r.reported = false
+}
+
+// recovering reports whether the firmware is crashed but expected to be recovered by the
+// kernel shortly.
+func (r *crashReporter) recovering(state remoteproc.State) bool {
+	if state != remoteproc.StateCrashed {
+		return false
+	}
+	recovers := r.recovery == remoteproc.RecoveryEnabled || (r.recovery == RecoverOnce && !r.recovered)
+	return recovers && time.Since(r.crashedAt) < recoveryGrace
+}
+
+// stopped reports the crash of firmware which is gone for good, unless the kernel already
+// explained it. Firmware which went offline, or was detached from, didn't crash.
+func (r *crashReporter) stopped(state remoteproc.State) {
+	if state == remoteproc.StateOffline || state == remoteproc.StateDetached || r.reported {
+		return
+	}
+	r.report("", fmt.Sprintf("firmware stopped, processor is %s", state))
+}
+
+func (r *crashReporter) report(crashType string, reason string) {
+	r.reported = true
+	r.logger.Warn("firmware crashed", "reason", reason)
+	r.status.update(func(status *oci.ProxyStatus) {
+		status.Annotations[oci.OptionalStateCrashReason] = reason
+		status.Annotations[oci.OptionalStateCrashTime] = time.Now().UTC().Format(time.RFC3339)
+		if crashType == "" {
+			delete(status.Annotations, oci.OptionalStateCrashType)
+		} else {
+			status.Annotations[oci.OptionalStateCrashType] = crashType
+		}
+		if len(r.recent) == 0 {
+			delete(status.Annotations, oci.OptionalStateCrashKernelLog)
+		} else {
+			status.Annotations[oci.OptionalStateCrashKernelLog] = strings.Join(r.recent, "\n")
+		}
+	})
+}
+
+// deviceMessage strips the "<driver> <device>: " prefix off a kernel message.
+func deviceMessage(message string) string {
+	if _, text, ok := strings.Cut(message, ": "); ok {
+		return text
+	}
+	return message
+}
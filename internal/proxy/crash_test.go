@@ -0,0 +1,111 @@
+package proxy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReportsCrashes(t *testing.T) {
+	t.Run("records the crash the kernel reported along with its log", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.LogKernelMessage("remoteproc remoteproc0: stale message")
+		run := startProxy(t, processor, proxy.RunOptions{})
+
+		processor.LogKernelMessage("remoteproc remoteproc0: watchdog bark")
+		processor.LogKernelMessage("remoteproc remoteproc0: crash detected in m33: type watchdog")
+		processor.Crash()
+
+		waitForExit(t, run.done, time.Second)
+		annotations := run.status.last().Annotations
+		assert.Equal(t, "crash detected in m33: type watchdog", annotations[oci.OptionalStateCrashReason])
+		assert.Equal(t, "watchdog", annotations[oci.OptionalStateCrashType])
+		assert.Equal(t, "remoteproc remoteproc0: watchdog bark\nremoteproc remoteproc0: crash detected in m33: type watchdog", annotations[oci.OptionalStateCrashKernelLog])
+		assert.Contains(t, annotations, oci.OptionalStateCrashTime)
+	})
+
+	t.Run("records the processor state when the kernel said nothing", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{})
+
+		processor.Crash()
+
+		waitForExit(t, run.done, time.Second)
+		annotations := run.status.last().Annotations
+		assert.Equal(t, "firmware stopped, processor is crashed", annotations[oci.OptionalStateCrashReason])
+		assert.NotContains(t, annotations, oci.OptionalStateCrashType)
+		assert.NotContains(t, annotations, oci.OptionalStateCrashKernelLog)
+	})
+
+	t.Run("doesn't record firmware which went offline by itself as crashed", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{})
+
+		processor.ForceState(remoteproc.StateOffline)
+
+		waitForExit(t, run.done, time.Second)
+		assert.NotContains(t, run.status.last().Annotations, oci.OptionalStateCrashReason)
+	})
+}
+
+func TestRunAwaitsKernelRecovery(t *testing.T) {
+	t.Run("keeps running while the kernel recovers crashed firmware", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{Recovery: remoteproc.RecoveryEnabled})
+
+		processor.LogKernelMessage("remoteproc remoteproc0: crash detected in m33: type fatal error")
+		processor.Crash()
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.Equal(c, "fatal error", run.status.last().Annotations[oci.OptionalStateCrashType])
+		}, time.Second, time.Millisecond)
+		processor.ForceState(remoteproc.StateRunning)
+		time.Sleep(50 * time.Millisecond)
+
+		select {
+		case <-run.done:
+			t.Fatal("proxy exited while the kernel recovered the firmware")
+		default:
+		}
+		assert.Equal(t, remoteproc.RecoveryEnabled, processor.Recovery())
+	})
+
+	t.Run("forgets the crash once the kernel recovered the firmware", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{Recovery: remoteproc.RecoveryEnabled})
+
+		processor.LogKernelMessage("remoteproc remoteproc0: crash detected in m33: type fatal error")
+		processor.Crash()
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.Contains(c, run.status.last().Annotations, oci.OptionalStateCrashReason)
+		}, time.Second, time.Millisecond)
+		processor.ForceState(remoteproc.StateRunning)
+
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			annotations := run.status.last().Annotations
+			for _, key := range []string{oci.OptionalStateCrashReason, oci.OptionalStateCrashType, oci.OptionalStateCrashTime, oci.OptionalStateCrashKernelLog} {
+				assert.NotContains(c, annotations, key)
+			}
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("disables recovery once the kernel recovered the firmware once", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{Recovery: proxy.RecoverOnce})
+
+		processor.Crash()
+		time.Sleep(50 * time.Millisecond)
+		processor.ForceState(remoteproc.StateRunning)
+		require.Eventually(t, func() bool {
+			return processor.Recovery() == remoteproc.RecoveryDisabled
+		}, time.Second, time.Millisecond)
+
+		processor.Crash()
+
+		waitForExit(t, run.done, time.Second)
+	})
+}
@@ -0,0 +1,72 @@
+package proxy_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDetachesFirmware(t *testing.T) {
+	t.Run("detaches attached firmware and exits, leaving it running", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.ForceState(remoteproc.StateDetached)
+		run := startProxy(t, processor, proxy.RunOptions{Attach: true})
+		waitForState(t, processor, remoteproc.StateAttached)
+
+		run.signals <- syscall.SIGUSR2
+
+		waitForExit(t, run.done, time.Second)
+		state, err := processor.State()
+		require.NoError(t, err)
+		assert.Equal(t, remoteproc.StateDetached, state)
+		assert.Equal(t, 0, processor.StopCount())
+	})
+
+	t.Run("keeps running firmware it can't detach", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.ForceState(remoteproc.StateRunning)
+		run := startProxy(t, processor, proxy.RunOptions{Attach: true})
+
+		run.signals <- syscall.SIGUSR2
+		time.Sleep(50 * time.Millisecond)
+
+		select {
+		case <-run.done:
+			t.Fatal("proxy exited although the firmware wasn't detached")
+		default:
+		}
+		state, err := processor.State()
+		require.NoError(t, err)
+		assert.Equal(t, remoteproc.StateRunning, state)
+	})
+}
+
+func TestRunAttachesToFirmware(t *testing.T) {
+	t.Run("adopts running firmware without starting the processor", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.ForceState(remoteproc.StateAttached)
+		run := startProxy(t, processor, proxy.RunOptions{Attach: true})
+		time.Sleep(50 * time.Millisecond)
+
+		select {
+		case <-run.done:
+			t.Fatal("proxy exited although the firmware runs")
+		default:
+		}
+		assert.Equal(t, 0, processor.StartCount())
+		assert.Equal(t, 1, processor.AttachCount())
+	})
+
+	t.Run("fails without firmware to attach to", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{Attach: true})
+
+		waitForExit(t, run.done, time.Second)
+		assert.Equal(t, 0, processor.StartCount())
+	})
+}
@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"strings"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// endpointCreator creates the requested RPMsg endpoints once the firmware's RPMsg control
+// device appears, and destroys them before the processor stops.
+type endpointCreator struct {
+	logger    *slog.Logger
+	processor remoteproc.Processor
+	status    *statusPublisher
+	requested []remoteproc.RPMsgEndpoint
+	pending   []remoteproc.RPMsgEndpoint
+	created   []remoteproc.RPMsgEndpoint
+}
+
+func newEndpointCreator(logger *slog.Logger, processor remoteproc.Processor, status *statusPublisher, endpoints []remoteproc.RPMsgEndpoint) *endpointCreator {
+	return &endpointCreator{
+		logger:    logger,
+		processor: processor,
+		status:    status,
+		requested: endpoints,
+		pending:   endpoints,
+	}
+}
+
+// poll creates the endpoints not created yet.
+func (c *endpointCreator) poll() {
+	if len(c.pending) == 0 {
+		return
+	}
+	var pending []remoteproc.RPMsgEndpoint
+	for _, endpoint := range c.pending {
+		deviceNode, err := c.processor.CreateEndpoint(endpoint)
+		if errors.Is(err, fs.ErrNotExist) {
+			// The firmware hasn't brought up its virtio rpmsg device yet.
+			pending = append(pending, endpoint)
+			continue
+		}
+		if err != nil {
+			c.logger.Error("failed to create RPMsg endpoint", "endpoint", endpoint.Name, "error", err)
+			continue
+		}
+		endpoint.DeviceNode = deviceNode
+		c.logger.Debug("created RPMsg endpoint", "endpoint", endpoint.String())
+		c.created = append(c.created, endpoint)
+	}
+	changed := len(pending) != len(c.pending)
+	c.pending = pending
+	if changed {
+		c.publish()
+	}
+}
+
+// destroy removes the endpoints created so far. They are created anew should the firmware be
+// restarted.
+func (c *endpointCreator) destroy() {
+	c.pending = c.requested
+	if len(c.created) == 0 {
+		return
+	}
+	for _, endpoint := range c.created {
+		if err := c.processor.DestroyEndpoint(endpoint.DeviceNode); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			c.logger.Error("failed to destroy RPMsg endpoint", "endpoint", endpoint.Name, "error", err)
+		}
+	}
+	c.created = nil
+	c.publish()
+}
+
+// deviceNode returns the device node of the created endpoint with the given name, or "".
+func (c *endpointCreator) deviceNode(name string) string {
+	for _, endpoint := range c.created {
+		if endpoint.Name == name {
+			return endpoint.DeviceNode
+		}
+	}
+	return ""
+}
+
+func (c *endpointCreator) publish() {
+	descriptions := make([]string, len(c.created))
+	deviceNodes := make([]string, len(c.created))
+	for i, endpoint := range c.created {
+		descriptions[i] = endpoint.String()
+		deviceNodes[i] = endpoint.DeviceNode
+	}
+	c.status.update(func(status *oci.ProxyStatus) {
+		if len(c.created) == 0 {
+			delete(status.Annotations, oci.OptionalStateRPMsgEndpoints)
+		} else {
+			status.Annotations[oci.OptionalStateRPMsgEndpoints] = strings.Join(descriptions, "; ")
+		}
+		status.RPMsgEndpoints = deviceNodes
+	})
+}
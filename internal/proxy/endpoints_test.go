@@ -0,0 +1,50 @@
+package proxy_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var controlEndpoint = remoteproc.RPMsgEndpoint{Name: "control", Src: 0x400, Dst: 0x1}
+
+func TestRunCreatesRPMsgEndpoints(t *testing.T) {
+	t.Run("creates the endpoints once the firmware's control device appears", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		status := startProxy(t, processor, proxy.RunOptions{RPMsgEndpoints: []remoteproc.RPMsgEndpoint{controlEndpoint}}).status
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Empty(t, processor.Endpoints())
+		processor.EnableRPMsgCtrl()
+
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.Equal(c, []string{"/dev/rpmsg0"}, status.last().RPMsgEndpoints)
+		}, time.Second, time.Millisecond)
+		created := controlEndpoint
+		created.DeviceNode = "/dev/rpmsg0"
+		assert.Equal(t, []remoteproc.RPMsgEndpoint{created}, processor.Endpoints())
+		assert.Equal(t, "control src=0x400 dst=0x1 dev=/dev/rpmsg0", status.last().Annotations[oci.OptionalStateRPMsgEndpoints])
+	})
+
+	t.Run("destroys the endpoints before stopping the firmware", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.EnableRPMsgCtrl()
+		run := startProxy(t, processor, proxy.RunOptions{RPMsgEndpoints: []remoteproc.RPMsgEndpoint{controlEndpoint}})
+		require.Eventually(t, func() bool {
+			return len(processor.Endpoints()) == 1
+		}, time.Second, time.Millisecond)
+
+		run.signals <- syscall.SIGTERM
+
+		waitForState(t, processor, remoteproc.StateOffline)
+		assert.Empty(t, processor.Endpoints())
+		assert.Empty(t, run.status.last().RPMsgEndpoints)
+		assert.NotContains(t, run.status.last().Annotations, oci.OptionalStateRPMsgEndpoints)
+	})
+}
@@ -0,0 +1,204 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+const (
+	// DefaultHealthInterval is how often the health check runs when no interval is configured.
+	DefaultHealthInterval = 10 * time.Second
+	// DefaultHealthPing is sent to the firmware when no ping is configured.
+	DefaultHealthPing = "ping"
+	// DefaultHealthTimeout is how long the firmware gets to answer a ping when no timeout is configured.
+	DefaultHealthTimeout = 5 * time.Second
+)
+
+// HealthCheck configures the check the proxy runs on the firmware every Interval. It passes while
+// the processor is running and every configured probe succeeds.
+type HealthCheck struct {
+	Interval time.Duration
+	// RPMsgChannel names the RPMsg endpoint, among RunOptions.RPMsgEndpoints, or else the
+	// announced RPMsg channel Ping is sent over; empty to not ping. Any reply within Timeout passes.
+	RPMsgChannel string
+	Ping         []byte
+	Timeout      time.Duration
+	// Heartbeat must show up in the firmware's trace output between two checks; empty to not
+	// look for one.
+	Heartbeat string
+}
+
+// healthMonitor runs the health check and publishes its outcome. RPMsg pings run in the
+// background, so a slow firmware doesn't hold up the proxy.
+type healthMonitor struct {
+	logger    *slog.Logger
+	processor remoteproc.Processor
+	endpoints *endpointCreator
+	status    *statusPublisher
+	check     *HealthCheck
+
+	nextCheck time.Time
+	pinging   bool
+	pings     chan []string
+	// beat is set when the heartbeat was traced since the previous check; tail holds the end of
+	// the trace output, in case the next write completes the heartbeat.
+	beat bool
+	tail []byte
+}
+
+func newHealthMonitor(logger *slog.Logger, processor remoteproc.Processor, endpoints *endpointCreator, status *statusPublisher, check *HealthCheck) *healthMonitor {
+	m := &healthMonitor{
+		logger:    logger,
+		processor: processor,
+		endpoints: endpoints,
+		status:    status,
+		check:     check,
+	}
+	m.started()
+	return m
+}
+
+// started starts checking freshly started firmware over.
+func (m *healthMonitor) started() {
+	if m.check == nil {
+		return
+	}
+	m.nextCheck = time.Now().Add(m.check.Interval)
+	m.beat = false
+	// A ping still pending went to the previous run, and its outcome goes to the channel it
+	// was given rather than the new run's.
+	m.pinging = false
+	m.pings = make(chan []string, 1)
+	m.publish(oci.HealthStarting, nil)
+}
+
+// traceOutput returns where the trace follower writes to: out, and the monitor too when it
+// looks for a heartbeat.
+func (m *healthMonitor) traceOutput(out io.Writer) io.Writer {
+	if m.check == nil || m.check.Heartbeat == "" {
+		return out
+	}
+	if out == nil {
+		return m
+	}
+	return io.MultiWriter(out, m)
+}
+
+// Write scans the firmware's trace output for the heartbeat.
+func (m *healthMonitor) Write(p []byte) (int, error) {
+	heartbeat := []byte(m.check.Heartbeat)
+	text := append(m.tail, p...)
+	if bytes.Contains(text, heartbeat) {
+		m.beat = true
+	}
+	keep := min(len(text), len(heartbeat)-1)
+	m.tail = bytes.Clone(text[len(text)-keep:])
+	return len(p), nil
+}
+
+// poll runs the check when it's due, and publishes the outcome of a ping once it's answered.
+// It is only called while the processor is running.
+func (m *healthMonitor) poll() {
+	if m.check == nil {
+		return
+	}
+	if m.pinging {
+		select {
+		case failures := <-m.pings:
+			m.pinging = false
+			m.report(failures)
+		default:
+		}
+		return
+	}
+	if time.Now().Before(m.nextCheck) {
+		return
+	}
+	m.nextCheck = time.Now().Add(m.check.Interval)
+
+	var failures []string
+	if m.check.Heartbeat != "" {
+		if !m.beat {
+			failures = append(failures, fmt.Sprintf("no heartbeat %q traced in %s", m.check.Heartbeat, m.check.Interval))
+		}
+		m.beat = false
+	}
+	if m.check.RPMsgChannel == "" {
+		m.report(failures)
+		return
+	}
+	deviceNode, err := rpmsgDeviceNode(m.processor, m.endpoints, m.check.RPMsgChannel)
+	if err != nil {
+		m.report(append(failures, err.Error()))
+		return
+	}
+	m.pinging = true
+	pings := m.pings
+	go func() {
+		if err := m.ping(deviceNode); err != nil {
+			failures = append(failures, err.Error())
+		}
+		pings <- failures
+	}()
+}
+
+// ping sends the ping and waits for any reply.
+func (m *healthMonitor) ping(deviceNode string) error {
+	conn, err := m.processor.OpenRPMsg(deviceNode)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", deviceNode, err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Write(m.check.Ping); err != nil {
+		return fmt.Errorf("failed to ping firmware: %w", err)
+	}
+	select {
+	case <-awaitReply(conn):
+		return nil
+	case <-time.After(m.check.Timeout):
+		return fmt.Errorf("no reply to ping on %s within %s", m.check.RPMsgChannel, m.check.Timeout)
+	}
+}
+
+func (m *healthMonitor) report(failures []string) {
+	if len(failures) == 0 {
+		m.publish(oci.HealthHealthy, nil)
+		return
+	}
+	m.logger.Warn("firmware health check failed", "failures", failures)
+	m.publish(oci.HealthUnhealthy, failures)
+}
+
+// stopped withdraws the health of a firmware that is gone.
+func (m *healthMonitor) stopped() {
+	if m.check == nil {
+		return
+	}
+	m.status.update(func(status *oci.ProxyStatus) {
+		delete(status.Annotations, oci.OptionalStateHealth)
+		delete(status.Annotations, oci.OptionalStateHealthFailure)
+	})
+}
+
+func (m *healthMonitor) publish(health string, failures []string) {
+	current := m.status.status.Annotations
+	failure := strings.Join(failures, "; ")
+	if current[oci.OptionalStateHealth] == health && current[oci.OptionalStateHealthFailure] == failure {
+		return
+	}
+	m.status.update(func(status *oci.ProxyStatus) {
+		status.Annotations[oci.OptionalStateHealth] = health
+		if failure == "" {
+			delete(status.Annotations, oci.OptionalStateHealthFailure)
+		} else {
+			status.Annotations[oci.OptionalStateHealthFailure] = failure
+		}
+	})
+}
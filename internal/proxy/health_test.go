@@ -0,0 +1,110 @@
+package proxy_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var healthChannel = remoteproc.RPMsgDevice{Channel: "rpmsg-health", DeviceNode: "/dev/ttyRPMSG2"}
+
+func TestRunChecksFirmwareHealth(t *testing.T) {
+	t.Run("reports firmware which answers pings as healthy", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddRPMsgDevice(healthChannel)
+		processor.HandleRPMsg(healthChannel.DeviceNode, func([]byte) []byte { return []byte("pong") })
+		status := startProxy(t, processor, proxy.RunOptions{HealthCheck: &proxy.HealthCheck{
+			Interval:     20 * time.Millisecond,
+			RPMsgChannel: "rpmsg-health",
+			Ping:         []byte("ping"),
+			Timeout:      time.Second,
+		}}).status
+
+		assertHealth(t, status, oci.HealthHealthy, "")
+		assert.Contains(t, processor.RPMsgInput(healthChannel.DeviceNode), "ping")
+	})
+
+	t.Run("reports firmware which doesn't answer pings as unhealthy", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddRPMsgDevice(healthChannel)
+		status := startProxy(t, processor, proxy.RunOptions{HealthCheck: &proxy.HealthCheck{
+			Interval:     20 * time.Millisecond,
+			RPMsgChannel: "rpmsg-health",
+			Ping:         []byte("ping"),
+			Timeout:      50 * time.Millisecond,
+		}}).status
+
+		assertHealth(t, status, oci.HealthUnhealthy, "no reply to ping on rpmsg-health within 50ms")
+	})
+
+	t.Run("follows the firmware's heartbeat in its trace output", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddTraceBuffer("trace0", 256)
+		status := startProxy(t, processor, proxy.RunOptions{HealthCheck: &proxy.HealthCheck{
+			Interval:  100 * time.Millisecond,
+			Heartbeat: "alive",
+		}}).status
+		assert.Equal(t, oci.HealthStarting, status.last().Annotations[oci.OptionalStateHealth])
+
+		processor.WriteTrace("trace0", "still al")
+		processor.WriteTrace("trace0", "ive\n")
+		assertHealth(t, status, oci.HealthHealthy, "")
+
+		assertHealth(t, status, oci.HealthUnhealthy, `no heartbeat "alive" traced in 100ms`)
+	})
+
+	t.Run("drops the pending ping once the kernel recovered the firmware", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddRPMsgDevice(healthChannel)
+		status := startProxy(t, processor, proxy.RunOptions{
+			Recovery: remoteproc.RecoveryEnabled,
+			HealthCheck: &proxy.HealthCheck{
+				Interval:     20 * time.Millisecond,
+				RPMsgChannel: "rpmsg-health",
+				Ping:         []byte("ping"),
+				Timeout:      200 * time.Millisecond,
+			},
+		}).status
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.Contains(c, processor.RPMsgInput(healthChannel.DeviceNode), "ping")
+		}, time.Second, time.Millisecond)
+
+		processor.Crash()
+		time.Sleep(20 * time.Millisecond)
+		processor.HandleRPMsg(healthChannel.DeviceNode, func([]byte) []byte { return []byte("pong") })
+		processor.ForceState(remoteproc.StateRunning)
+
+		assertHealth(t, status, oci.HealthHealthy, "")
+		time.Sleep(250 * time.Millisecond)
+		for _, published := range status.all() {
+			assert.NotEqual(t, oci.HealthUnhealthy, published.Annotations[oci.OptionalStateHealth])
+		}
+	})
+
+	t.Run("withdraws the health once the firmware stops", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{HealthCheck: &proxy.HealthCheck{Interval: 20 * time.Millisecond}})
+		assertHealth(t, run.status, oci.HealthHealthy, "")
+
+		run.signals <- syscall.SIGTERM
+
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.NotContains(c, run.status.last().Annotations, oci.OptionalStateHealth)
+		}, time.Second, time.Millisecond)
+	})
+}
+
+func assertHealth(t *testing.T, status *statusRecorder, health string, failure string) {
+	t.Helper()
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		annotations := status.last().Annotations
+		assert.Equal(c, health, annotations[oci.OptionalStateHealth])
+		assert.Equal(c, failure, annotations[oci.OptionalStateHealthFailure])
+	}, 2*time.Second, time.Millisecond)
+}
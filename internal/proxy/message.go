@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// ParseMessage interprets the Go escape sequences of a message sent to the firmware, so binary
+// messages can be written as e.g. \x01\x00.
+func ParseMessage(raw string) ([]byte, error) {
+	message, err := strconv.Unquote(`"` + raw + `"`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message %q: must be a string with Go escape sequences", raw)
+	}
+	if message == "" {
+		return nil, fmt.Errorf("message must not be empty")
+	}
+	return []byte(message), nil
+}
+
+// escapeMessage is the reverse of ParseMessage.
+func escapeMessage(message []byte) string {
+	quoted := strconv.Quote(string(message))
+	return quoted[1 : len(quoted)-1]
+}
+
+// rpmsgDeviceNode resolves a channel to the device node of an endpoint the proxy created, or of
+// a channel the firmware announced.
+func rpmsgDeviceNode(processor remoteproc.Processor, endpoints *endpointCreator, channel string) (string, error) {
+	if deviceNode := endpoints.deviceNode(channel); deviceNode != "" {
+		return deviceNode, nil
+	}
+	devices, err := processor.RPMsgDevices()
+	if err != nil {
+		return "", fmt.Errorf("failed to list RPMsg devices: %w", err)
+	}
+	for _, device := range devices {
+		if device.Channel == channel && device.DeviceNode != "" {
+			return device.DeviceNode, nil
+		}
+	}
+	return "", fmt.Errorf("no RPMsg endpoint or channel with a device node named %s", channel)
+}
+
+// awaitReply returns a channel closed once the firmware's first reply is read from conn. The
+// read ends, unanswered, when conn is closed.
+func awaitReply(conn io.Reader) <-chan struct{} {
+	replied := make(chan struct{})
+	go func() {
+		buf := make([]byte, 512)
+		if n, _ := conn.Read(buf); n > 0 {
+			close(replied)
+		}
+	}()
+	return replied
+}
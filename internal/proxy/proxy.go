@@ -1,16 +1,110 @@
 package proxy
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
+	"strconv"
 	"syscall"
+	"time"
 
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
 )
 
-func NewProcess(logger *slog.Logger, namespaces []specs.LinuxNamespace, devicePath string) (int, error) {
+// Options configures a proxy process.
+type Options struct {
+	DevicePath string
+	Namespaces []specs.LinuxNamespace
+	// OwnershipLock is kept open by the proxy for its whole lifetime, so the processor
+	// ownership lock it backs is released exactly when the proxy goes away.
+	OwnershipLock *os.File
+	// Console is the pty slave of a container with a terminal. It becomes the proxy's stdin and
+	// stdout, and the proxy bridges it to the firmware's RPMsg TTY.
+	Console *os.File
+	// ContainerID names the container whose status file the proxy reports to; empty if it
+	// reports nothing.
+	ContainerID string
+	// RPMsgEndpoints are created by the proxy once the firmware is up.
+	RPMsgEndpoints []remoteproc.RPMsgEndpoint
+	// RefreshCDI has the proxy regenerate the CDI specs whenever the firmware's RPMsg device
+	// nodes change.
+	RefreshCDI bool
+	// Shutdown is the handshake the proxy performs with the firmware before stopping it; nil
+	// if there is none.
+	Shutdown *GracefulShutdown
+	// HealthCheck is run by the proxy while the firmware runs; nil if there is none.
+	HealthCheck *HealthCheck
+	// Restart is the policy the proxy restarts firmware that stops by itself with; nil if it
+	// doesn't.
+	Restart *RestartPolicy
+	// CoredumpDir is where the proxy saves coredumps of the crashed firmware; empty if it
+	// doesn't.
+	CoredumpDir string
+	// Recovery is the processor's recovery mode, one of RecoveryModes; empty if it was left
+	// as is.
+	Recovery string
+	// Attach has the proxy adopt the firmware already running on the processor rather than
+	// boot it.
+	Attach bool
+	// Control is the listening control socket the proxy serves, from ListenControl; nil if it
+	// is driven by signals only.
+	Control *os.File
+}
+
+// IgnoredSignals are taken by the proxy without effect, rather than having their default action
+// kill it, and the firmware along with it.
+var IgnoredSignals = []os.Signal{syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGALRM}
+
+// Launcher spawns proxy processes and delivers lifecycle signals to them.
+type Launcher interface {
+	Launch(logger *slog.Logger, opts Options) (int, error)
+	Signal(pid int, signal syscall.Signal) error
+	// AwaitExit blocks until the proxy exits, failing if it is still running after timeout.
+	AwaitExit(pid int, timeout time.Duration) error
+}
+
+// ExecLauncher runs the proxy as a child process of the current executable.
+type ExecLauncher struct{}
+
+func (ExecLauncher) Launch(logger *slog.Logger, opts Options) (int, error) {
+	return NewProcess(logger, opts)
+}
+
+func (ExecLauncher) Signal(pid int, signal syscall.Signal) error {
+	return SendSignal(pid, signal)
+}
+
+func (ExecLauncher) AwaitExit(pid int, timeout time.Duration) error {
+	pidfd, err := unix.PidfdOpen(pid, 0)
+	if errors.Is(err, unix.ESRCH) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to obtain file descriptor of process %d: %w", pid, err)
+	}
+	defer func() { _ = unix.Close(pidfd) }()
+	// The pidfd becomes readable once the process exits.
+	pfds := []unix.PollFd{{Fd: int32(pidfd), Events: unix.POLLIN}}
+	for {
+		n, err := unix.Poll(pfds, int(timeout.Milliseconds()))
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to wait for process %d: %w", pid, err)
+		}
+		if n == 0 {
+			return fmt.Errorf("process %d still running after %s", pid, timeout)
+		}
+		return nil
+	}
+}
+
+func NewProcess(logger *slog.Logger, opts Options) (int, error) {
 	execPath, err := os.Executable()
 	if err != nil {
 		return -1, fmt.Errorf("failed to get executable path: %w", err)
@@ -18,12 +112,69 @@ func NewProcess(logger *slog.Logger, namespaces []specs.LinuxNamespace, devicePa
 
 	isRoot := os.Geteuid() == 0
 
-	namespaceFlags, err := LinuxCloneFlags(logger, isRoot, namespaces)
+	namespaceFlags, err := LinuxCloneFlags(logger, isRoot, opts.Namespaces)
 	if err != nil {
 		return -1, err
 	}
 
-	cmd := exec.Command(execPath, "proxy", "--device-path", devicePath)
+	cmd := exec.Command(execPath, "proxy", "--device-path", opts.DevicePath)
+	if opts.ContainerID != "" {
+		cmd.Args = append(cmd.Args, "--container-id", opts.ContainerID)
+	}
+	for _, endpoint := range opts.RPMsgEndpoints {
+		cmd.Args = append(cmd.Args, "--rpmsg-endpoint", endpoint.Spec())
+	}
+	if opts.RefreshCDI {
+		cmd.Args = append(cmd.Args, "--refresh-cdi")
+	}
+	if opts.Shutdown != nil {
+		cmd.Args = append(cmd.Args,
+			"--shutdown-channel", opts.Shutdown.Channel,
+			"--shutdown-message", escapeMessage(opts.Shutdown.Message),
+			"--shutdown-timeout", opts.Shutdown.Timeout.String())
+	}
+	if check := opts.HealthCheck; check != nil {
+		cmd.Args = append(cmd.Args, "--health-interval", check.Interval.String())
+		if check.RPMsgChannel != "" {
+			cmd.Args = append(cmd.Args,
+				"--health-rpmsg-channel", check.RPMsgChannel,
+				"--health-ping", escapeMessage(check.Ping),
+				"--health-timeout", check.Timeout.String())
+		}
+		if check.Heartbeat != "" {
+			cmd.Args = append(cmd.Args, "--health-heartbeat", check.Heartbeat)
+		}
+	}
+	if opts.Restart != nil {
+		cmd.Args = append(cmd.Args,
+			"--restart", opts.Restart.String(),
+			"--restart-backoff", opts.Restart.Backoff.String())
+	}
+	if opts.CoredumpDir != "" {
+		cmd.Args = append(cmd.Args, "--coredump-dir", opts.CoredumpDir)
+	}
+	if opts.Recovery != "" {
+		cmd.Args = append(cmd.Args, "--recovery", opts.Recovery)
+	}
+	if opts.Attach {
+		cmd.Args = append(cmd.Args, "--attach")
+	}
+	// Like a container's init process, the proxy inherits create's stdout, where it writes the
+	// firmware's trace output. Stderr isn't passed on, so callers capturing it aren't held up.
+	cmd.Stdout = os.Stdout
+	if opts.Console != nil {
+		cmd.Args = append(cmd.Args, "--console")
+		cmd.Stdin = opts.Console
+		cmd.Stdout = opts.Console
+	}
+	if opts.OwnershipLock != nil {
+		cmd.ExtraFiles = append(cmd.ExtraFiles, opts.OwnershipLock)
+	}
+	if opts.Control != nil {
+		// Inherited files are numbered from 3 on, after stdin, stdout and stderr.
+		cmd.Args = append(cmd.Args, "--control-fd", strconv.Itoa(3+len(cmd.ExtraFiles)))
+		cmd.ExtraFiles = append(cmd.ExtraFiles, opts.Control)
+	}
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		Setpgid:    true,
 		Cloneflags: namespaceFlags,
@@ -36,12 +187,29 @@ func NewProcess(logger *slog.Logger, namespaces []specs.LinuxNamespace, devicePa
 	return cmd.Process.Pid, nil
 }
 
-func StopFirmware(pid int) error {
-	return SendSignal(pid, syscall.SIGTERM)
+func StopFirmware(launcher Launcher, pid int) error {
+	return launcher.Signal(pid, syscall.SIGTERM)
 }
 
-func StartFirmware(pid int) error {
-	return SendSignal(pid, syscall.SIGUSR1)
+// StartFirmware has the container's proxy start the firmware, returning why it failed to, or
+// ErrProxyExited if the proxy is gone. A proxy without a control socket is signalled instead,
+// which tells nothing of the outcome.
+func StartFirmware(launcher Launcher, containerID string, pid int) error {
+	_, err := SendControl(containerID, ControlStart, startTimeout)
+	if errors.Is(err, ErrNoControlSocket) {
+		return launcher.Signal(pid, syscall.SIGUSR1)
+	}
+	return err
+}
+
+// DetachFirmware has the container's proxy detach from the firmware and exit, leaving it
+// running, returning why it failed to. A proxy without a control socket is signalled instead.
+func DetachFirmware(launcher Launcher, containerID string, pid int) error {
+	_, err := SendControl(containerID, ControlDetach, detachTimeout)
+	if errors.Is(err, ErrNoControlSocket) {
+		return launcher.Signal(pid, syscall.SIGUSR2)
+	}
+	return err
 }
 
 func SendSignal(pid int, signal syscall.Signal) error {
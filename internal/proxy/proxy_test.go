@@ -0,0 +1,150 @@
+package proxy_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc/remoteproctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testProxy is a proxy run by startProxy.
+type testProxy struct {
+	// signals are delivered to the proxy.
+	signals chan<- os.Signal
+	// done is closed once Run returned.
+	done <-chan struct{}
+	// status records what the proxy published, unless the test publishes it elsewhere.
+	status *statusRecorder
+	err    *error
+}
+
+// result waits for Run to return and returns its error.
+func (p testProxy) result() error {
+	<-p.done
+	return *p.err
+}
+
+// startProxy runs the proxy until the test ends and signals it to start, returning once the
+// firmware runs or the proxy gave up. Unless set in opts, the proxy polls every 10ms and
+// publishes its status to testProxy.status.
+func startProxy(t *testing.T, processor remoteproc.Processor, opts proxy.RunOptions) testProxy {
+	t.Helper()
+	if opts.PollInterval == 0 {
+		opts.PollInterval = 10 * time.Millisecond
+	}
+	status := &statusRecorder{}
+	if opts.PublishStatus == nil {
+		opts.PublishStatus = status.publish
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		err = proxy.Run(ctx, discardLogger(), processor, signals, opts)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	signals <- syscall.SIGUSR1
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		state, _ := processor.State()
+		assert.True(c, state.Running(), "processor is %s", state)
+	}, time.Second, time.Millisecond)
+	return testProxy{signals: signals, done: done, status: status, err: &err}
+}
+
+func newBootableProcessor(t *testing.T) *remoteproctest.Processor {
+	t.Helper()
+	firmwareDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(firmwareDir, "firmware.elf"), remoteproctest.NewFirmware().Bytes(), 0o644))
+	processor := remoteproctest.NewBackend(firmwareDir).AddProcessor("m33")
+	require.NoError(t, processor.SetFirmware("firmware.elf"))
+	return processor
+}
+
+func waitForState(t *testing.T, processor remoteproc.Processor, want remoteproc.State) {
+	t.Helper()
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		state, _ := processor.State()
+		assert.Equal(c, want, state)
+	}, time.Second, time.Millisecond)
+}
+
+func waitForExit(t *testing.T, done <-chan struct{}, timeout time.Duration) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatalf("proxy didn't exit within %s", timeout)
+	}
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+type statusRecorder struct {
+	mu       sync.Mutex
+	statuses []oci.ProxyStatus
+}
+
+func (r *statusRecorder) publish(status oci.ProxyStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+func (r *statusRecorder) last() oci.ProxyStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.statuses) == 0 {
+		return oci.ProxyStatus{}
+	}
+	return r.statuses[len(r.statuses)-1]
+}
+
+func (r *statusRecorder) all() []oci.ProxyStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.statuses)
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
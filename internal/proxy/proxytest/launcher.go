@@ -0,0 +1,230 @@
+// Package proxytest runs proxies in-process so the runtime can be tested without spawning processes.
+package proxytest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"golang.org/x/sys/unix"
+)
+
+var _ proxy.Launcher = (*Launcher)(nil)
+
+// Launcher runs each proxy as a goroutine driving a processor obtained from a backend.
+// Pids handed out are fake and only meaningful to the same Launcher.
+type Launcher struct {
+	backend      remoteproc.Backend
+	pollInterval time.Duration
+
+	mu      sync.Mutex
+	nextPid int
+	proxies map[int]*process
+}
+
+type process struct {
+	sigCh  chan os.Signal
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+	stdout syncBuffer
+}
+
+// syncBuffer is a bytes.Buffer safe for a proxy to write while a test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func NewLauncher(backend remoteproc.Backend) *Launcher {
+	return &Launcher{
+		backend:      backend,
+		pollInterval: 10 * time.Millisecond,
+		nextPid:      1000,
+		proxies:      map[int]*process{},
+	}
+}
+
+func (l *Launcher) Launch(logger *slog.Logger, opts proxy.Options) (int, error) {
+	if _, err := proxy.ParseNamespaceFlags(opts.Namespaces); err != nil {
+		return -1, err
+	}
+	processor, err := l.backend.Open(opts.DevicePath)
+	if err != nil {
+		return -1, fmt.Errorf("failed to start proxy process: %w", err)
+	}
+	// Like a child process inheriting it, hold a duplicate of the lock so it outlives the caller's copy.
+	var ownershipLock *os.File
+	if opts.OwnershipLock != nil {
+		fd, err := unix.Dup(int(opts.OwnershipLock.Fd()))
+		if err != nil {
+			return -1, fmt.Errorf("failed to duplicate ownership lock: %w", err)
+		}
+		ownershipLock = os.NewFile(uintptr(fd), opts.OwnershipLock.Name())
+	}
+	var console *os.File
+	if opts.Console != nil {
+		fd, err := unix.Dup(int(opts.Console.Fd()))
+		if err != nil {
+			return -1, fmt.Errorf("failed to duplicate console: %w", err)
+		}
+		console = os.NewFile(uintptr(fd), opts.Console.Name())
+	}
+	var control net.Listener
+	if opts.Control != nil {
+		// net.FileListener duplicates the socket, as inheriting it would.
+		control, err = net.FileListener(opts.Control)
+		if err != nil {
+			return -1, fmt.Errorf("failed to use control socket: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &process{
+		sigCh:  make(chan os.Signal, 1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	l.mu.Lock()
+	l.nextPid++
+	pid := l.nextPid
+	l.proxies[pid] = p
+	l.mu.Unlock()
+
+	go func() {
+		defer close(p.done)
+		if ownershipLock != nil {
+			defer func() { _ = ownershipLock.Close() }()
+		}
+		runOpts := proxy.RunOptions{
+			PollInterval:   l.pollInterval,
+			TraceOutput:    &p.stdout,
+			RPMsgEndpoints: opts.RPMsgEndpoints,
+			Shutdown:       opts.Shutdown,
+			HealthCheck:    opts.HealthCheck,
+			Restart:        opts.Restart,
+			CoredumpDir:    opts.CoredumpDir,
+			Recovery:       opts.Recovery,
+			Attach:         opts.Attach,
+			Control:        control,
+		}
+		if opts.ContainerID != "" {
+			runOpts.PublishStatus = func(status oci.ProxyStatus) error {
+				return oci.WriteProxyStatus(opts.ContainerID, status)
+			}
+		}
+		if console != nil {
+			defer func() { _ = console.Close() }()
+			runOpts.TraceOutput = console
+			runOpts.Console = console
+		}
+		p.err = proxy.Run(ctx, logger, processor, p.sigCh, runOpts)
+	}()
+
+	return pid, nil
+}
+
+func (l *Launcher) Signal(pid int, signal syscall.Signal) error {
+	p, err := l.lookup(pid)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-p.done:
+		return fmt.Errorf("failed to send %s: %w", signal, os.ErrProcessDone)
+	default:
+	}
+	// Like the proxy process, whose default action for any signal it doesn't take is to die.
+	handled := slices.Contains([]os.Signal{syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGTERM, syscall.SIGINT}, os.Signal(signal)) ||
+		slices.Contains(proxy.IgnoredSignals, os.Signal(signal))
+	if !handled {
+		p.cancel()
+		<-p.done
+		return nil
+	}
+	select {
+	case <-p.done:
+		return fmt.Errorf("failed to send %s: %w", signal, os.ErrProcessDone)
+	case p.sigCh <- signal:
+		return nil
+	}
+}
+
+func (l *Launcher) AwaitExit(pid int, timeout time.Duration) error {
+	p, err := l.lookup(pid)
+	if err != nil {
+		return nil
+	}
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("process %d still running after %s", pid, timeout)
+	}
+}
+
+// Wait blocks until the proxy with the given pid exits and returns its error.
+func (l *Launcher) Wait(pid int) error {
+	p, err := l.lookup(pid)
+	if err != nil {
+		return err
+	}
+	<-p.done
+	return p.err
+}
+
+// Stdout returns what the proxy with the given pid has written to its stdout so far.
+func (l *Launcher) Stdout(pid int) string {
+	p, err := l.lookup(pid)
+	if err != nil {
+		return ""
+	}
+	return p.stdout.String()
+}
+
+// Exited reports whether the proxy with the given pid has exited.
+func (l *Launcher) Exited(pid int) bool {
+	p, err := l.lookup(pid)
+	if err != nil {
+		return true
+	}
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *Launcher) lookup(pid int) (*process, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.proxies[pid]
+	if !ok {
+		return nil, fmt.Errorf("failed to find process %d", pid)
+	}
+	return p, nil
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// Restart policy modes, named like Docker's.
+const (
+	RestartNo        = "no"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+const (
+	// DefaultRestartBackoff is the delay before the first restart when no backoff is configured.
+	DefaultRestartBackoff = time.Second
+	// maxRestartBackoff caps the delay, which doubles with each restart in a row.
+	maxRestartBackoff = time.Minute
+	// restartResetAfter is how long firmware must have been running for the delay to start over.
+	restartResetAfter = 10 * time.Second
+)
+
+// RestartPolicy has the proxy restart firmware that stopped by itself in place, rather than
+// exit and leave it to the container engine.
+type RestartPolicy struct {
+	// Mode is RestartOnFailure, restarting crashed firmware only, or RestartAlways, also
+	// restarting firmware that went offline by itself.
+	Mode string
+	// MaxRestarts bounds the restarts of RestartOnFailure; 0 for no bound.
+	MaxRestarts int
+	// Backoff is the delay before a restart, doubling with each restart in a row.
+	Backoff time.Duration
+}
+
+// ParseRestartPolicy parses a policy written like Docker's --restart, i.e. no, on-failure,
+// on-failure:<max-restarts> or always. It returns nil for no.
+func ParseRestartPolicy(raw string) (*RestartPolicy, error) {
+	mode, rawMax, hasMax := strings.Cut(raw, ":")
+	switch mode {
+	case RestartNo, RestartAlways:
+		if hasMax {
+			return nil, fmt.Errorf("invalid restart policy %q: only %s takes a maximum restart count", raw, RestartOnFailure)
+		}
+		if mode == RestartNo {
+			return nil, nil
+		}
+		return &RestartPolicy{Mode: mode, Backoff: DefaultRestartBackoff}, nil
+	case RestartOnFailure:
+		policy := &RestartPolicy{Mode: mode, Backoff: DefaultRestartBackoff}
+		if hasMax {
+			maxRestarts, err := strconv.Atoi(rawMax)
+			if err != nil || maxRestarts <= 0 {
+				return nil, fmt.Errorf("invalid restart policy %q: maximum restart count must be a positive integer", raw)
+			}
+			policy.MaxRestarts = maxRestarts
+		}
+		return policy, nil
+	default:
+		return nil, fmt.Errorf("invalid restart policy %q: must be one of %s, %s[:<max-restarts>], %s", raw, RestartNo, RestartOnFailure, RestartAlways)
+	}
+}
+
+// String formats the policy the way ParseRestartPolicy reads it.
+func (p *RestartPolicy) String() string {
+	if p == nil {
+		return RestartNo
+	}
+	if p.MaxRestarts > 0 {
+		return fmt.Sprintf("%s:%d", p.Mode, p.MaxRestarts)
+	}
+	return p.Mode
+}
+
+// restarter applies the restart policy to firmware that left the running state, restarting it
+// once the backoff delay has passed, and publishes how often it did and when it last crashed.
+type restarter struct {
+	logger    *slog.Logger
+	processor remoteproc.Processor
+	status    *statusPublisher
+	policy    *RestartPolicy
+
+	restarts  int
+	delay     time.Duration
+	startedAt time.Time
+	// restartAt is when the pending restart is due; zero when none is.
+	restartAt time.Time
+}
+
+func newRestarter(logger *slog.Logger, processor remoteproc.Processor, status *statusPublisher, policy *RestartPolicy) *restarter {
+	return &restarter{
+		logger:    logger,
+		processor: processor,
+		status:    status,
+		policy:    policy,
+		startedAt: time.Now(),
+	}
+}
+
+// stopped records that the firmware left the running state by itself, and schedules a restart
+// if the policy allows for one. It reports whether it did.
+func (r *restarter) stopped(state remoteproc.State) bool {
+	failed := state != remoteproc.StateOffline && state != remoteproc.StateDetached
+	if failed {
+		r.status.update(func(status *oci.ProxyStatus) {
+			status.Annotations[oci.OptionalStateRestartLastCrash] = time.Now().UTC().Format(time.RFC3339)
+		})
+	}
+	if r.policy == nil || (r.policy.Mode == RestartOnFailure && !failed) {
+		return false
+	}
+	if r.policy.MaxRestarts > 0 && r.restarts >= r.policy.MaxRestarts {
+		r.logger.Warn("not restarting firmware, restart policy exhausted", "policy", r.policy.String(), "restarts", r.restarts)
+		return false
+	}
+	if r.delay == 0 || time.Since(r.startedAt) >= restartResetAfter {
+		r.delay = r.policy.Backoff
+	} else {
+		r.delay = min(2*r.delay, maxRestartBackoff)
+	}
+	r.restartAt = time.Now().Add(r.delay)
+	r.logger.Info("restarting firmware", "state", state, "delay", r.delay)
+	return true
+}
+
+// pending reports whether a restart is scheduled.
+func (r *restarter) pending() bool {
+	return !r.restartAt.IsZero()
+}
+
+// poll restarts the processor once the scheduled restart is due. A processor that fails to
+// start counts as crashed firmware, so it fails once the policy gives up on it.
+func (r *restarter) poll() error {
+	if time.Now().Before(r.restartAt) {
+		return nil
+	}
+	r.restartAt = time.Time{}
+	// Crashed firmware still holds on to the processor, while starting attaches to detached
+	// firmware again.
+	if state, err := r.processor.State(); err == nil && state != remoteproc.StateOffline && state != remoteproc.StateDetached {
+		if err := r.processor.Stop(); err != nil {
+			r.logger.Warn("failed to stop remoteproc before restarting it", "error", err)
+		}
+	}
+	r.restarts++
+	r.startedAt = time.Now()
+	err := r.processor.Start()
+	// Only published now, so the count doesn't run ahead of the restart.
+	r.status.update(func(status *oci.ProxyStatus) {
+		status.Annotations[oci.OptionalStateRestartCount] = strconv.Itoa(r.restarts)
+	})
+	if err != nil {
+		r.logger.Error("failed to restart remoteproc", "error", err)
+		if !r.stopped(remoteproc.StateCrashed) {
+			return fmt.Errorf("failed to restart remoteproc: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package proxy_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRestartsFirmware(t *testing.T) {
+	t.Run("restarts crashed firmware in place", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{Restart: &proxy.RestartPolicy{Mode: proxy.RestartOnFailure, Backoff: 20 * time.Millisecond}})
+
+		processor.Crash()
+
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.Equal(c, 2, processor.StartCount())
+			assert.Equal(c, "1", run.status.last().Annotations[oci.OptionalStateRestartCount])
+		}, time.Second, time.Millisecond)
+		waitForState(t, processor, remoteproc.StateRunning)
+		assert.Contains(t, run.status.last().Annotations, oci.OptionalStateRestartLastCrash)
+		select {
+		case <-run.done:
+			t.Fatal("proxy exited although the firmware was restarted")
+		default:
+		}
+	})
+
+	t.Run("exits once the maximum restart count is reached", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{Restart: &proxy.RestartPolicy{Mode: proxy.RestartOnFailure, MaxRestarts: 2, Backoff: time.Millisecond}})
+
+		for range 3 {
+			waitForState(t, processor, remoteproc.StateRunning)
+			processor.Crash()
+		}
+
+		waitForExit(t, run.done, time.Second)
+		assert.Equal(t, 3, processor.StartCount())
+	})
+
+	t.Run("doesn't restart firmware which went offline by itself on failure only", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{Restart: &proxy.RestartPolicy{Mode: proxy.RestartOnFailure, Backoff: time.Millisecond}})
+
+		processor.ForceState(remoteproc.StateOffline)
+
+		waitForExit(t, run.done, time.Second)
+		assert.Equal(t, 1, processor.StartCount())
+		assert.NotContains(t, run.status.last().Annotations, oci.OptionalStateRestartLastCrash)
+	})
+
+	t.Run("always restarts firmware which went offline by itself", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		startProxy(t, processor, proxy.RunOptions{Restart: &proxy.RestartPolicy{Mode: proxy.RestartAlways, Backoff: time.Millisecond}})
+
+		processor.ForceState(remoteproc.StateOffline)
+
+		require.Eventually(t, func() bool {
+			return processor.StartCount() == 2
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("stops the processor when signalled while a restart is pending", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{Restart: &proxy.RestartPolicy{Mode: proxy.RestartAlways, Backoff: time.Hour}})
+		processor.Crash()
+		time.Sleep(50 * time.Millisecond)
+
+		run.signals <- syscall.SIGTERM
+
+		waitForExit(t, run.done, time.Second)
+		state, err := processor.State()
+		require.NoError(t, err)
+		assert.Equal(t, remoteproc.StateOffline, state)
+		assert.Equal(t, 1, processor.StartCount())
+	})
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	t.Run("parses a maximum restart count", func(t *testing.T) {
+		policy, err := proxy.ParseRestartPolicy("on-failure:3")
+
+		require.NoError(t, err)
+		assert.Equal(t, &proxy.RestartPolicy{Mode: proxy.RestartOnFailure, MaxRestarts: 3, Backoff: proxy.DefaultRestartBackoff}, policy)
+		assert.Equal(t, "on-failure:3", policy.String())
+	})
+
+	t.Run("returns no policy for no", func(t *testing.T) {
+		policy, err := proxy.ParseRestartPolicy("no")
+
+		require.NoError(t, err)
+		assert.Nil(t, policy)
+	})
+
+	t.Run("rejects a maximum restart count for always", func(t *testing.T) {
+		_, err := proxy.ParseRestartPolicy("always:3")
+
+		assert.ErrorContains(t, err, "only on-failure takes a maximum restart count")
+	})
+
+	t.Run("rejects unknown policies", func(t *testing.T) {
+		_, err := proxy.ParseRestartPolicy("unless-stopped")
+
+		assert.ErrorContains(t, err, "must be one of no, on-failure[:<max-restarts>], always")
+	})
+}
@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// rpmsgWatcher reports when the RPMsg device nodes of the firmware change, e.g. once it
+// announces its channels, or when endpoints are created or destroyed.
+type rpmsgWatcher struct {
+	logger      *slog.Logger
+	processor   remoteproc.Processor
+	changed     func()
+	deviceNodes []string
+}
+
+func newRPMsgWatcher(logger *slog.Logger, processor remoteproc.Processor, changed func()) *rpmsgWatcher {
+	return &rpmsgWatcher{
+		logger:    logger,
+		processor: processor,
+		changed:   changed,
+	}
+}
+
+func (w *rpmsgWatcher) poll() {
+	if w.changed == nil {
+		return
+	}
+	deviceNodes, err := remoteproc.RPMsgDeviceNodes(w.processor)
+	if err != nil {
+		w.logger.Warn("failed to list RPMsg device nodes", "error", err)
+		return
+	}
+	w.update(deviceNodes)
+}
+
+// stopped reports the device nodes gone along with the firmware.
+func (w *rpmsgWatcher) stopped() {
+	if w.changed == nil {
+		return
+	}
+	w.update(nil)
+}
+
+func (w *rpmsgWatcher) update(deviceNodes []string) {
+	if slices.Equal(deviceNodes, w.deviceNodes) {
+		return
+	}
+	w.deviceNodes = deviceNodes
+	w.changed()
+}
@@ -0,0 +1,31 @@
+package proxy_test
+
+import (
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReportsRPMsgChanges(t *testing.T) {
+	t.Run("reports channels appearing and going away with the firmware", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		var changes atomic.Int32
+		run := startProxy(t, processor, proxy.RunOptions{RPMsgChanged: func() { changes.Add(1) }})
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Zero(t, changes.Load(), "nothing changed before the firmware announced a channel")
+		processor.AddRPMsgDevice(remoteproc.RPMsgDevice{Name: "virtio0.rpmsg-tty.-1.1024", Channel: "rpmsg-tty", DeviceNode: "/dev/ttyRPMSG0"})
+		require.Eventually(t, func() bool { return changes.Load() == 1 }, time.Second, time.Millisecond)
+
+		run.signals <- syscall.SIGTERM
+		<-run.done
+
+		assert.Equal(t, int32(2), changes.Load())
+	})
+}
@@ -0,0 +1,264 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// RunOptions configures Run.
+type RunOptions struct {
+	PollInterval time.Duration
+	// TraceOutput receives the firmware's trace buffer output; nil discards it.
+	TraceOutput io.Writer
+	// Console is the container's terminal, bridged to the firmware's RPMsg TTY; nil if it has none.
+	Console io.ReadWriter
+	// RPMsgEndpoints are created once the firmware's RPMsg control device appears, and
+	// destroyed before the processor is stopped.
+	RPMsgEndpoints []remoteproc.RPMsgEndpoint
+	// PublishStatus receives what the proxy reports about the firmware whenever it changes;
+	// nil discards it.
+	PublishStatus func(oci.ProxyStatus) error
+	// RPMsgChanged is called whenever the firmware's RPMsg device nodes change; nil if nobody
+	// is interested.
+	RPMsgChanged func()
+	// Shutdown asks the firmware to shut down on SIGTERM/SIGINT before the processor is
+	// stopped; nil stops it right away.
+	Shutdown *GracefulShutdown
+	// HealthCheck is run periodically while the firmware runs, its outcome published in the
+	// status; nil if there is none.
+	HealthCheck *HealthCheck
+	// Restart has the proxy restart firmware that leaves the running state by itself; nil to
+	// return instead.
+	Restart *RestartPolicy
+	// CoredumpDir receives the dumps the kernel makes of the crashed firmware; empty to leave
+	// them to expire.
+	CoredumpDir string
+	// Recovery is the processor's recovery mode, one of RecoveryModes. Unless it is disabled,
+	// the proxy gives the kernel time to recover crashed firmware rather than return; empty if
+	// the mode was left as is.
+	Recovery string
+	// Attach adopts the firmware already running on the processor, starting the processor
+	// only to attach to detached firmware.
+	Attach bool
+	// Control is the control socket, whose start, stop, detach and status requests stand in for
+	// the signals and are replied to with their outcome; nil if there is none. It is closed
+	// once Run returns.
+	Control net.Listener
+}
+
+// Run drives the processor lifecycle from the signals received on sigCh.
+//
+// Phase 1 waits for SIGUSR1 and starts the processor, or adopts its firmware in Attach mode.
+// SIGTERM/SIGINT and SIGUSR2 have it exit without touching the processor; other signals are
+// ignored.
+// Phase 2 polls the processor every PollInterval until SIGTERM/SIGINT stops it, after the
+// Shutdown handshake if any, until SIGUSR2 detaches it, leaving the firmware running, or until
+// it leaves the running state for good, neither recovered by the kernel nor restarted by the
+// Restart policy. On the way, it copies new trace buffer output to TraceOutput, connects
+// Console, creates RPMsgEndpoints, runs the HealthCheck, saves coredumps to CoredumpDir and
+// reports crashes.
+// Requests received on the Control socket act like the signals, and are answered once done.
+// Cancelling ctx abandons the processor as-is, the same way SIGKILL would.
+func Run(ctx context.Context, logger *slog.Logger, processor remoteproc.Processor, sigCh <-chan os.Signal, opts RunOptions) error {
+	calls := make(chan controlCall)
+	if opts.Control != nil {
+		done := make(chan struct{})
+		defer func() {
+			close(done)
+			_ = opts.Control.Close()
+		}()
+		go serveControl(logger, opts.Control, calls, done)
+	}
+
+	// Phase 1: Wait for SIGUSR1 or a start request
+	var startCall *controlCall
+waiting:
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case sig := <-sigCh:
+			switch sig {
+			case syscall.SIGUSR1:
+				break waiting
+			case syscall.SIGTERM, syscall.SIGINT, syscall.SIGUSR2:
+				// Firmware that was never started leaves nothing to stop or detach.
+				return nil
+			}
+		case call := <-calls:
+			switch call.command {
+			case ControlStart:
+				startCall = &call
+				break waiting
+			case ControlStop, ControlDetach:
+				call.answer(processor, nil, nil)
+				return nil
+			default:
+				call.answer(processor, nil, nil)
+			}
+		}
+	}
+
+	// Phase 2: Start the firmware and wait for its termination or SIGTERM
+	status := newStatusPublisher(logger, opts.PublishStatus)
+	crashes := newCrashReporter(logger, processor, status, opts.Recovery)
+	if err := startProcessor(processor, opts.Attach); err != nil {
+		if startCall != nil {
+			startCall.answer(processor, nil, err)
+		}
+		return err
+	}
+	if startCall != nil {
+		startCall.answer(processor, status, nil)
+	}
+
+	endpoints := newEndpointCreator(logger, processor, status, opts.RPMsgEndpoints)
+	health := newHealthMonitor(logger, processor, endpoints, status, opts.HealthCheck)
+	traces := newTraceFollower(logger, processor, health.traceOutput(opts.TraceOutput))
+	console := newConsoleBridge(logger, processor, opts.Console)
+	defer console.close()
+	rpmsg := newRPMsgWatcher(logger, processor, opts.RPMsgChanged)
+	restarts := newRestarter(logger, processor, status, opts.Restart)
+	coredumps := newCoredumpCollector(logger, processor, status, opts.CoredumpDir)
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	stop := func() {
+		// The trace buffers are released along with the processor, even when the firmware
+		// shuts down by itself.
+		traces.poll()
+		shutdownFirmware(logger, processor, endpoints, opts.Shutdown, opts.PollInterval)
+		traces.poll()
+		endpoints.destroy()
+		// Firmware shutting down by itself, or detached from, leaves nothing to stop.
+		if state, err := processor.State(); err != nil || (state != remoteproc.StateOffline && state != remoteproc.StateDetached) {
+			if err := processor.Stop(); err != nil {
+				logger.Error("failed to stop remoteproc", "error", err)
+			}
+		}
+		rpmsg.stopped()
+		health.stopped()
+	}
+	detach := func() error {
+		// Detaching leaves the firmware running, only its host side goes away.
+		traces.poll()
+		endpoints.destroy()
+		if err := processor.Detach(); err != nil {
+			// The endpoints are created again while the firmware stays attached.
+			logger.Error("failed to detach remoteproc", "error", err)
+			return err
+		}
+		rpmsg.stopped()
+		health.stopped()
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case sig := <-sigCh:
+			switch sig {
+			case syscall.SIGUSR2:
+				if detach() == nil {
+					return nil
+				}
+			case syscall.SIGTERM, syscall.SIGINT:
+				stop()
+				return nil
+			}
+		case call := <-calls:
+			switch call.command {
+			case ControlStop:
+				stop()
+				call.answer(processor, status, nil)
+				return nil
+			case ControlDetach:
+				err := detach()
+				call.answer(processor, status, err)
+				if err == nil {
+					return nil
+				}
+			case ControlStart:
+				call.answer(processor, status, fmt.Errorf("firmware already started"))
+			default:
+				call.answer(processor, status, nil)
+			}
+		case <-ticker.C:
+			coredumps.poll()
+			if restarts.pending() {
+				if err := restarts.poll(); err != nil {
+					return err
+				}
+				if !restarts.pending() {
+					crashes.started()
+					coredumps.started()
+					health.started()
+				}
+				continue
+			}
+			traces.poll()
+			console.poll()
+			state, err := processor.State()
+			if err != nil {
+				logger.Error("failed to get remoteproc state", "error", err)
+				continue
+			}
+			if crashes.poll(state) {
+				// The kernel took the endpoints down along with the crashed firmware.
+				endpoints.destroy()
+				coredumps.started()
+				health.started()
+			}
+			if !state.Running() {
+				if crashes.recovering(state) {
+					continue
+				}
+				crashes.stopped(state)
+				endpoints.destroy()
+				rpmsg.stopped()
+				health.stopped()
+				if restarts.stopped(state) {
+					continue
+				}
+				return fmt.Errorf("remoteproc not running, current state: %s", state)
+			}
+			endpoints.poll()
+			rpmsg.poll()
+			health.poll()
+		}
+	}
+}
+
+// startProcessor boots the processor, unless attach adopts the firmware already running on it,
+// only starting the processor to attach to detached firmware.
+func startProcessor(processor remoteproc.Processor, attach bool) error {
+	if attach {
+		state, err := processor.State()
+		if err != nil {
+			return fmt.Errorf("failed to get remoteproc state: %w", err)
+		}
+		if state.Running() {
+			if err := processor.Attach(); err != nil {
+				return fmt.Errorf("failed to attach to remoteproc: %w", err)
+			}
+			return nil
+		}
+		if state != remoteproc.StateDetached {
+			return fmt.Errorf("no firmware to attach to, processor is %s", state)
+		}
+	}
+	if err := processor.Start(); err != nil {
+		return fmt.Errorf("failed to start remoteproc: %w", err)
+	}
+	return nil
+}
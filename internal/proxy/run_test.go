@@ -0,0 +1,61 @@
+package proxy_test
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWaitsForStart(t *testing.T) {
+	t.Run("ignores signals other than the start", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		signals, done := runUnstarted(t, processor)
+
+		signals <- syscall.SIGHUP
+		time.Sleep(50 * time.Millisecond)
+
+		select {
+		case <-done:
+			t.Fatal("proxy exited on a signal it should ignore")
+		default:
+		}
+		assert.Equal(t, 0, processor.StartCount())
+
+		signals <- syscall.SIGUSR1
+
+		waitForState(t, processor, remoteproc.StateRunning)
+	})
+
+	t.Run("exits without starting the firmware when detached before the start", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		signals, done := runUnstarted(t, processor)
+
+		signals <- syscall.SIGUSR2
+
+		waitForExit(t, done, time.Second)
+		assert.Equal(t, 0, processor.StartCount())
+	})
+}
+
+// runUnstarted runs the proxy until the test ends without signalling it to start.
+func runUnstarted(t *testing.T, processor remoteproc.Processor) (chan<- os.Signal, <-chan struct{}) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = proxy.Run(ctx, discardLogger(), processor, signals, proxy.RunOptions{PollInterval: 10 * time.Millisecond})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return signals, done
+}
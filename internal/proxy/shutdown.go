@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+const (
+	// DefaultShutdownMessage is sent to the firmware when no message is configured.
+	DefaultShutdownMessage = "shutdown"
+	// DefaultShutdownTimeout is how long the firmware gets to shut down when no timeout is configured.
+	DefaultShutdownTimeout = 5 * time.Second
+)
+
+// GracefulShutdown asks the firmware to shut down by itself before the processor is stopped.
+type GracefulShutdown struct {
+	// Channel names the RPMsg endpoint, among RunOptions.RPMsgEndpoints, or else the announced
+	// RPMsg channel the message is sent over.
+	Channel string
+	Message []byte
+	// Timeout bounds the wait for the firmware to acknowledge the message or go offline.
+	Timeout time.Duration
+}
+
+// shutdownFirmware sends the shutdown message and waits until the firmware replies, leaves the
+// running state, or the timeout expires. Failures are logged, as stopping the processor follows
+// either way.
+func shutdownFirmware(logger *slog.Logger, processor remoteproc.Processor, endpoints *endpointCreator, shutdown *GracefulShutdown, pollInterval time.Duration) {
+	if shutdown == nil {
+		return
+	}
+	logger = logger.With("channel", shutdown.Channel)
+	deviceNode, err := rpmsgDeviceNode(processor, endpoints, shutdown.Channel)
+	if err != nil {
+		logger.Warn("can't ask firmware to shut down", "error", err)
+		return
+	}
+	conn, err := processor.OpenRPMsg(deviceNode)
+	if err != nil {
+		logger.Warn("can't ask firmware to shut down", "error", err)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Write(shutdown.Message); err != nil {
+		logger.Warn("failed to send shutdown message", "error", err)
+		return
+	}
+
+	acknowledged := awaitReply(conn)
+	timeout := time.NewTimer(shutdown.Timeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-acknowledged:
+			logger.Debug("firmware acknowledged shutdown")
+			return
+		case <-timeout.C:
+			logger.Warn("firmware didn't shut down in time, stopping it", "timeout", shutdown.Timeout)
+			return
+		case <-ticker.C:
+			if state, err := processor.State(); err == nil && !state.Running() {
+				logger.Debug("firmware shut down", "state", state)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package proxy_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunShutsFirmwareDownGracefully(t *testing.T) {
+	t.Run("stops the processor once the firmware acknowledges the shutdown message", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.EnableRPMsgCtrl()
+		processor.HandleRPMsg("/dev/rpmsg0", func([]byte) []byte { return []byte("ok") })
+		run := startProxy(t, processor, proxy.RunOptions{Shutdown: &proxy.GracefulShutdown{
+			Channel: "control",
+			Message: []byte("bye\x00"),
+			Timeout: 10 * time.Second,
+		}, RPMsgEndpoints: []remoteproc.RPMsgEndpoint{controlEndpoint}})
+		require.Eventually(t, func() bool {
+			return len(processor.Endpoints()) == 1
+		}, time.Second, time.Millisecond)
+
+		run.signals <- syscall.SIGTERM
+
+		waitForExit(t, run.done, 5*time.Second)
+		assert.Equal(t, "bye\x00", processor.RPMsgInput("/dev/rpmsg0"))
+		assert.Equal(t, 1, processor.StopCount())
+		assert.Empty(t, processor.Endpoints())
+	})
+
+	t.Run("leaves firmware which went offline by itself alone", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddRPMsgDevice(remoteproc.RPMsgDevice{Channel: "rpmsg-shutdown", DeviceNode: "/dev/ttyRPMSG1"})
+		processor.HandleRPMsg("/dev/ttyRPMSG1", func([]byte) []byte {
+			processor.ForceState(remoteproc.StateOffline)
+			return nil
+		})
+		run := startProxy(t, processor, proxy.RunOptions{Shutdown: &proxy.GracefulShutdown{
+			Channel: "rpmsg-shutdown",
+			Message: []byte("shutdown"),
+			Timeout: 10 * time.Second,
+		}})
+
+		run.signals <- syscall.SIGTERM
+
+		waitForExit(t, run.done, 5*time.Second)
+		assert.Equal(t, "shutdown", processor.RPMsgInput("/dev/ttyRPMSG1"))
+		assert.Equal(t, 0, processor.StopCount())
+	})
+
+	t.Run("stops firmware which doesn't answer once the timeout expires", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddRPMsgDevice(remoteproc.RPMsgDevice{Channel: "rpmsg-shutdown", DeviceNode: "/dev/ttyRPMSG1"})
+		run := startProxy(t, processor, proxy.RunOptions{Shutdown: &proxy.GracefulShutdown{
+			Channel: "rpmsg-shutdown",
+			Message: []byte("shutdown"),
+			Timeout: 100 * time.Millisecond,
+		}})
+
+		start := time.Now()
+		run.signals <- syscall.SIGTERM
+
+		waitForExit(t, run.done, 5*time.Second)
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		assert.Equal(t, 1, processor.StopCount())
+	})
+
+	t.Run("stops the processor right away when the channel doesn't exist", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		run := startProxy(t, processor, proxy.RunOptions{Shutdown: &proxy.GracefulShutdown{
+			Channel: "missing",
+			Message: []byte("shutdown"),
+			Timeout: 10 * time.Second,
+		}})
+
+		run.signals <- syscall.SIGTERM
+
+		waitForExit(t, run.done, time.Second)
+		assert.Equal(t, 1, processor.StopCount())
+	})
+}
+
+func TestParseMessage(t *testing.T) {
+	t.Run("interprets Go escape sequences", func(t *testing.T) {
+		message, err := proxy.ParseMessage(`halt\x00\n`)
+
+		require.NoError(t, err)
+		assert.Equal(t, []byte("halt\x00\n"), message)
+	})
+
+	t.Run("rejects invalid escape sequences", func(t *testing.T) {
+		_, err := proxy.ParseMessage(`halt\q`)
+
+		assert.ErrorContains(t, err, "Go escape sequences")
+	})
+
+	t.Run("rejects empty messages", func(t *testing.T) {
+		_, err := proxy.ParseMessage("")
+
+		assert.ErrorContains(t, err, "must not be empty")
+	})
+}
@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+)
+
+// statusPublisher holds what the proxy reports about the firmware and publishes every change.
+type statusPublisher struct {
+	logger  *slog.Logger
+	publish func(oci.ProxyStatus) error
+	status  oci.ProxyStatus
+}
+
+func newStatusPublisher(logger *slog.Logger, publish func(oci.ProxyStatus) error) *statusPublisher {
+	return &statusPublisher{
+		logger:  logger,
+		publish: publish,
+		status:  oci.ProxyStatus{Annotations: map[string]string{}},
+	}
+}
+
+func (p *statusPublisher) update(change func(status *oci.ProxyStatus)) {
+	change(&p.status)
+	if p.publish == nil {
+		return
+	}
+	if err := p.publish(p.current()); err != nil {
+		p.logger.Warn("failed to publish proxy status", "error", err)
+	}
+}
+
+// current returns a copy of the status.
+func (p *statusPublisher) current() oci.ProxyStatus {
+	return oci.ProxyStatus{
+		Annotations:    maps.Clone(p.status.Annotations),
+		RPMsgEndpoints: slices.Clone(p.status.RPMsgEndpoints),
+	}
+}
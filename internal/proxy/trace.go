@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// traceFollower copies whatever the firmware writes to its trace buffers to out.
+type traceFollower struct {
+	logger    *slog.Logger
+	processor remoteproc.Processor
+	out       io.Writer
+	tails     map[string]*traceTail
+	disabled  bool
+}
+
+func newTraceFollower(logger *slog.Logger, processor remoteproc.Processor, out io.Writer) *traceFollower {
+	return &traceFollower{
+		logger:    logger,
+		processor: processor,
+		out:       out,
+		tails:     map[string]*traceTail{},
+		disabled:  out == nil,
+	}
+}
+
+// poll writes out the text added to each trace buffer since the previous poll.
+func (f *traceFollower) poll() {
+	if f.disabled {
+		return
+	}
+	names, err := f.processor.TraceBuffers()
+	if errors.Is(err, fs.ErrNotExist) {
+		// Not booted yet, or debugfs isn't mounted.
+		return
+	}
+	if err != nil {
+		f.logger.Debug("not following trace buffers", "error", err)
+		f.disabled = true
+		return
+	}
+
+	present := map[string]bool{}
+	for _, name := range names {
+		content, err := f.processor.ReadTrace(name)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				f.logger.Debug("failed to read trace buffer", "trace", name, "error", err)
+			}
+			continue
+		}
+		present[name] = true
+		tail, ok := f.tails[name]
+		if !ok {
+			tail = &traceTail{}
+			f.tails[name] = tail
+		}
+		if text := tail.next(content); len(text) > 0 {
+			if _, err := f.out.Write(text); err != nil {
+				f.logger.Debug("not following trace buffers", "error", err)
+				f.disabled = true
+				return
+			}
+		}
+	}
+	// A buffer that went away, e.g. because the processor stopped, starts afresh if it returns.
+	for name := range f.tails {
+		if !present[name] {
+			delete(f.tails, name)
+		}
+	}
+}
+
+// traceTail turns successive snapshots of a circular trace buffer into the text written in
+// between. The kernel exposes the buffer up to its first NUL but not the firmware's write
+// position, so once the buffer has wrapped, new text is taken to run from where the previous
+// read ended up to the last byte that changed. Text overwriting identical bytes is therefore
+// only reported once something after it changes.
+type traceTail struct {
+	last    []byte
+	pos     int
+	wrapped bool
+}
+
+func (t *traceTail) next(current []byte) []byte {
+	if len(current) < len(t.last) {
+		// A wrapped buffer stays full, so a shorter one means the firmware started over.
+		*t = traceTail{}
+	}
+	if !t.wrapped && bytes.HasPrefix(current, t.last) {
+		text := current[len(t.last):]
+		t.last = current
+		t.pos = len(current)
+		return text
+	}
+
+	t.wrapped = true
+	size := len(current)
+	previous := make([]byte, size)
+	copy(previous, t.last)
+	t.last = current
+	if size == 0 {
+		return nil
+	}
+	start := t.pos % size
+	end := -1
+	for i := range size {
+		if j := (start + i) % size; current[j] != previous[j] {
+			end = i
+		}
+	}
+	if end < 0 {
+		return nil
+	}
+	text := make([]byte, 0, end+1)
+	for i := 0; i <= end; i++ {
+		text = append(text, current[(start+i)%size])
+	}
+	t.pos = (start + end + 1) % size
+	return text
+}
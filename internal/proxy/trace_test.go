@@ -0,0 +1,77 @@
+package proxy_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunFollowsTraceBuffers(t *testing.T) {
+	t.Run("writes firmware trace output as it appears", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddTraceBuffer("trace0", 64)
+		out := &lockedBuffer{}
+		startProxy(t, processor, proxy.RunOptions{TraceOutput: out})
+
+		processor.WriteTrace("trace0", "booting\n")
+		assertOutput(t, out, "booting\n")
+		processor.WriteTrace("trace0", "ready\n")
+
+		assertOutput(t, out, "booting\nready\n")
+	})
+
+	t.Run("follows the buffer when it wraps around", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddTraceBuffer("trace0", 16)
+		out := &lockedBuffer{}
+		startProxy(t, processor, proxy.RunOptions{TraceOutput: out})
+
+		processor.WriteTrace("trace0", "first line\n")
+		assertOutput(t, out, "first line\n")
+		processor.WriteTrace("trace0", "second line\n")
+		assertOutput(t, out, "first line\nsecond line\n")
+		processor.WriteTrace("trace0", "third\n")
+
+		assertOutput(t, out, "first line\nsecond line\nthird\n")
+	})
+
+	t.Run("reports text rewriting identical bytes once something after it changes", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddTraceBuffer("trace0", 10)
+		out := &lockedBuffer{}
+		startProxy(t, processor, proxy.RunOptions{TraceOutput: out})
+		processor.WriteTrace("trace0", "tick\n")
+		assertOutput(t, out, "tick\n")
+		processor.WriteTrace("trace0", "tick\n")
+		assertOutput(t, out, "tick\ntick\n")
+
+		processor.WriteTrace("trace0", "tick\n")
+		processor.WriteTrace("trace0", "bye\n")
+
+		assertOutput(t, out, "tick\ntick\ntick\nbye\n")
+	})
+
+	t.Run("flushes trace output before stopping the processor", func(t *testing.T) {
+		processor := newBootableProcessor(t)
+		processor.AddTraceBuffer("trace0", 64)
+		out := &lockedBuffer{}
+		run := startProxy(t, processor, proxy.RunOptions{PollInterval: time.Hour, TraceOutput: out})
+
+		processor.WriteTrace("trace0", "shutting down\n")
+		run.signals <- syscall.SIGTERM
+
+		require.NoError(t, run.result())
+		assert.Equal(t, "shutting down\n", out.String())
+	})
+}
+
+func assertOutput(t *testing.T, out *lockedBuffer, want string) {
+	t.Helper()
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, want, out.String())
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,79 @@
+package remoteproc
+
+import "io"
+
+// Backend gives access to the remote processors present on the system.
+type Backend interface {
+	// Processors lists every remote processor known to the backend.
+	Processors() ([]Processor, error)
+	// Open returns the processor identified by its device path.
+	Open(devicePath string) (Processor, error)
+	// FirmwareDir returns the directory the kernel loads firmware files from.
+	FirmwareDir() string
+}
+
+// Processor controls a single remote processor.
+type Processor interface {
+	DevicePath() string
+	Name() string
+	State() (State, error)
+	// Info gathers the processor's kernel-reported attributes.
+	Info() (Info, error)
+	// SetFirmware selects the firmware file to boot; only its base name is passed to the kernel.
+	SetFirmware(firmwareFilePath string) error
+	Start() error
+	// Attach takes over firmware already running on the processor, e.g. booted by the
+	// bootloader, so that it is stopped, or detached from, once its holder goes away, as if it
+	// had been started.
+	Attach() error
+	Stop() error
+	// Detach releases firmware the kernel attached to, leaving it running on the processor.
+	Detach() error
+	// TraceBuffers lists the trace buffers declared by the running firmware.
+	TraceBuffers() ([]string, error)
+	// ReadTrace returns the current content of a trace buffer. It fails with fs.ErrNotExist
+	// once the buffer is gone, e.g. because the processor stopped.
+	ReadTrace(name string) ([]byte, error)
+	// TTYs lists the RPMsg TTYs the running firmware exposes, e.g. ttyRPMSG0.
+	TTYs() ([]string, error)
+	// OpenTTY opens an RPMsg TTY in raw mode. Reads fail once the firmware goes away.
+	OpenTTY(name string) (io.ReadWriteCloser, error)
+	// RPMsgDevices lists the RPMsg channels the running firmware announced.
+	RPMsgDevices() ([]RPMsgDevice, error)
+	// CreateEndpoint creates a host-side rpmsg_char endpoint and returns its device node. It
+	// fails with fs.ErrNotExist while the firmware's RPMsg control device isn't there yet.
+	CreateEndpoint(endpoint RPMsgEndpoint) (string, error)
+	// RPMsgEndpoints lists the host-side endpoints created on the running firmware.
+	RPMsgEndpoints() ([]RPMsgEndpoint, error)
+	// OpenRPMsg opens the device node of an RPMsg channel or endpoint, e.g. /dev/rpmsg0, to
+	// exchange messages with the firmware. Reads fail once the firmware goes away.
+	OpenRPMsg(deviceNode string) (io.ReadWriteCloser, error)
+	// DestroyEndpoint destroys an endpoint by its device node, failing with fs.ErrNotExist
+	// if it is already gone.
+	DestroyEndpoint(deviceNode string) error
+	// SetCoredump selects how the kernel dumps crashed firmware, one of CoredumpModes.
+	SetCoredump(mode string) error
+	// OpenCoredump opens the dump the kernel made of the processor's crashed firmware, failing
+	// with fs.ErrNotExist when there is none. Closing it dismisses the dump.
+	OpenCoredump() (io.ReadCloser, error)
+	// SetRecovery selects whether the kernel restarts crashed firmware, RecoveryEnabled or
+	// RecoveryDisabled.
+	SetRecovery(mode string) error
+	// ReadKernelMessages returns the kernel log messages about the processor logged since the
+	// previous call, the first call returning those still in the kernel's log buffer.
+	ReadKernelMessages() ([]string, error)
+}
+
+// Info describes a processor as reported by the kernel. Optional attributes are left empty
+// when the kernel doesn't expose them.
+type Info struct {
+	Index          int    `json:"index"`
+	Name           string `json:"name"`
+	DevicePath     string `json:"devicePath"`
+	State          State  `json:"state"`
+	Firmware       string `json:"firmware"`
+	Coredump       string `json:"coredump,omitempty"`
+	Recovery       string `json:"recovery,omitempty"`
+	ParentDevice   string `json:"parentDevice,omitempty"`
+	DeviceTreeNode string `json:"deviceTreeNode,omitempty"`
+}
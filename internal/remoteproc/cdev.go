@@ -0,0 +1,50 @@
+package remoteproc
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/arm/remoteproc-runtime/internal/rootpath"
+	"golang.org/x/sys/unix"
+)
+
+// RPROC_SET_SHUTDOWN_ON_RELEASE from include/uapi/linux/remoteproc_cdev.h: _IOW(0xB7, 1, __s32)
+const rprocSetShutdownOnRelease = 0x4004b701
+
+var rprocDevPath = rootpath.Join("dev")
+
+// setShutdownOnRelease has the kernel stop the processor once the character device is released.
+var setShutdownOnRelease = func(f *os.File) error {
+	return unix.IoctlSetPointerInt(int(f.Fd()), rprocSetShutdownOnRelease, 1)
+}
+
+func cdevPath(devicePath string) string {
+	return filepath.Join(rprocDevPath, filepath.Base(devicePath))
+}
+
+// openCdev opens the processor's character device with shutdown-on-release enabled, so the
+// kernel stops the processor, or detaches from firmware it attached to, as soon as the returned
+// file is closed, including when its holder dies. It returns nil if the device can't be opened,
+// leaving the processor to be controlled through sysfs.
+func openCdev(logger *slog.Logger, devicePath string) (*os.File, error) {
+	path := cdevPath(devicePath)
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Kernels built without CONFIG_REMOTEPROC_CDEV only offer control through sysfs.
+		return nil, nil
+	}
+	if err != nil {
+		logger.Warn("Failed to open remote processor character device, controlling it through sysfs, so nothing stops the firmware should the proxy die",
+			"path", path, "error", err)
+		return nil, nil
+	}
+	if err := setShutdownOnRelease(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to enable shutdown on release for %s: %w", path, err)
+	}
+	return f, nil
+}
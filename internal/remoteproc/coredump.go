@@ -0,0 +1,75 @@
+package remoteproc
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Coredump modes of a processor, selecting how the kernel dumps crashed firmware.
+const (
+	CoredumpDisabled = "disabled"
+	// CoredumpEnabled copies the firmware's memory into the dump.
+	CoredumpEnabled = "enabled"
+	// CoredumpInline reads the firmware's memory as the dump is read, holding up recovery
+	// until it has been.
+	CoredumpInline = "inline"
+)
+
+// CoredumpModes lists the coredump modes, in the order the kernel documents them.
+var CoredumpModes = []string{CoredumpDisabled, CoredumpEnabled, CoredumpInline}
+
+func (p *sysfsProcessor) SetCoredump(mode string) error {
+	if !slices.Contains(CoredumpModes, mode) {
+		return fmt.Errorf("unknown coredump mode %q", mode)
+	}
+	if err := os.WriteFile(filepath.Join(p.devicePath, rprocCoredumpFileName), []byte(mode), 0o644); err != nil {
+		return fmt.Errorf("failed to set coredump mode %s: %w", mode, err)
+	}
+	return nil
+}
+
+// OpenCoredump looks for the device coredump whose failing device is the processor among
+// /sys/class/devcoredump/devcdN.
+func (p *sysfsProcessor) OpenCoredump() (io.ReadCloser, error) {
+	device, err := filepath.EvalSymlinks(p.devicePath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(devcoredumpClassPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		dumpPath := filepath.Join(devcoredumpClassPath, entry.Name())
+		failing, err := filepath.EvalSymlinks(filepath.Join(dumpPath, devcdFailingDeviceLinkName))
+		if err != nil || failing != device {
+			continue
+		}
+		dataPath := filepath.Join(dumpPath, devcdDataFileName)
+		data, err := os.Open(dataPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open coredump %s: %w", dataPath, err)
+		}
+		return &devcoredump{File: data, dataPath: dataPath}, nil
+	}
+	return nil, fs.ErrNotExist
+}
+
+// devcoredump is the data of a device coredump, which is dismissed once closed.
+type devcoredump struct {
+	*os.File
+	dataPath string
+}
+
+func (d *devcoredump) Close() error {
+	_ = d.File.Close()
+	// Writing anything to the data frees the dump, rather than waiting for it to expire.
+	if err := os.WriteFile(d.dataPath, []byte("1"), 0o200); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to dismiss coredump %s: %w", d.dataPath, err)
+	}
+	return nil
+}
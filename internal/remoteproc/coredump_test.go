@@ -0,0 +1,64 @@
+package remoteproc_test
+
+import (
+	"io"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSysfsSetCoredump(t *testing.T) {
+	t.Run("writes the mode to the coredump attribute", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateOffline)
+		processor := sysfs.open(t, devicePath)
+
+		require.NoError(t, processor.SetCoredump(remoteproc.CoredumpInline))
+
+		assert.Equal(t, "inline", readFixture(t, filepath.Join(devicePath, "coredump")))
+	})
+
+	t.Run("rejects unknown modes", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		processor := sysfs.open(t, sysfs.addProcessor(t, 0, "m33", remoteproc.StateOffline))
+
+		err := processor.SetCoredump("sometimes")
+
+		assert.EqualError(t, err, `unknown coredump mode "sometimes"`)
+	})
+}
+
+func TestSysfsOpenCoredump(t *testing.T) {
+	t.Run("reads the processor's device coredump and dismisses it once closed", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateCrashed)
+		otherDevicePath := sysfs.addProcessor(t, 1, "m4", remoteproc.StateCrashed)
+		sysfs.addCoredump(t, "devcd1", otherDevicePath, "other dump")
+		dataPath := sysfs.addCoredump(t, "devcd2", devicePath, "dump")
+		processor := sysfs.open(t, devicePath)
+
+		dump, err := processor.OpenCoredump()
+		require.NoError(t, err)
+		content, err := io.ReadAll(dump)
+		require.NoError(t, err)
+		require.NoError(t, dump.Close())
+
+		assert.Equal(t, "dump", string(content))
+		assert.Equal(t, "1", readFixture(t, dataPath))
+	})
+
+	t.Run("fails with fs.ErrNotExist without a coredump of the processor", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateCrashed)
+		sysfs.addCoredump(t, "devcd1", sysfs.addProcessor(t, 1, "m4", remoteproc.StateCrashed), "other dump")
+		processor := sysfs.open(t, devicePath)
+
+		_, err := processor.OpenCoredump()
+
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
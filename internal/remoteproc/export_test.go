@@ -0,0 +1,39 @@
+package remoteproc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// UseRoot points the sysfs backend at a fixture tree under root until the test ends. Regular
+// files stand in for the character devices, which take no ioctls.
+func UseRoot(t *testing.T, root string) {
+	t.Helper()
+	paths := map[*string][]string{
+		&rprocClassPath:       {"sys", "class", "remoteproc"},
+		&firmwareParamPath:    {"sys", "module", "firmware_class", "parameters", "path"},
+		&defaultFirmwarePath:  {"lib", "firmware"},
+		&deviceTreeBasePath:   {"sys", "firmware", "devicetree", "base"},
+		&rprocDebugfsPath:     {"sys", "kernel", "debug", "remoteproc"},
+		&devcoredumpClassPath: {"sys", "class", "devcoredump"},
+		&kmsgPath:             {"dev", "kmsg"},
+		&rprocDevPath:         {"dev"},
+		&rpmsgBusDevicesPath:  {"sys", "bus", "rpmsg", "devices"},
+		&rpmsgClassPath:       {"sys", "class", "rpmsg"},
+		&ttyClassPath:         {"sys", "class", "tty"},
+	}
+	for path, segments := range paths {
+		original := *path
+		*path = filepath.Join(append([]string{root}, segments...)...)
+		t.Cleanup(func() { *path = original })
+	}
+	original := setShutdownOnRelease
+	setShutdownOnRelease = func(*os.File) error { return nil }
+	t.Cleanup(func() { setShutdownOnRelease = original })
+}
+
+// RPMsgCtrl returns the RPMsg control device endpoints are created on for a sysfs processor.
+func RPMsgCtrl(processor Processor) (string, error) {
+	return processor.(*sysfsProcessor).rpmsgCtrl()
+}
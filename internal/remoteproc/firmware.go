@@ -0,0 +1,130 @@
+package remoteproc
+
+import (
+	"debug/elf"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+const resourceTableSectionName = ".resource_table"
+
+// resourceTableHeaderSize is the size of struct resource_table without its offsets array.
+const resourceTableHeaderSize = 16
+
+// Architecture is the ELF identity firmware built for a processor family carries.
+type Architecture struct {
+	Class   elf.Class
+	Data    elf.Data
+	Machine elf.Machine
+}
+
+// Architectures lists the architecture names accepted in FirmwareRequirements.
+var Architectures = map[string]Architecture{
+	"arm":     {Class: elf.ELFCLASS32, Data: elf.ELFDATA2LSB, Machine: elf.EM_ARM},
+	"arm64":   {Class: elf.ELFCLASS64, Data: elf.ELFDATA2LSB, Machine: elf.EM_AARCH64},
+	"riscv32": {Class: elf.ELFCLASS32, Data: elf.ELFDATA2LSB, Machine: elf.EM_RISCV},
+	"riscv64": {Class: elf.ELFCLASS64, Data: elf.ELFDATA2LSB, Machine: elf.EM_RISCV},
+	"xtensa":  {Class: elf.ELFCLASS32, Data: elf.ELFDATA2LSB, Machine: elf.EM_XTENSA},
+	"c6000":   {Class: elf.ELFCLASS32, Data: elf.ELFDATA2LSB, Machine: elf.EM_TI_C6000},
+}
+
+// FirmwareRequirements describes what a processor expects from a firmware image.
+type FirmwareRequirements struct {
+	// Arch is a key of Architectures; empty accepts any architecture.
+	Arch string
+	// RequireResourceTable rejects images without a usable .resource_table section.
+	RequireResourceTable bool
+}
+
+// ValidateFirmware checks that firmwareFilePath is an ELF image the kernel's remoteproc ELF
+// loader will accept and that it matches requirements. It catches wrong files (Linux binaries,
+// truncated images, other architectures) before they reach the driver.
+func ValidateFirmware(firmwareFilePath string, requirements FirmwareRequirements) error {
+	var arch Architecture
+	if requirements.Arch != "" {
+		var ok bool
+		arch, ok = Architectures[requirements.Arch]
+		if !ok {
+			return fmt.Errorf("unknown architecture %q, supported: %s", requirements.Arch, strings.Join(slices.Sorted(maps.Keys(Architectures)), ", "))
+		}
+	}
+
+	file, err := os.Open(firmwareFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open firmware: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat firmware: %w", err)
+	}
+	size := uint64(stat.Size())
+	image, err := elf.NewFile(file)
+	if err != nil {
+		return fmt.Errorf("firmware %s is not a valid ELF image: %w", firmwareFilePath, err)
+	}
+
+	if requirements.Arch != "" {
+		if image.Class != arch.Class {
+			return fmt.Errorf("firmware %s is %s, expected %s for architecture %s", firmwareFilePath, image.Class, arch.Class, requirements.Arch)
+		}
+		if image.Data != arch.Data {
+			return fmt.Errorf("firmware %s is %s, expected %s for architecture %s", firmwareFilePath, image.Data, arch.Data, requirements.Arch)
+		}
+		if image.Machine != arch.Machine {
+			return fmt.Errorf("firmware %s is built for %s, expected %s for architecture %s", firmwareFilePath, image.Machine, arch.Machine, requirements.Arch)
+		}
+	}
+
+	// The kernel only loads executables, not relocatable objects or shared libraries.
+	if image.Type != elf.ET_EXEC {
+		return fmt.Errorf("firmware %s is %s, expected an executable (ET_EXEC)", firmwareFilePath, image.Type)
+	}
+	loadable := 0
+	for i, prog := range image.Progs {
+		switch prog.Type {
+		case elf.PT_INTERP:
+			return fmt.Errorf("firmware %s requests a program interpreter, it looks like a Linux executable", firmwareFilePath)
+		case elf.PT_LOAD:
+			if prog.Filesz > prog.Memsz {
+				return fmt.Errorf("firmware %s segment %d has file size %#x larger than its memory size %#x", firmwareFilePath, i, prog.Filesz, prog.Memsz)
+			}
+			if prog.Off+prog.Filesz > size {
+				return fmt.Errorf("firmware %s segment %d (offset %#x, size %#x) extends past the end of the file (%d bytes), the image looks truncated", firmwareFilePath, i, prog.Off, prog.Filesz, size)
+			}
+			if prog.Filesz > 0 {
+				loadable++
+			}
+		}
+	}
+	if loadable == 0 {
+		return fmt.Errorf("firmware %s has no loadable segments", firmwareFilePath)
+	}
+
+	if requirements.RequireResourceTable {
+		if len(image.Sections) == 0 {
+			return fmt.Errorf("firmware %s has no section headers to find its %s section by", firmwareFilePath, resourceTableSectionName)
+		}
+		table := image.Section(resourceTableSectionName)
+		if table == nil {
+			return fmt.Errorf("firmware %s has no %s section", firmwareFilePath, resourceTableSectionName)
+		}
+		if table.Size < resourceTableHeaderSize {
+			return fmt.Errorf("firmware %s has a %d byte %s section, too small for a resource table header", firmwareFilePath, table.Size, resourceTableSectionName)
+		}
+		if table.Offset+table.Size > size {
+			return fmt.Errorf("firmware %s %s section extends past the end of the file (%d bytes), the image looks truncated", firmwareFilePath, resourceTableSectionName, size)
+		}
+		data, err := table.Data()
+		if err != nil {
+			return fmt.Errorf("failed to read %s section: %w", resourceTableSectionName, err)
+		}
+		if _, err := ParseResourceTable(data, image.ByteOrder); err != nil {
+			return fmt.Errorf("firmware %s has a malformed resource table: %w", firmwareFilePath, err)
+		}
+	}
+	return nil
+}
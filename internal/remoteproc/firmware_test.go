@@ -0,0 +1,178 @@
+package remoteproc_test
+
+import (
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc/remoteproctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleFirmwarePath = "../../testdata/bundle/rootfs/hello_world_cm33.elf"
+
+func TestValidateFirmware(t *testing.T) {
+	t.Run("accepts the sample Cortex-M33 firmware", func(t *testing.T) {
+		err := remoteproc.ValidateFirmware(sampleFirmwarePath, remoteproc.FirmwareRequirements{Arch: "arm"})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("accepts firmware with a resource table when one is required", func(t *testing.T) {
+		firmwarePath := writeFirmware(t, remoteproctest.NewFirmware().Bytes())
+
+		err := remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{Arch: "arm", RequireResourceTable: true})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects files that aren't ELF images", func(t *testing.T) {
+		firmwarePath := writeFirmware(t, []byte("pretend binary"))
+
+		err := remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{})
+
+		assert.ErrorContains(t, err, "is not a valid ELF image")
+	})
+
+	t.Run("rejects truncated images", func(t *testing.T) {
+		content, err := os.ReadFile(sampleFirmwarePath)
+		require.NoError(t, err)
+		firmwarePath := writeFirmware(t, content[:0x2000])
+
+		err = remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{})
+
+		assert.ErrorContains(t, err, "not a valid ELF image")
+	})
+
+	t.Run("rejects segments extending past the end of the file", func(t *testing.T) {
+		content, err := os.ReadFile(sampleFirmwarePath)
+		require.NoError(t, err)
+		firmware := writeFirmware(t, content)
+		// Point the last segment beyond the end of the file, keeping section headers intact.
+		file, err := os.OpenFile(firmware, os.O_RDWR, 0)
+		require.NoError(t, err)
+		const thirdProgramHeaderOffset = 52 + 2*32 + 4
+		_, err = file.WriteAt([]byte{0x00, 0x00, 0x01, 0x00}, thirdProgramHeaderOffset)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		err = remoteproc.ValidateFirmware(firmware, remoteproc.FirmwareRequirements{})
+
+		assert.ErrorContains(t, err, "segment 2 (offset 0x10000, size 0x44) extends past the end of the file")
+	})
+
+	t.Run("rejects Linux executables", func(t *testing.T) {
+		image := remoteproctest.NewFirmware()
+		image.Interpreter = "/lib/ld-linux-armhf.so.3"
+		firmwarePath := writeFirmware(t, image.Bytes())
+
+		err := remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{})
+
+		assert.ErrorContains(t, err, "requests a program interpreter, it looks like a Linux executable")
+	})
+
+	t.Run("rejects relocatable objects and shared objects", func(t *testing.T) {
+		for _, fileType := range []elf.Type{elf.ET_REL, elf.ET_DYN} {
+			image := remoteproctest.NewFirmware()
+			image.Type = fileType
+			firmwarePath := writeFirmware(t, image.Bytes())
+
+			err := remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{})
+
+			assert.ErrorContains(t, err, "is "+fileType.String()+", expected an executable (ET_EXEC)")
+		}
+	})
+
+	t.Run("rejects firmware built for another machine", func(t *testing.T) {
+		image := remoteproctest.NewFirmware()
+		image.Machine = elf.EM_XTENSA
+		firmwarePath := writeFirmware(t, image.Bytes())
+
+		err := remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{Arch: "arm"})
+
+		assert.ErrorContains(t, err, "is built for EM_XTENSA, expected EM_ARM for architecture arm")
+	})
+
+	t.Run("rejects firmware of another class", func(t *testing.T) {
+		image := remoteproctest.NewFirmware()
+		image.Class = elf.ELFCLASS64
+		image.Machine = elf.EM_AARCH64
+		firmwarePath := writeFirmware(t, image.Bytes())
+
+		err := remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{Arch: "arm"})
+
+		assert.ErrorContains(t, err, "is ELFCLASS64, expected ELFCLASS32 for architecture arm")
+	})
+
+	t.Run("rejects firmware of another endianness", func(t *testing.T) {
+		image := remoteproctest.NewFirmware()
+		image.Data = elf.ELFDATA2MSB
+		firmwarePath := writeFirmware(t, image.Bytes())
+
+		err := remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{Arch: "arm"})
+
+		assert.ErrorContains(t, err, "is ELFDATA2MSB, expected ELFDATA2LSB for architecture arm")
+	})
+
+	t.Run("rejects unknown architectures", func(t *testing.T) {
+		err := remoteproc.ValidateFirmware(sampleFirmwarePath, remoteproc.FirmwareRequirements{Arch: "z80"})
+
+		assert.ErrorContains(t, err, `unknown architecture "z80", supported: arm, arm64, c6000, riscv32, riscv64, xtensa`)
+	})
+
+	t.Run("rejects missing resource table when required", func(t *testing.T) {
+		image := remoteproctest.NewFirmware()
+		image.ResourceTable = nil
+		firmwarePath := writeFirmware(t, image.Bytes())
+
+		err := remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{RequireResourceTable: true})
+
+		assert.ErrorContains(t, err, "has no .resource_table section")
+	})
+
+	t.Run("rejects empty resource table section when required", func(t *testing.T) {
+		err := remoteproc.ValidateFirmware(sampleFirmwarePath, remoteproc.FirmwareRequirements{RequireResourceTable: true})
+
+		assert.ErrorContains(t, err, "has a 0 byte .resource_table section, too small for a resource table header")
+	})
+
+	t.Run("rejects images without section headers when a resource table is required", func(t *testing.T) {
+		image := remoteproctest.NewFirmware()
+		image.StripSectionHeaders = true
+		firmwarePath := writeFirmware(t, image.Bytes())
+
+		err := remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{RequireResourceTable: true})
+
+		assert.ErrorContains(t, err, "has no section headers to find its .resource_table section by")
+	})
+
+	t.Run("accepts images without section headers when no resource table is required", func(t *testing.T) {
+		image := remoteproctest.NewFirmware()
+		image.StripSectionHeaders = true
+		firmwarePath := writeFirmware(t, image.Bytes())
+
+		err := remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{Arch: "arm"})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("accepts missing resource table when not required", func(t *testing.T) {
+		image := remoteproctest.NewFirmware()
+		image.ResourceTable = nil
+		firmwarePath := writeFirmware(t, image.Bytes())
+
+		err := remoteproc.ValidateFirmware(firmwarePath, remoteproc.FirmwareRequirements{})
+
+		assert.NoError(t, err)
+	})
+}
+
+func writeFirmware(t *testing.T, content []byte) string {
+	t.Helper()
+	firmwarePath := filepath.Join(t.TempDir(), "firmware.elf")
+	require.NoError(t, os.WriteFile(firmwarePath, content, 0o644))
+	return firmwarePath
+}
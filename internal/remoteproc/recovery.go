@@ -0,0 +1,82 @@
+package remoteproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Recovery modes of a processor, selecting whether the kernel restarts crashed firmware.
+const (
+	RecoveryEnabled  = "enabled"
+	RecoveryDisabled = "disabled"
+)
+
+func (p *sysfsProcessor) SetRecovery(mode string) error {
+	if mode != RecoveryEnabled && mode != RecoveryDisabled {
+		return fmt.Errorf("unknown recovery mode %q", mode)
+	}
+	// Kernels before 5.13 only offer the debugfs file.
+	recoveryPath := filepath.Join(p.devicePath, rprocRecoveryFileName)
+	if _, err := os.Stat(recoveryPath); errors.Is(err, fs.ErrNotExist) {
+		recoveryPath = filepath.Join(p.debugfsPath(), rprocRecoveryFileName)
+	}
+	if err := os.WriteFile(recoveryPath, []byte(mode), 0o644); err != nil {
+		return fmt.Errorf("failed to set recovery mode %s: %w", mode, err)
+	}
+	return nil
+}
+
+// ReadKernelMessages reads /dev/kmsg, keeping it open between calls, and picks out the
+// messages of the processor's device and its parent. The descriptor is read directly, as
+// os.File would wait for more records rather than report there are none left.
+func (p *sysfsProcessor) ReadKernelMessages() ([]string, error) {
+	if p.kmsg == nil {
+		fd, err := unix.Open(kmsgPath, unix.O_RDONLY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", kmsgPath, err)
+		}
+		p.kmsg = &fd
+	}
+	// Messages read "<driver> <device>: <text>".
+	devices := []string{" " + filepath.Base(p.devicePath) + ": "}
+	if parent, err := filepath.EvalSymlinks(filepath.Join(p.devicePath, rprocDeviceLinkName)); err == nil {
+		devices = append(devices, " "+filepath.Base(parent)+": ")
+	}
+
+	var messages []string
+	// Each read returns one record: "<priority>,<sequence>,<timestamp>,<flags>;<message>",
+	// followed by indented key=value lines.
+	buf := make([]byte, 8192)
+	for {
+		n, err := unix.Read(*p.kmsg, buf)
+		if errors.Is(err, unix.EAGAIN) || (err == nil && n == 0) {
+			return messages, nil
+		}
+		if errors.Is(err, unix.EPIPE) || errors.Is(err, unix.EINTR) {
+			// EPIPE means records were overwritten before they were read; carry on with the
+			// next one.
+			continue
+		}
+		if err != nil {
+			return messages, fmt.Errorf("failed to read %s: %w", kmsgPath, err)
+		}
+		record, _, _ := bytes.Cut(buf[:n], []byte("\n"))
+		_, message, ok := strings.Cut(string(record), ";")
+		if !ok {
+			continue
+		}
+		for _, device := range devices {
+			if strings.Contains(message, device) {
+				messages = append(messages, message)
+				break
+			}
+		}
+	}
+}
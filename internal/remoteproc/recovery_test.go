@@ -0,0 +1,87 @@
+package remoteproc_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSysfsSetRecovery(t *testing.T) {
+	t.Run("writes the mode to the recovery attribute", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateOffline)
+		writeFixture(t, filepath.Join(devicePath, "recovery"), "enabled\n")
+		processor := sysfs.open(t, devicePath)
+
+		require.NoError(t, processor.SetRecovery(remoteproc.RecoveryDisabled))
+
+		assert.Equal(t, "disabled", readFixture(t, filepath.Join(devicePath, "recovery")))
+	})
+
+	t.Run("falls back to debugfs on kernels before 5.13", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateOffline)
+		debugfsRecoveryPath := sysfs.path("sys", "kernel", "debug", "remoteproc", "remoteproc0", "recovery")
+		writeFixture(t, debugfsRecoveryPath, "enabled\n")
+		processor := sysfs.open(t, devicePath)
+
+		require.NoError(t, processor.SetRecovery(remoteproc.RecoveryDisabled))
+
+		assert.Equal(t, "disabled", readFixture(t, debugfsRecoveryPath))
+		assert.NoFileExists(t, filepath.Join(devicePath, "recovery"))
+	})
+
+	t.Run("rejects unknown modes", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		processor := sysfs.open(t, sysfs.addProcessor(t, 0, "m33", remoteproc.StateOffline))
+
+		err := processor.SetRecovery("recover-once")
+
+		assert.EqualError(t, err, `unknown recovery mode "recover-once"`)
+	})
+}
+
+func TestSysfsReadKernelMessages(t *testing.T) {
+	t.Run("picks out the messages of the processor, leaving off where it stopped", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateCrashed)
+		writeFixture(t, sysfs.path("dev", "kmsg"), "3,1024,5678,-;remoteproc remoteproc0: crash detected in m33: type watchdog\n SUBSYSTEM=platform\n DEVICE=+platform:4c000000.m33\n")
+		processor := sysfs.open(t, devicePath)
+
+		first, err := processor.ReadKernelMessages()
+		require.NoError(t, err)
+		second, err := processor.ReadKernelMessages()
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"remoteproc remoteproc0: crash detected in m33: type watchdog"}, first)
+		assert.Empty(t, second)
+	})
+
+	t.Run("picks out the messages of the processor's parent device", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateCrashed)
+		sysfs.addParent(t, devicePath, "4c000000.m33")
+		writeFixture(t, sysfs.path("dev", "kmsg"), "4,1025,5679,-;imx-rproc 4c000000.m33: watchdog timeout\n")
+		processor := sysfs.open(t, devicePath)
+
+		got, err := processor.ReadKernelMessages()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"imx-rproc 4c000000.m33: watchdog timeout"}, got)
+	})
+
+	t.Run("skips the messages of other devices", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateCrashed)
+		writeFixture(t, sysfs.path("dev", "kmsg"), "3,1026,5680,-;remoteproc remoteproc10: crash detected in m4: type watchdog\n")
+		processor := sysfs.open(t, devicePath)
+
+		got, err := processor.ReadKernelMessages()
+
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
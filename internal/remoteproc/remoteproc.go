@@ -16,12 +16,25 @@ const (
 	rprocStateFileName        = "state"
 	rprocInstanceNameFileName = "name"
 	rprocFirmwareFileName     = "firmware"
+	rprocCoredumpFileName     = "coredump"
+	rprocRecoveryFileName     = "recovery"
+	rprocDeviceLinkName       = "device"
+	rprocOfNodeLinkName       = "of_node"
+	rprocDevicePrefix         = "remoteproc"
+	rprocTracePrefix          = "trace"
+
+	devcdFailingDeviceLinkName = "failing_device"
+	devcdDataFileName          = "data"
 )
 
 var (
-	rprocClassPath      = rootpath.Join("sys", "class", "remoteproc")
-	firmwareParamPath   = rootpath.Join("sys", "module", "firmware_class", "parameters", "path")
-	defaultFirmwarePath = rootpath.Join("lib", "firmware")
+	rprocClassPath       = rootpath.Join("sys", "class", "remoteproc")
+	firmwareParamPath    = rootpath.Join("sys", "module", "firmware_class", "parameters", "path")
+	defaultFirmwarePath  = rootpath.Join("lib", "firmware")
+	deviceTreeBasePath   = rootpath.Join("sys", "firmware", "devicetree", "base")
+	rprocDebugfsPath     = rootpath.Join("sys", "kernel", "debug", "remoteproc")
+	devcoredumpClassPath = rootpath.Join("sys", "class", "devcoredump")
+	kmsgPath             = rootpath.Join("dev", "kmsg")
 )
 
 func GetCustomFirmwarePath(customPathFile string) (string, error) {
@@ -45,28 +58,6 @@ func GetSystemFirmwarePath(logger *slog.Logger) string {
 	return customPath
 }
 
-func FindDevicePath(name string) (string, error) {
-	files, err := os.ReadDir(rprocClassPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read remoteproc directory %s: %w", rprocClassPath, err)
-	}
-
-	availableNames := []string{}
-	for _, file := range files {
-		instancePath := filepath.Join(rprocClassPath, file.Name())
-		instanceName, err := readFile(filepath.Join(instancePath, rprocInstanceNameFileName))
-		if err != nil {
-			continue
-		}
-		if instanceName == name {
-			return instancePath, nil
-		}
-		availableNames = append(availableNames, instanceName)
-	}
-
-	return "", fmt.Errorf("remote processor %s does not exist, available remote processors: %s", name, strings.Join(availableNames, ", "))
-}
-
 type State string
 
 const (
@@ -75,8 +66,20 @@ const (
 	StateRunning   State = "running"
 	StateCrashed   State = "crashed"
 	StateInvalid   State = "invalid"
+	// StateAttached is firmware booted by someone else, e.g. the bootloader, which the kernel
+	// has attached to and controls as if it had booted it.
+	StateAttached State = "attached"
+	// StateDetached is firmware booted by someone else which the kernel isn't attached to;
+	// starting the processor attaches to it.
+	StateDetached State = "detached"
 )
 
+// Running reports whether firmware runs under the kernel's control, whether the kernel booted
+// it or attached to it.
+func (s State) Running() bool {
+	return s == StateRunning || s == StateAttached
+}
+
 func newState(value string) (State, error) {
 	switch State(value) {
 	case StateOffline:
@@ -89,24 +92,15 @@ func newState(value string) (State, error) {
 		return StateCrashed, nil
 	case StateInvalid:
 		return StateInvalid, nil
+	case StateAttached:
+		return StateAttached, nil
+	case StateDetached:
+		return StateDetached, nil
 	default:
 		return "", fmt.Errorf("unknown state %s", value)
 	}
 }
 
-func GetState(devicePath string) (State, error) {
-	stateFilePath := buildStateFilePath(devicePath)
-	rawState, err := readFile(stateFilePath)
-	if err != nil {
-		return "", err
-	}
-	state, err := newState(string(rawState))
-	if err != nil {
-		return "", fmt.Errorf("can't parse state from %s: %w", stateFilePath, err)
-	}
-	return state, nil
-}
-
 // StoreFirmware copies a firmware file to kernel's firmware directory from sourcePath with a unique suffix
 // to prevent overwriting existing files. Returns the stored firmware file path.
 func StoreFirmware(sourcePath string, destDir string) (string, error) {
@@ -136,51 +130,6 @@ func StoreFirmware(sourcePath string, destDir string) (string, error) {
 	return destPath, nil
 }
 
-func SetFirmware(devicePath string, firmwareFilePath string) error {
-	state, err := GetState(devicePath)
-	if err != nil {
-		return fmt.Errorf("pre-flight state check failed: %w", err)
-	}
-	if state == StateRunning {
-		return fmt.Errorf("remote processor is already running")
-	}
-
-	firmwareFileName := filepath.Base(firmwareFilePath)
-	if err := os.WriteFile(buildFirmwareFilePath(devicePath), []byte(firmwareFileName), 0o644); err != nil {
-		return fmt.Errorf("failed to set firmware %s: %w", firmwareFileName, err)
-	}
-	return nil
-}
-
-func Start(devicePath string) error {
-	state, err := GetState(devicePath)
-	if err != nil {
-		return fmt.Errorf("pre-flight state check failed: %w", err)
-	}
-	if state == StateRunning {
-		return fmt.Errorf("remote processor is already running")
-	}
-	if err := os.WriteFile(buildStateFilePath(devicePath), []byte("start"), 0o644); err != nil {
-		return fmt.Errorf("failed to start remote processor: %w", err)
-	}
-	return nil
-}
-
-func Stop(devicePath string) error {
-	if err := os.WriteFile(buildStateFilePath(devicePath), []byte("stop"), 0o644); err != nil {
-		return fmt.Errorf("failed to stop remote processor: %w", err)
-	}
-	return nil
-}
-
-func buildStateFilePath(devicePath string) string {
-	return filepath.Join(devicePath, rprocStateFileName)
-}
-
-func buildFirmwareFilePath(devicePath string) string {
-	return filepath.Join(devicePath, rprocFirmwareFileName)
-}
-
 func readFile(path string) (string, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
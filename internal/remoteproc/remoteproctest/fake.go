@@ -0,0 +1,762 @@
+// Package remoteproctest provides an in-memory remoteproc backend for tests.
+package remoteproctest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// ErrRefused is a convenience error for RefuseStart.
+var ErrRefused = errors.New("boot refused by fake processor")
+
+var _ remoteproc.Backend = (*Backend)(nil)
+
+// backendCount keeps device paths of separate fake backends distinct.
+var backendCount atomic.Int64
+
+// Backend is a fake remoteproc.Backend holding processors in memory.
+type Backend struct {
+	root        string
+	mu          sync.Mutex
+	processors  []*Processor
+	firmwareDir string
+}
+
+// NewBackend returns an empty fake backend which expects firmware to be stored in firmwareDir.
+func NewBackend(firmwareDir string) *Backend {
+	return &Backend{
+		root:        fmt.Sprintf("/fake/%d/sys/class/remoteproc", backendCount.Add(1)),
+		firmwareDir: firmwareDir,
+	}
+}
+
+// AddProcessor registers a new offline processor with the given name.
+func (b *Backend) AddProcessor(name string) *Processor {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	processor := &Processor{
+		backend:    b,
+		index:      len(b.processors),
+		devicePath: fmt.Sprintf("%s/remoteproc%d", b.root, len(b.processors)),
+		name:       name,
+		state:      remoteproc.StateOffline,
+	}
+	b.processors = append(b.processors, processor)
+	return processor
+}
+
+func (b *Backend) Processors() ([]remoteproc.Processor, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	processors := make([]remoteproc.Processor, len(b.processors))
+	for i, processor := range b.processors {
+		processors[i] = processor
+	}
+	return processors, nil
+}
+
+func (b *Backend) Open(devicePath string) (remoteproc.Processor, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, processor := range b.processors {
+		if processor.devicePath == devicePath {
+			return processor, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to read file %s: %w", filepath.Join(devicePath, "name"), os.ErrNotExist)
+}
+
+func (b *Backend) FirmwareDir() string {
+	return b.firmwareDir
+}
+
+var _ remoteproc.Processor = (*Processor)(nil)
+
+// Processor is a fake remote processor following the kernel's remoteproc state machine.
+type Processor struct {
+	backend    *Backend
+	index      int
+	devicePath string
+	name       string
+
+	mu             sync.Mutex
+	state          remoteproc.State
+	firmware       string
+	parentDevice   string
+	deviceTreeNode string
+	startDelay     time.Duration
+	startErr       error
+	infoErr        error
+	startCount     int
+	attachCount    int
+	stopCount      int
+	traces         []*traceBuffer
+	ttys           []*ttyDevice
+	rpmsgDevices   []remoteproc.RPMsgDevice
+	rpmsgCtrl      bool
+	endpoints      []remoteproc.RPMsgEndpoint
+	endpointCount  int
+	rpmsgHandlers  map[string]func(message []byte) []byte
+	rpmsgInput     map[string]*bytes.Buffer
+	rpmsgConns     []*rpmsgConn
+	coredumpMode   string
+	coredumps      [][]byte
+	recovery       string
+	kernelLog      []string
+}
+
+// traceBuffer is a circular buffer the firmware writes its log into.
+type traceBuffer struct {
+	name string
+	data []byte
+	pos  int
+}
+
+// ttyDevice is an RPMsg TTY. While open, firmware output flows through a pipe to the host end
+// and host input accumulates in input.
+type ttyDevice struct {
+	name   string
+	input  bytes.Buffer
+	output *io.PipeWriter
+}
+
+func (p *Processor) DevicePath() string {
+	return p.devicePath
+}
+
+func (p *Processor) Name() string {
+	return p.name
+}
+
+func (p *Processor) State() (remoteproc.State, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state, nil
+}
+
+func (p *Processor) Info() (remoteproc.Info, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.infoErr != nil {
+		return remoteproc.Info{}, p.infoErr
+	}
+	return remoteproc.Info{
+		Index:          p.index,
+		Name:           p.name,
+		DevicePath:     p.devicePath,
+		State:          p.state,
+		Firmware:       p.firmware,
+		Coredump:       p.coredumpModeLocked(),
+		Recovery:       p.recoveryLocked(),
+		ParentDevice:   p.parentDevice,
+		DeviceTreeNode: p.deviceTreeNode,
+	}, nil
+}
+
+func (p *Processor) SetFirmware(firmwareFilePath string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state.Running() {
+		return fmt.Errorf("remote processor is already running")
+	}
+	p.firmware = filepath.Base(firmwareFilePath)
+	return nil
+}
+
+func (p *Processor) Start() error {
+	p.mu.Lock()
+	delay := p.startDelay
+	p.mu.Unlock()
+	// Booting is synchronous in the kernel, so a slow core blocks the writer.
+	time.Sleep(delay)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state.Running() {
+		return fmt.Errorf("remote processor is already running")
+	}
+	if p.startErr != nil {
+		return fmt.Errorf("failed to start remote processor: %w", p.startErr)
+	}
+	if p.state == remoteproc.StateDetached {
+		// Starting attaches to the firmware already running.
+		p.state = remoteproc.StateAttached
+		p.startCount++
+		return nil
+	}
+	if p.firmware == "" {
+		return fmt.Errorf("failed to start remote processor: no firmware set")
+	}
+	if _, err := os.Stat(filepath.Join(p.backend.firmwareDir, p.firmware)); err != nil {
+		return fmt.Errorf("failed to start remote processor: %w", err)
+	}
+	p.state = remoteproc.StateRunning
+	p.startCount++
+	for _, trace := range p.traces {
+		clear(trace.data)
+		trace.pos = 0
+	}
+	return nil
+}
+
+func (p *Processor) Attach() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.state.Running() {
+		return fmt.Errorf("remote processor is %s, not running", p.state)
+	}
+	p.attachCount++
+	return nil
+}
+
+func (p *Processor) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.state.Running() && p.state != remoteproc.StateCrashed {
+		return fmt.Errorf("failed to stop remote processor: not running")
+	}
+	p.state = remoteproc.StateOffline
+	p.stopCount++
+	p.closeTTYs()
+	p.closeRPMsgConns()
+	p.endpoints = nil
+	return nil
+}
+
+func (p *Processor) Detach() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state != remoteproc.StateAttached {
+		return fmt.Errorf("remote processor is %s, not attached", p.state)
+	}
+	p.state = remoteproc.StateDetached
+	p.closeTTYs()
+	p.closeRPMsgConns()
+	p.endpoints = nil
+	return nil
+}
+
+// Crash moves a running processor to the crashed state, as a firmware fault would.
+func (p *Processor) Crash() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state = remoteproc.StateCrashed
+	p.closeTTYs()
+	p.closeRPMsgConns()
+}
+
+// SetStartDelay makes subsequent starts block for delay before the processor runs.
+func (p *Processor) SetStartDelay(delay time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.startDelay = delay
+}
+
+// RefuseStart makes subsequent starts fail with err; a nil err restores normal behaviour.
+func (p *Processor) RefuseStart(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.startErr = err
+}
+
+// FailInfo makes subsequent Info calls fail with err, as when the processor's sysfs attributes
+// can't be read; a nil err restores normal behaviour.
+func (p *Processor) FailInfo(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.infoErr = err
+}
+
+// ForceState sets the processor state directly, bypassing the state machine.
+func (p *Processor) ForceState(state remoteproc.State) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state = state
+}
+
+// SetParent sets the parent platform device and its device tree node reported by Info.
+func (p *Processor) SetParent(device string, deviceTreeNode string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.parentDevice = device
+	p.deviceTreeNode = deviceTreeNode
+}
+
+// Firmware returns the name of the currently selected firmware file.
+func (p *Processor) Firmware() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.firmware
+}
+
+// StartCount returns how many times the processor was successfully started.
+func (p *Processor) StartCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.startCount
+}
+
+// AttachCount returns how many times running firmware was successfully attached to.
+func (p *Processor) AttachCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.attachCount
+}
+
+// StopCount returns how many times the processor was successfully stopped.
+func (p *Processor) StopCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopCount
+}
+
+func (p *Processor) TraceBuffers() ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.hasTraces() {
+		return nil, nil
+	}
+	names := make([]string, len(p.traces))
+	for i, trace := range p.traces {
+		names[i] = trace.name
+	}
+	return names, nil
+}
+
+func (p *Processor) ReadTrace(name string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	trace := p.trace(name)
+	if trace == nil || !p.hasTraces() {
+		return nil, fmt.Errorf("failed to read trace %s: %w", name, os.ErrNotExist)
+	}
+	content := trace.data
+	if i := bytes.IndexByte(content, 0); i >= 0 {
+		content = content[:i]
+	}
+	return bytes.Clone(content), nil
+}
+
+// AddTraceBuffer declares a trace buffer of the given size. Like the kernel's, it exists only
+// while the processor is running or crashed, and is cleared on every start.
+func (p *Processor) AddTraceBuffer(name string, size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.traces = append(p.traces, &traceBuffer{name: name, data: make([]byte, size)})
+}
+
+// WriteTrace writes to a trace buffer as the firmware would, wrapping around at its end.
+func (p *Processor) WriteTrace(name string, text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	trace := p.trace(name)
+	for i := range len(text) {
+		trace.data[trace.pos] = text[i]
+		trace.pos = (trace.pos + 1) % len(trace.data)
+	}
+}
+
+func (p *Processor) trace(name string) *traceBuffer {
+	for _, trace := range p.traces {
+		if trace.name == name {
+			return trace
+		}
+	}
+	return nil
+}
+
+func (p *Processor) hasTraces() bool {
+	return p.state.Running() || p.state == remoteproc.StateCrashed
+}
+
+func (p *Processor) TTYs() ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.state.Running() {
+		return nil, nil
+	}
+	names := make([]string, len(p.ttys))
+	for i, tty := range p.ttys {
+		names[i] = tty.name
+	}
+	return names, nil
+}
+
+func (p *Processor) OpenTTY(name string) (io.ReadWriteCloser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tty := p.tty(name)
+	if tty == nil || !p.state.Running() {
+		return nil, fmt.Errorf("failed to open %s: %w", name, os.ErrNotExist)
+	}
+	r, w := io.Pipe()
+	tty.output = w
+	return &ttyHostEnd{processor: p, tty: tty, reader: r, writer: w}, nil
+}
+
+// AddTTY declares an RPMsg TTY, which exists while the processor is running.
+func (p *Processor) AddTTY(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ttys = append(p.ttys, &ttyDevice{name: name})
+}
+
+// TTYOpen reports whether the host holds the TTY open.
+func (p *Processor) TTYOpen(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tty := p.tty(name)
+	return tty != nil && tty.output != nil
+}
+
+// WriteTTY sends text from the firmware to the host, blocking until the host reads it.
+func (p *Processor) WriteTTY(name string, text string) error {
+	p.mu.Lock()
+	tty := p.tty(name)
+	var output *io.PipeWriter
+	if tty != nil {
+		output = tty.output
+	}
+	p.mu.Unlock()
+	if output == nil {
+		return fmt.Errorf("%s is not open", name)
+	}
+	_, err := output.Write([]byte(text))
+	return err
+}
+
+// TTYInput returns everything the host has written to the TTY.
+func (p *Processor) TTYInput(name string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tty := p.tty(name)
+	if tty == nil {
+		return ""
+	}
+	return tty.input.String()
+}
+
+func (p *Processor) tty(name string) *ttyDevice {
+	for _, tty := range p.ttys {
+		if tty.name == name {
+			return tty
+		}
+	}
+	return nil
+}
+
+// closeTTYs hangs up open TTYs, as the kernel does when the firmware goes away.
+func (p *Processor) closeTTYs() {
+	for _, tty := range p.ttys {
+		if tty.output != nil {
+			_ = tty.output.CloseWithError(io.ErrUnexpectedEOF)
+			tty.output = nil
+		}
+	}
+}
+
+type ttyHostEnd struct {
+	processor *Processor
+	tty       *ttyDevice
+	reader    *io.PipeReader
+	writer    *io.PipeWriter
+}
+
+func (t *ttyHostEnd) Read(b []byte) (int, error) {
+	return t.reader.Read(b)
+}
+
+func (t *ttyHostEnd) Write(b []byte) (int, error) {
+	t.processor.mu.Lock()
+	defer t.processor.mu.Unlock()
+	if !t.processor.state.Running() {
+		return 0, fmt.Errorf("failed to write %s: %w", t.tty.name, os.ErrClosed)
+	}
+	return t.tty.input.Write(b)
+}
+
+func (t *ttyHostEnd) Close() error {
+	t.processor.mu.Lock()
+	defer t.processor.mu.Unlock()
+	if t.tty.output == t.writer {
+		t.tty.output = nil
+	}
+	return t.reader.Close()
+}
+
+func (p *Processor) RPMsgDevices() ([]remoteproc.RPMsgDevice, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	devices := []remoteproc.RPMsgDevice{}
+	if p.state.Running() {
+		devices = append(devices, p.rpmsgDevices...)
+	}
+	return devices, nil
+}
+
+// AddRPMsgDevice declares an RPMsg channel, which is announced while the processor is running.
+func (p *Processor) AddRPMsgDevice(device remoteproc.RPMsgDevice) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rpmsgDevices = append(p.rpmsgDevices, device)
+}
+
+func (p *Processor) CreateEndpoint(endpoint remoteproc.RPMsgEndpoint) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.state.Running() || !p.rpmsgCtrl {
+		return "", fmt.Errorf("no RPMsg control device for %s: %w", p.name, os.ErrNotExist)
+	}
+	endpoint.DeviceNode = fmt.Sprintf("/dev/rpmsg%d", p.endpointCount)
+	p.endpointCount++
+	p.endpoints = append(p.endpoints, endpoint)
+	return endpoint.DeviceNode, nil
+}
+
+func (p *Processor) DestroyEndpoint(deviceNode string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, endpoint := range p.endpoints {
+		if endpoint.DeviceNode == deviceNode {
+			p.endpoints = append(p.endpoints[:i], p.endpoints[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to open %s: %w", deviceNode, os.ErrNotExist)
+}
+
+// EnableRPMsgCtrl makes the RPMsg control device available, as probing the firmware's virtio
+// rpmsg device does, so endpoints can be created while the processor runs.
+func (p *Processor) EnableRPMsgCtrl() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rpmsgCtrl = true
+}
+
+func (p *Processor) RPMsgEndpoints() ([]remoteproc.RPMsgEndpoint, error) {
+	return p.Endpoints(), nil
+}
+
+// Endpoints returns the endpoints that currently exist.
+func (p *Processor) Endpoints() []remoteproc.RPMsgEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]remoteproc.RPMsgEndpoint{}, p.endpoints...)
+}
+
+func (p *Processor) OpenRPMsg(deviceNode string) (io.ReadWriteCloser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.state.Running() || !p.hasRPMsgDeviceNode(deviceNode) {
+		return nil, fmt.Errorf("failed to open %s: %w", deviceNode, os.ErrNotExist)
+	}
+	r, w := io.Pipe()
+	conn := &rpmsgConn{processor: p, deviceNode: deviceNode, reader: r, writer: w}
+	p.rpmsgConns = append(p.rpmsgConns, conn)
+	return conn, nil
+}
+
+// HandleRPMsg sets how the firmware answers messages written to an RPMsg device node: handler
+// receives each message and returns the reply, or nil to stay silent. It is called without the
+// processor locked, so it may change the processor's state, e.g. to go offline.
+func (p *Processor) HandleRPMsg(deviceNode string, handler func(message []byte) []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rpmsgHandlers == nil {
+		p.rpmsgHandlers = map[string]func([]byte) []byte{}
+	}
+	p.rpmsgHandlers[deviceNode] = handler
+}
+
+// RPMsgInput returns everything the host has written to an RPMsg device node.
+func (p *Processor) RPMsgInput(deviceNode string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if input, ok := p.rpmsgInput[deviceNode]; ok {
+		return input.String()
+	}
+	return ""
+}
+
+func (p *Processor) hasRPMsgDeviceNode(deviceNode string) bool {
+	for _, device := range p.rpmsgDevices {
+		if device.DeviceNode == deviceNode {
+			return true
+		}
+	}
+	for _, endpoint := range p.endpoints {
+		if endpoint.DeviceNode == deviceNode {
+			return true
+		}
+	}
+	return false
+}
+
+// closeRPMsgConns hangs up open RPMsg device nodes, as the kernel does when the firmware goes away.
+func (p *Processor) closeRPMsgConns() {
+	for _, conn := range p.rpmsgConns {
+		_ = conn.writer.CloseWithError(io.ErrUnexpectedEOF)
+	}
+	p.rpmsgConns = nil
+}
+
+func (p *Processor) SetCoredump(mode string) error {
+	if !slices.Contains(remoteproc.CoredumpModes, mode) {
+		return fmt.Errorf("unknown coredump mode %q", mode)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.coredumpMode = mode
+	return nil
+}
+
+// CoredumpMode returns the coredump mode last set, disabled if none was.
+func (p *Processor) CoredumpMode() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.coredumpModeLocked()
+}
+
+func (p *Processor) coredumpModeLocked() string {
+	if p.coredumpMode == "" {
+		return remoteproc.CoredumpDisabled
+	}
+	return p.coredumpMode
+}
+
+func (p *Processor) OpenCoredump() (io.ReadCloser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.coredumps) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return &coredump{Reader: bytes.NewReader(p.coredumps[0]), processor: p}, nil
+}
+
+// AddCoredump makes a dump of crashed firmware available, as the kernel does while recovering
+// a processor whose coredump mode isn't disabled.
+func (p *Processor) AddCoredump(data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.coredumps = append(p.coredumps, data)
+}
+
+// Coredumps returns how many dumps are waiting to be read and dismissed.
+func (p *Processor) Coredumps() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.coredumps)
+}
+
+func (p *Processor) SetRecovery(mode string) error {
+	if mode != remoteproc.RecoveryEnabled && mode != remoteproc.RecoveryDisabled {
+		return fmt.Errorf("unknown recovery mode %q", mode)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recovery = mode
+	return nil
+}
+
+// Recovery returns the recovery mode last set, enabled if none was, like the kernel's default.
+func (p *Processor) Recovery() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.recoveryLocked()
+}
+
+func (p *Processor) recoveryLocked() string {
+	if p.recovery == "" {
+		return remoteproc.RecoveryEnabled
+	}
+	return p.recovery
+}
+
+func (p *Processor) ReadKernelMessages() ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	messages := p.kernelLog
+	p.kernelLog = nil
+	return messages, nil
+}
+
+// LogKernelMessage logs a kernel message about the processor, e.g. the driver's
+// "remoteproc remoteproc0: crash detected in m33: type watchdog".
+func (p *Processor) LogKernelMessage(message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.kernelLog = append(p.kernelLog, message)
+}
+
+// coredump dismisses the oldest dump once closed.
+type coredump struct {
+	*bytes.Reader
+	processor *Processor
+}
+
+func (c *coredump) Close() error {
+	c.processor.mu.Lock()
+	defer c.processor.mu.Unlock()
+	if len(c.processor.coredumps) > 0 {
+		c.processor.coredumps = c.processor.coredumps[1:]
+	}
+	return nil
+}
+
+type rpmsgConn struct {
+	processor  *Processor
+	deviceNode string
+	reader     *io.PipeReader
+	writer     *io.PipeWriter
+}
+
+func (c *rpmsgConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func (c *rpmsgConn) Write(b []byte) (int, error) {
+	c.processor.mu.Lock()
+	if !c.processor.state.Running() {
+		c.processor.mu.Unlock()
+		return 0, fmt.Errorf("failed to write %s: %w", c.deviceNode, os.ErrClosed)
+	}
+	if c.processor.rpmsgInput == nil {
+		c.processor.rpmsgInput = map[string]*bytes.Buffer{}
+	}
+	if c.processor.rpmsgInput[c.deviceNode] == nil {
+		c.processor.rpmsgInput[c.deviceNode] = &bytes.Buffer{}
+	}
+	c.processor.rpmsgInput[c.deviceNode].Write(b)
+	handler := c.processor.rpmsgHandlers[c.deviceNode]
+	c.processor.mu.Unlock()
+
+	if handler != nil {
+		if reply := handler(bytes.Clone(b)); len(reply) > 0 {
+			// The reply waits in the pipe until the host reads it, or closes its end.
+			go func() { _, _ = c.writer.Write(reply) }()
+		}
+	}
+	return len(b), nil
+}
+
+func (c *rpmsgConn) Close() error {
+	c.processor.mu.Lock()
+	defer c.processor.mu.Unlock()
+	for i, conn := range c.processor.rpmsgConns {
+		if conn == c {
+			c.processor.rpmsgConns = append(c.processor.rpmsgConns[:i], c.processor.rpmsgConns[i+1:]...)
+			break
+		}
+	}
+	return c.reader.Close()
+}
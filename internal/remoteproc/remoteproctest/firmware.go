@@ -0,0 +1,217 @@
+package remoteproctest
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// Firmware describes a minimal ELF firmware image for tests.
+type Firmware struct {
+	Class   elf.Class
+	Data    elf.Data
+	Machine elf.Machine
+	// Type is the object file type; zero means ET_EXEC.
+	Type elf.Type
+	// StripSectionHeaders omits the section header table, as some loaders' images do.
+	StripSectionHeaders bool
+	// Interpreter adds a PT_INTERP segment, as found in dynamically linked Linux executables.
+	Interpreter string
+	// ResourceTable is the content of the .resource_table section; nil omits the section.
+	ResourceTable []byte
+}
+
+// NewFirmware returns a 32-bit little-endian ARM image with an empty resource table.
+func NewFirmware() *Firmware {
+	return &Firmware{
+		Class:         elf.ELFCLASS32,
+		Data:          elf.ELFDATA2LSB,
+		Machine:       elf.EM_ARM,
+		ResourceTable: EmptyResourceTable(),
+	}
+}
+
+// EmptyResourceTable returns a version 1 resource table header without entries.
+func EmptyResourceTable() []byte {
+	return NewResourceTable().Bytes()
+}
+
+// ResourceTable builds resource table section content.
+type ResourceTable struct {
+	entries [][]byte
+}
+
+func NewResourceTable() *ResourceTable {
+	return &ResourceTable{}
+}
+
+func (r *ResourceTable) Carveout(name string, da, pa, length uint32) *ResourceTable {
+	return r.memory(remoteproc.ResourceCarveout, name, da, pa, length)
+}
+
+func (r *ResourceTable) DevMem(name string, da, pa, length uint32) *ResourceTable {
+	return r.memory(remoteproc.ResourceDevMem, name, da, pa, length)
+}
+
+func (r *ResourceTable) Trace(name string, da, length uint32) *ResourceTable {
+	return r.add(remoteproc.ResourceTrace, da, length, uint32(0), name32(name))
+}
+
+// Vdev adds a virtio device with one vring per element of vrings and no config space.
+func (r *ResourceTable) Vdev(id, notifyID, features uint32, vrings ...remoteproc.Vring) *ResourceTable {
+	fields := []any{id, notifyID, features, uint32(0), uint32(0), uint8(0), uint8(len(vrings)), [2]uint8{}}
+	for _, vring := range vrings {
+		fields = append(fields, vring.DeviceAddress, vring.Align, vring.Num, vring.NotifyID, uint32(0))
+	}
+	return r.add(remoteproc.ResourceVdev, fields...)
+}
+
+func (r *ResourceTable) memory(resourceType remoteproc.ResourceType, name string, da, pa, length uint32) *ResourceTable {
+	return r.add(resourceType, da, pa, length, uint32(0), uint32(0), name32(name))
+}
+
+func (r *ResourceTable) add(resourceType remoteproc.ResourceType, fields ...any) *ResourceTable {
+	var entry bytes.Buffer
+	_ = binary.Write(&entry, binary.LittleEndian, uint32(resourceType))
+	for _, field := range fields {
+		_ = binary.Write(&entry, binary.LittleEndian, field)
+	}
+	r.entries = append(r.entries, entry.Bytes())
+	return r
+}
+
+// Bytes encodes the table in little-endian byte order.
+func (r *ResourceTable) Bytes() []byte {
+	var table bytes.Buffer
+	write := func(v any) { _ = binary.Write(&table, binary.LittleEndian, v) }
+	write([4]uint32{1, uint32(len(r.entries)), 0, 0})
+	offset := 16 + 4*len(r.entries)
+	for _, entry := range r.entries {
+		write(uint32(offset))
+		offset += len(entry)
+	}
+	for _, entry := range r.entries {
+		table.Write(entry)
+	}
+	return table.Bytes()
+}
+
+func name32(name string) [32]byte {
+	var b [32]byte
+	copy(b[:], name)
+	return b
+}
+
+type section struct {
+	name      string
+	sectType  elf.SectionType
+	flags     elf.SectionFlag
+	addr      uint64
+	content   []byte
+	offset    uint64
+	nameIndex uint32
+}
+
+type segment struct {
+	progType elf.ProgType
+	flags    elf.ProgFlag
+	addr     uint64
+	section  *section
+}
+
+const loadAddress = 0x1ffe0000
+
+// Bytes encodes the image.
+func (f *Firmware) Bytes() []byte {
+	var order binary.ByteOrder = binary.LittleEndian
+	if f.Data == elf.ELFDATA2MSB {
+		order = binary.BigEndian
+	}
+	is64 := f.Class == elf.ELFCLASS64
+	ehdrSize, phdrSize, shdrSize := uint64(52), uint64(32), uint64(40)
+	if is64 {
+		ehdrSize, phdrSize, shdrSize = 64, 56, 64
+	}
+
+	text := &section{name: ".text", sectType: elf.SHT_PROGBITS, flags: elf.SHF_ALLOC | elf.SHF_EXECINSTR, addr: loadAddress, content: bytes.Repeat([]byte{0xbf, 0x00}, 8)}
+	sections := []*section{{}, text}
+	segments := []segment{{progType: elf.PT_LOAD, flags: elf.PF_R | elf.PF_X, addr: loadAddress, section: text}}
+	if f.Interpreter != "" {
+		interp := &section{name: ".interp", sectType: elf.SHT_PROGBITS, flags: elf.SHF_ALLOC, content: append([]byte(f.Interpreter), 0)}
+		sections = append(sections, interp)
+		segments = append([]segment{{progType: elf.PT_INTERP, flags: elf.PF_R, section: interp}}, segments...)
+	}
+	if f.ResourceTable != nil {
+		sections = append(sections, &section{name: ".resource_table", sectType: elf.SHT_PROGBITS, flags: elf.SHF_ALLOC | elf.SHF_WRITE, addr: loadAddress + 0x1000, content: f.ResourceTable})
+	}
+	shstrtab := &section{name: ".shstrtab", sectType: elf.SHT_STRTAB}
+	sections = append(sections, shstrtab)
+	names := []byte{0}
+	for _, s := range sections[1:] {
+		s.nameIndex = uint32(len(names))
+		names = append(append(names, s.name...), 0)
+	}
+	shstrtab.content = names
+
+	offset := ehdrSize + phdrSize*uint64(len(segments))
+	for _, s := range sections[1:] {
+		offset = (offset + 3) &^ 3
+		s.offset = offset
+		offset += uint64(len(s.content))
+	}
+	shoff := (offset + 3) &^ 3
+
+	fileType := f.Type
+	if fileType == elf.ET_NONE {
+		fileType = elf.ET_EXEC
+	}
+	shnum, shstrndx := uint16(len(sections)), uint16(len(sections)-1)
+	if f.StripSectionHeaders {
+		shoff, shnum, shstrndx = 0, 0, 0
+	}
+
+	var buf bytes.Buffer
+	ident := [elf.EI_NIDENT]byte{0x7f, 'E', 'L', 'F', byte(f.Class), byte(f.Data), byte(elf.EV_CURRENT)}
+	write := func(v any) { _ = binary.Write(&buf, order, v) }
+	if is64 {
+		write(elf.Header64{
+			Ident: ident, Type: uint16(fileType), Machine: uint16(f.Machine), Version: uint32(elf.EV_CURRENT),
+			Entry: loadAddress, Phoff: ehdrSize, Shoff: shoff, Ehsize: uint16(ehdrSize),
+			Phentsize: uint16(phdrSize), Phnum: uint16(len(segments)),
+			Shentsize: uint16(shdrSize), Shnum: shnum, Shstrndx: shstrndx,
+		})
+		for _, p := range segments {
+			size := uint64(len(p.section.content))
+			write(elf.Prog64{Type: uint32(p.progType), Flags: uint32(p.flags), Off: p.section.offset, Vaddr: p.addr, Paddr: p.addr, Filesz: size, Memsz: size, Align: 4})
+		}
+	} else {
+		write(elf.Header32{
+			Ident: ident, Type: uint16(fileType), Machine: uint16(f.Machine), Version: uint32(elf.EV_CURRENT),
+			Entry: loadAddress, Phoff: uint32(ehdrSize), Shoff: uint32(shoff), Ehsize: uint16(ehdrSize),
+			Phentsize: uint16(phdrSize), Phnum: uint16(len(segments)),
+			Shentsize: uint16(shdrSize), Shnum: shnum, Shstrndx: shstrndx,
+		})
+		for _, p := range segments {
+			size := uint32(len(p.section.content))
+			write(elf.Prog32{Type: uint32(p.progType), Flags: uint32(p.flags), Off: uint32(p.section.offset), Vaddr: uint32(p.addr), Paddr: uint32(p.addr), Filesz: size, Memsz: size, Align: 4})
+		}
+	}
+	for _, s := range sections[1:] {
+		buf.Write(make([]byte, s.offset-uint64(buf.Len())))
+		buf.Write(s.content)
+	}
+	if f.StripSectionHeaders {
+		return buf.Bytes()
+	}
+	buf.Write(make([]byte, shoff-uint64(buf.Len())))
+	for _, s := range sections {
+		if is64 {
+			write(elf.Section64{Name: s.nameIndex, Type: uint32(s.sectType), Flags: uint64(s.flags), Addr: s.addr, Off: s.offset, Size: uint64(len(s.content)), Addralign: 1})
+		} else {
+			write(elf.Section32{Name: s.nameIndex, Type: uint32(s.sectType), Flags: uint32(s.flags), Addr: uint32(s.addr), Off: uint32(s.offset), Size: uint32(len(s.content)), Addralign: 1})
+		}
+	}
+	return buf.Bytes()
+}
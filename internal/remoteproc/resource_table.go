@@ -0,0 +1,283 @@
+package remoteproc
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoResourceTable is returned for firmware without a usable .resource_table section.
+var ErrNoResourceTable = errors.New("firmware has no resource table")
+
+// ResourceType is the type of a resource table entry, as defined in linux/remoteproc.h.
+type ResourceType uint32
+
+const (
+	ResourceCarveout ResourceType = 0
+	ResourceDevMem   ResourceType = 1
+	ResourceTrace    ResourceType = 2
+	ResourceVdev     ResourceType = 3
+
+	resourceVendorStart ResourceType = 128
+	resourceVendorEnd   ResourceType = 512
+)
+
+func (t ResourceType) String() string {
+	switch t {
+	case ResourceCarveout:
+		return "carveout"
+	case ResourceDevMem:
+		return "devmem"
+	case ResourceTrace:
+		return "trace"
+	case ResourceVdev:
+		return "vdev"
+	}
+	if t >= resourceVendorStart && t <= resourceVendorEnd {
+		return fmt.Sprintf("vendor(%d)", uint32(t))
+	}
+	return fmt.Sprintf("unknown(%d)", uint32(t))
+}
+
+func (t ResourceType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// AddressAny (FW_RSC_ADDR_ANY) lets the kernel choose the address.
+const AddressAny = 0xffffffff
+
+const (
+	resourceTableVersion    = 1
+	resourceNameSize        = 32
+	resourceMemorySize      = 5*4 + resourceNameSize
+	resourceTraceSize       = 3*4 + resourceNameSize
+	resourceVdevHeaderSize  = 5*4 + 4
+	resourceVdevVringSize   = 5 * 4
+	resourceEntryHeaderSize = 4
+)
+
+// ResourceTable is the parsed content of a firmware's .resource_table section.
+type ResourceTable struct {
+	Version uint32     `json:"version"`
+	Entries []Resource `json:"entries"`
+}
+
+// Resource is a single resource table entry; the field matching Type is set.
+type Resource struct {
+	Type     ResourceType    `json:"type"`
+	Offset   uint32          `json:"offset"`
+	Carveout *MemoryResource `json:"carveout,omitempty"`
+	DevMem   *MemoryResource `json:"devmem,omitempty"`
+	Trace    *TraceResource  `json:"trace,omitempty"`
+	Vdev     *VdevResource   `json:"vdev,omitempty"`
+}
+
+// MemoryResource is a carveout or devmem entry.
+type MemoryResource struct {
+	Name            string `json:"name"`
+	DeviceAddress   uint32 `json:"da"`
+	PhysicalAddress uint32 `json:"pa"`
+	Length          uint32 `json:"len"`
+	Flags           uint32 `json:"flags"`
+}
+
+func (m MemoryResource) String() string {
+	return fmt.Sprintf("%s da=%s pa=%s len=%#x", m.Name, formatAddress(m.DeviceAddress), formatAddress(m.PhysicalAddress), m.Length)
+}
+
+// TraceResource declares a trace buffer the firmware writes its log to.
+type TraceResource struct {
+	Name          string `json:"name"`
+	DeviceAddress uint32 `json:"da"`
+	Length        uint32 `json:"len"`
+}
+
+func (t TraceResource) String() string {
+	return fmt.Sprintf("%s da=%s len=%#x", t.Name, formatAddress(t.DeviceAddress), t.Length)
+}
+
+// VdevResource declares a virtio device, typically rpmsg, and its vrings.
+type VdevResource struct {
+	ID             uint32  `json:"id"`
+	NotifyID       uint32  `json:"notifyId"`
+	DeviceFeatures uint32  `json:"deviceFeatures"`
+	ConfigLength   uint32  `json:"configLength"`
+	Vrings         []Vring `json:"vrings"`
+}
+
+func (v VdevResource) String() string {
+	vrings := make([]string, len(v.Vrings))
+	for i, vring := range v.Vrings {
+		vrings[i] = fmt.Sprintf("vring%d %s", i, vring)
+	}
+	description := fmt.Sprintf("%s notifyid=%d features=%#x", v.DeviceName(), v.NotifyID, v.DeviceFeatures)
+	if len(vrings) == 0 {
+		return description
+	}
+	return description + " " + strings.Join(vrings, " ")
+}
+
+// DeviceName names the virtio device type.
+func (v VdevResource) DeviceName() string {
+	switch v.ID {
+	case 3:
+		return "console"
+	case 7:
+		return "rpmsg"
+	}
+	return fmt.Sprintf("virtio(%d)", v.ID)
+}
+
+type Vring struct {
+	DeviceAddress uint32 `json:"da"`
+	Align         uint32 `json:"align"`
+	Num           uint32 `json:"num"`
+	NotifyID      uint32 `json:"notifyId"`
+}
+
+func (v Vring) String() string {
+	return fmt.Sprintf("(da=%s num=%d align=%#x notifyid=%d)", formatAddress(v.DeviceAddress), v.Num, v.Align, v.NotifyID)
+}
+
+// ReadResourceTable parses the resource table of an ELF firmware image. Like the kernel, it
+// treats a section too small for the table header as absent and returns ErrNoResourceTable.
+func ReadResourceTable(firmwareFilePath string) (*ResourceTable, error) {
+	image, err := elf.Open(firmwareFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("firmware %s is not a valid ELF image: %w", firmwareFilePath, err)
+	}
+	defer func() { _ = image.Close() }()
+	section := image.Section(resourceTableSectionName)
+	if section == nil || section.Size < resourceTableHeaderSize || section.Type == elf.SHT_NOBITS {
+		return nil, ErrNoResourceTable
+	}
+	data, err := section.Data()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s section: %w", resourceTableSectionName, err)
+	}
+	return ParseResourceTable(data, image.ByteOrder)
+}
+
+// ParseResourceTable decodes a resource table, applying the bounds checks the kernel does.
+func ParseResourceTable(data []byte, order binary.ByteOrder) (*ResourceTable, error) {
+	if len(data) < resourceTableHeaderSize {
+		return nil, fmt.Errorf("resource table is %d bytes, too small for its %d byte header", len(data), resourceTableHeaderSize)
+	}
+	table := &ResourceTable{Version: order.Uint32(data[0:])}
+	if table.Version != resourceTableVersion {
+		return nil, fmt.Errorf("unsupported resource table version %d", table.Version)
+	}
+	num := order.Uint32(data[4:])
+	if uint64(resourceTableHeaderSize)+uint64(num)*4 > uint64(len(data)) {
+		return nil, fmt.Errorf("resource table declares %d entries, more than fit in %d bytes", num, len(data))
+	}
+
+	table.Entries = make([]Resource, num)
+	for i := range table.Entries {
+		offset := order.Uint32(data[resourceTableHeaderSize+4*i:])
+		entry, err := parseResource(data, offset, order)
+		if err != nil {
+			return nil, fmt.Errorf("resource table entry %d: %w", i, err)
+		}
+		table.Entries[i] = entry
+	}
+	return table, nil
+}
+
+func parseResource(table []byte, offset uint32, order binary.ByteOrder) (Resource, error) {
+	if uint64(offset)+resourceEntryHeaderSize > uint64(len(table)) {
+		return Resource{}, fmt.Errorf("offset %#x is outside the table", offset)
+	}
+	resource := Resource{Type: ResourceType(order.Uint32(table[offset:])), Offset: offset}
+	body := table[offset+resourceEntryHeaderSize:]
+	need := func(size int) error {
+		if len(body) < size {
+			return fmt.Errorf("%s entry at offset %#x is truncated", resource.Type, offset)
+		}
+		return nil
+	}
+
+	switch resource.Type {
+	case ResourceCarveout, ResourceDevMem:
+		if err := need(resourceMemorySize); err != nil {
+			return Resource{}, err
+		}
+		memory := &MemoryResource{
+			DeviceAddress:   order.Uint32(body[0:]),
+			PhysicalAddress: order.Uint32(body[4:]),
+			Length:          order.Uint32(body[8:]),
+			Flags:           order.Uint32(body[12:]),
+			Name:            cString(body[20 : 20+resourceNameSize]),
+		}
+		if resource.Type == ResourceCarveout {
+			resource.Carveout = memory
+		} else {
+			resource.DevMem = memory
+		}
+	case ResourceTrace:
+		if err := need(resourceTraceSize); err != nil {
+			return Resource{}, err
+		}
+		resource.Trace = &TraceResource{
+			DeviceAddress: order.Uint32(body[0:]),
+			Length:        order.Uint32(body[4:]),
+			Name:          cString(body[12 : 12+resourceNameSize]),
+		}
+	case ResourceVdev:
+		if err := need(resourceVdevHeaderSize); err != nil {
+			return Resource{}, err
+		}
+		vdev := &VdevResource{
+			ID:             order.Uint32(body[0:]),
+			NotifyID:       order.Uint32(body[4:]),
+			DeviceFeatures: order.Uint32(body[8:]),
+			ConfigLength:   order.Uint32(body[16:]),
+		}
+		numVrings := int(body[21])
+		if err := need(resourceVdevHeaderSize + numVrings*resourceVdevVringSize + int(vdev.ConfigLength)); err != nil {
+			return Resource{}, err
+		}
+		for i := range numVrings {
+			vring := body[resourceVdevHeaderSize+i*resourceVdevVringSize:]
+			vdev.Vrings = append(vdev.Vrings, Vring{
+				DeviceAddress: order.Uint32(vring[0:]),
+				Align:         order.Uint32(vring[4:]),
+				Num:           order.Uint32(vring[8:]),
+				NotifyID:      order.Uint32(vring[12:]),
+			})
+		}
+		resource.Vdev = vdev
+	}
+	return resource, nil
+}
+
+func (r Resource) String() string {
+	switch {
+	case r.Carveout != nil:
+		return fmt.Sprintf("%s: %s", r.Type, r.Carveout)
+	case r.DevMem != nil:
+		return fmt.Sprintf("%s: %s", r.Type, r.DevMem)
+	case r.Trace != nil:
+		return fmt.Sprintf("%s: %s", r.Type, r.Trace)
+	case r.Vdev != nil:
+		return fmt.Sprintf("%s: %s", r.Type, r.Vdev)
+	}
+	return r.Type.String()
+}
+
+func formatAddress(address uint32) string {
+	if address == AddressAny {
+		return "any"
+	}
+	return fmt.Sprintf("%#x", address)
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
@@ -0,0 +1,125 @@
+package remoteproc_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc/remoteproctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadResourceTable(t *testing.T) {
+	t.Run("parses carveouts, device memory, vdevs and trace buffers", func(t *testing.T) {
+		image := remoteproctest.NewFirmware()
+		image.ResourceTable = remoteproctest.NewResourceTable().
+			Carveout("vdev0buffer", remoteproc.AddressAny, remoteproc.AddressAny, 0x40000).
+			DevMem("peripherals", 0x40000000, 0x44000000, 0x1000).
+			Vdev(7, 2, 1,
+				remoteproc.Vring{DeviceAddress: 0x20000000, Align: 0x1000, Num: 256, NotifyID: 0},
+				remoteproc.Vring{DeviceAddress: remoteproc.AddressAny, Align: 0x1000, Num: 256, NotifyID: 1},
+			).
+			Trace("trace0", 0x20010000, 0x1000).
+			Bytes()
+		firmwarePath := writeFirmware(t, image.Bytes())
+
+		table, err := remoteproc.ReadResourceTable(firmwarePath)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), table.Version)
+		require.Len(t, table.Entries, 4)
+		assert.Equal(t, &remoteproc.MemoryResource{Name: "vdev0buffer", DeviceAddress: remoteproc.AddressAny, PhysicalAddress: remoteproc.AddressAny, Length: 0x40000}, table.Entries[0].Carveout)
+		assert.Equal(t, &remoteproc.MemoryResource{Name: "peripherals", DeviceAddress: 0x40000000, PhysicalAddress: 0x44000000, Length: 0x1000}, table.Entries[1].DevMem)
+		assert.Equal(t, &remoteproc.VdevResource{
+			ID:             7,
+			NotifyID:       2,
+			DeviceFeatures: 1,
+			Vrings: []remoteproc.Vring{
+				{DeviceAddress: 0x20000000, Align: 0x1000, Num: 256, NotifyID: 0},
+				{DeviceAddress: remoteproc.AddressAny, Align: 0x1000, Num: 256, NotifyID: 1},
+			},
+		}, table.Entries[2].Vdev)
+		assert.Equal(t, &remoteproc.TraceResource{Name: "trace0", DeviceAddress: 0x20010000, Length: 0x1000}, table.Entries[3].Trace)
+	})
+
+	t.Run("renders entries on a single line", func(t *testing.T) {
+		image := remoteproctest.NewFirmware()
+		image.ResourceTable = remoteproctest.NewResourceTable().
+			Carveout("vdev0buffer", remoteproc.AddressAny, remoteproc.AddressAny, 0x40000).
+			Vdev(7, 2, 1, remoteproc.Vring{DeviceAddress: 0x20000000, Align: 0x1000, Num: 256}).
+			Trace("trace0", 0x20010000, 0x1000).
+			Bytes()
+		firmwarePath := writeFirmware(t, image.Bytes())
+
+		table, err := remoteproc.ReadResourceTable(firmwarePath)
+
+		require.NoError(t, err)
+		assert.Equal(t, "carveout: vdev0buffer da=any pa=any len=0x40000", table.Entries[0].String())
+		assert.Equal(t, "vdev: rpmsg notifyid=2 features=0x1 vring0 (da=0x20000000 num=256 align=0x1000 notifyid=0)", table.Entries[1].String())
+		assert.Equal(t, "trace: trace0 da=0x20010000 len=0x1000", table.Entries[2].String())
+	})
+
+	t.Run("reports firmware without a resource table", func(t *testing.T) {
+		image := remoteproctest.NewFirmware()
+		image.ResourceTable = nil
+		firmwarePath := writeFirmware(t, image.Bytes())
+
+		_, err := remoteproc.ReadResourceTable(firmwarePath)
+
+		assert.ErrorIs(t, err, remoteproc.ErrNoResourceTable)
+	})
+
+	t.Run("treats an empty resource table section as missing", func(t *testing.T) {
+		_, err := remoteproc.ReadResourceTable(sampleFirmwarePath)
+
+		assert.ErrorIs(t, err, remoteproc.ErrNoResourceTable)
+	})
+}
+
+func TestParseResourceTable(t *testing.T) {
+	t.Run("rejects unsupported versions", func(t *testing.T) {
+		table := remoteproctest.EmptyResourceTable()
+		binary.LittleEndian.PutUint32(table, 2)
+
+		_, err := remoteproc.ParseResourceTable(table, binary.LittleEndian)
+
+		assert.ErrorContains(t, err, "unsupported resource table version 2")
+	})
+
+	t.Run("rejects more entries than fit in the table", func(t *testing.T) {
+		table := remoteproctest.EmptyResourceTable()
+		binary.LittleEndian.PutUint32(table[4:], 3)
+
+		_, err := remoteproc.ParseResourceTable(table, binary.LittleEndian)
+
+		assert.ErrorContains(t, err, "resource table declares 3 entries, more than fit in 16 bytes")
+	})
+
+	t.Run("rejects truncated entries", func(t *testing.T) {
+		table := remoteproctest.NewResourceTable().Trace("trace0", 0x20010000, 0x1000).Bytes()
+
+		_, err := remoteproc.ParseResourceTable(table[:len(table)-8], binary.LittleEndian)
+
+		assert.ErrorContains(t, err, "resource table entry 0: trace entry at offset 0x14 is truncated")
+	})
+
+	t.Run("rejects entries outside the table", func(t *testing.T) {
+		table := remoteproctest.NewResourceTable().Trace("trace0", 0x20010000, 0x1000).Bytes()
+		binary.LittleEndian.PutUint32(table[16:], 0x1000)
+
+		_, err := remoteproc.ParseResourceTable(table, binary.LittleEndian)
+
+		assert.ErrorContains(t, err, "resource table entry 0: offset 0x1000 is outside the table")
+	})
+
+	t.Run("keeps vendor entries it can't decode", func(t *testing.T) {
+		table := remoteproctest.NewResourceTable().Trace("trace0", 0, 0x100).Bytes()
+		binary.LittleEndian.PutUint32(table[20:], 128)
+
+		parsed, err := remoteproc.ParseResourceTable(table, binary.LittleEndian)
+
+		require.NoError(t, err)
+		assert.Equal(t, "vendor(128)", parsed.Entries[0].Type.String())
+	})
+}
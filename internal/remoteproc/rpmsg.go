@@ -0,0 +1,156 @@
+package remoteproc
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/arm/remoteproc-runtime/internal/rootpath"
+)
+
+var rpmsgBusDevicesPath = rootpath.Join("sys", "bus", "rpmsg", "devices")
+
+const (
+	rpmsgNameFileName = "name"
+	rpmsgSrcFileName  = "src"
+	rpmsgDstFileName  = "dst"
+	rpmsgDriverLink   = "driver"
+)
+
+// rpmsgDeviceNodeClasses are the class directories below an RPMsg device holding the device
+// nodes its driver creates: rpmsg_tty creates tty/ttyRPMSGn, rpmsg_char creates rpmsg/rpmsgN.
+var rpmsgDeviceNodeClasses = []string{"tty", "rpmsg"}
+
+// RPMsgDevice is an RPMsg channel announced by the running firmware.
+type RPMsgDevice struct {
+	// Name is the kernel's device name, e.g. virtio0.rpmsg-tty.-1.1024.
+	Name string `json:"name"`
+	// Channel is the announced service name, e.g. rpmsg-tty.
+	Channel string `json:"channel"`
+	// Src and Dst are the local and remote endpoint addresses.
+	Src uint32 `json:"src"`
+	Dst uint32 `json:"dst"`
+	// Driver is the Linux driver bound to the channel, if any.
+	Driver string `json:"driver,omitempty"`
+	// DeviceNode is the character or tty device the driver created, e.g. /dev/ttyRPMSG0.
+	DeviceNode string `json:"deviceNode,omitempty"`
+}
+
+func (d RPMsgDevice) String() string {
+	description := fmt.Sprintf("%s src=%s dst=%s", d.Channel, formatAddress(d.Src), formatAddress(d.Dst))
+	if d.Driver != "" {
+		description += " driver=" + d.Driver
+	}
+	if d.DeviceNode != "" {
+		description += " dev=" + d.DeviceNode
+	}
+	return description
+}
+
+func (p *sysfsProcessor) RPMsgDevices() ([]RPMsgDevice, error) {
+	processorDir, err := filepath.EvalSymlinks(p.devicePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", p.devicePath, err)
+	}
+	entries, err := os.ReadDir(rpmsgBusDevicesPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		// The rpmsg bus only exists once a virtio rpmsg device was probed.
+		return []RPMsgDevice{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rpmsg devices %s: %w", rpmsgBusDevicesPath, err)
+	}
+	devices := []RPMsgDevice{}
+	for _, entry := range entries {
+		deviceDir, err := filepath.EvalSymlinks(filepath.Join(rpmsgBusDevicesPath, entry.Name()))
+		if err != nil || !isBelow(deviceDir, processorDir) {
+			continue
+		}
+		device, err := readRPMsgDevice(entry.Name(), deviceDir)
+		if err != nil {
+			// The channel may have gone away while being read.
+			continue
+		}
+		devices = append(devices, device)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Name < devices[j].Name })
+	return devices, nil
+}
+
+func readRPMsgDevice(name, deviceDir string) (RPMsgDevice, error) {
+	device := RPMsgDevice{Name: name}
+	var err error
+	if device.Channel, err = readFile(filepath.Join(deviceDir, rpmsgNameFileName)); err != nil {
+		return RPMsgDevice{}, err
+	}
+	if device.Src, err = readRPMsgAddress(filepath.Join(deviceDir, rpmsgSrcFileName)); err != nil {
+		return RPMsgDevice{}, err
+	}
+	if device.Dst, err = readRPMsgAddress(filepath.Join(deviceDir, rpmsgDstFileName)); err != nil {
+		return RPMsgDevice{}, err
+	}
+	if driver, err := filepath.EvalSymlinks(filepath.Join(deviceDir, rpmsgDriverLink)); err == nil {
+		device.Driver = filepath.Base(driver)
+	}
+	for _, class := range rpmsgDeviceNodeClasses {
+		nodes, err := os.ReadDir(filepath.Join(deviceDir, class))
+		if err == nil && len(nodes) > 0 {
+			device.DeviceNode = filepath.Join(rprocDevPath, nodes[0].Name())
+			break
+		}
+	}
+	return device, nil
+}
+
+// readRPMsgAddress parses an address attribute. The rpmsg bus prints addresses as hex, e.g.
+// 0x400, while rpmsg_char prints its endpoints' as signed decimals, so AddressAny reads -1.
+func readRPMsgAddress(path string) (uint32, error) {
+	raw, err := readFile(path)
+	if err != nil {
+		return 0, err
+	}
+	address, err := strconv.ParseInt(raw, 0, 64)
+	if err != nil || address < -1 || address > math.MaxUint32 {
+		return 0, fmt.Errorf("can't parse rpmsg address %q from %s", raw, path)
+	}
+	if address == -1 {
+		return AddressAny, nil
+	}
+	return uint32(address), nil
+}
+
+// isBelow reports whether the resolved sysfs path lies below dir.
+func isBelow(path, dir string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// RPMsgDeviceNodes lists the device nodes through which Linux applications talk to the
+// processor's firmware: those of the channels it announced and of the endpoints created on it.
+func RPMsgDeviceNodes(processor Processor) ([]string, error) {
+	devices, err := processor.RPMsgDevices()
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := processor.RPMsgEndpoints()
+	if err != nil {
+		return nil, err
+	}
+	deviceNodes := []string{}
+	for _, device := range devices {
+		if device.DeviceNode != "" {
+			deviceNodes = append(deviceNodes, device.DeviceNode)
+		}
+	}
+	for _, endpoint := range endpoints {
+		deviceNodes = append(deviceNodes, endpoint.DeviceNode)
+	}
+	slices.Sort(deviceNodes)
+	return slices.Compact(deviceNodes), nil
+}
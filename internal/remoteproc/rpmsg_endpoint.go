@@ -0,0 +1,244 @@
+package remoteproc
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/arm/remoteproc-runtime/internal/rootpath"
+	"golang.org/x/sys/unix"
+)
+
+// From include/uapi/linux/rpmsg.h.
+const (
+	// RPMSG_CREATE_EPT_IOCTL: _IOW(0xb5, 0x1, struct rpmsg_endpoint_info)
+	rpmsgCreateEptIoctl = 0x4028b501
+	// RPMSG_DESTROY_EPT_IOCTL: _IO(0xb5, 0x2)
+	rpmsgDestroyEptIoctl = 0xb502
+	// RPMSG_NAME_SIZE, including the terminating NUL.
+	rpmsgNameSize = 32
+)
+
+const (
+	rpmsgCtrlPrefix     = "rpmsg_ctrl"
+	rpmsgEndpointPrefix = "rpmsg"
+)
+
+var rpmsgClassPath = rootpath.Join("sys", "class", "rpmsg")
+
+// rpmsgEndpointInfo is struct rpmsg_endpoint_info.
+type rpmsgEndpointInfo struct {
+	Name [rpmsgNameSize]byte
+	Src  uint32
+	Dst  uint32
+}
+
+// RPMsgEndpoint is a host-side rpmsg_char endpoint, exposed to Linux applications as /dev/rpmsgN.
+type RPMsgEndpoint struct {
+	Name string `json:"name"`
+	Src  uint32 `json:"src"`
+	Dst  uint32 `json:"dst"`
+	// DeviceNode is set once the endpoint has been created.
+	DeviceNode string `json:"deviceNode,omitempty"`
+}
+
+func (e RPMsgEndpoint) String() string {
+	description := fmt.Sprintf("%s src=%s dst=%s", e.Name, formatAddress(e.Src), formatAddress(e.Dst))
+	if e.DeviceNode != "" {
+		description += " dev=" + e.DeviceNode
+	}
+	return description
+}
+
+// Spec returns the endpoint in the name:src:dst form ParseRPMsgEndpoint accepts.
+func (e RPMsgEndpoint) Spec() string {
+	return fmt.Sprintf("%s:%s:%s", e.Name, formatAddress(e.Src), formatAddress(e.Dst))
+}
+
+// ParseRPMsgEndpoints parses a comma separated list of endpoints, see ParseRPMsgEndpoint.
+func ParseRPMsgEndpoints(value string) ([]RPMsgEndpoint, error) {
+	var endpoints []RPMsgEndpoint
+	for _, spec := range strings.Split(value, ",") {
+		endpoint, err := ParseRPMsgEndpoint(strings.TrimSpace(spec))
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, nil
+}
+
+// ParseRPMsgEndpoint parses an endpoint given as name:src:dst. Addresses are decimal, or
+// hexadecimal with a 0x prefix, or "any" to let the kernel or the firmware choose.
+func ParseRPMsgEndpoint(spec string) (RPMsgEndpoint, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 {
+		return RPMsgEndpoint{}, fmt.Errorf("invalid RPMsg endpoint %q: expected name:src:dst", spec)
+	}
+	name := parts[0]
+	if name == "" || len(name) >= rpmsgNameSize {
+		return RPMsgEndpoint{}, fmt.Errorf("invalid RPMsg endpoint %q: name must be 1 to %d bytes long", spec, rpmsgNameSize-1)
+	}
+	src, err := parseEndpointAddress(parts[1])
+	if err != nil {
+		return RPMsgEndpoint{}, fmt.Errorf("invalid RPMsg endpoint %q: src: %w", spec, err)
+	}
+	dst, err := parseEndpointAddress(parts[2])
+	if err != nil {
+		return RPMsgEndpoint{}, fmt.Errorf("invalid RPMsg endpoint %q: dst: %w", spec, err)
+	}
+	return RPMsgEndpoint{Name: name, Src: src, Dst: dst}, nil
+}
+
+func parseEndpointAddress(value string) (uint32, error) {
+	if value == "any" {
+		return AddressAny, nil
+	}
+	address, err := strconv.ParseUint(value, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an address", value)
+	}
+	return uint32(address), nil
+}
+
+// CreateEndpoint creates an endpoint through the processor's /dev/rpmsg_ctrlN and returns its
+// /dev/rpmsgN device node. It fails with fs.ErrNotExist until the firmware's virtio rpmsg
+// device has probed and the control device exists.
+func (p *sysfsProcessor) CreateEndpoint(endpoint RPMsgEndpoint) (string, error) {
+	ctrl, err := p.rpmsgCtrl()
+	if err != nil {
+		return "", err
+	}
+	existing, err := p.RPMsgEndpoints()
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.OpenFile(ctrl, os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", ctrl, err)
+	}
+	defer func() { _ = f.Close() }()
+	info := rpmsgEndpointInfo{Src: endpoint.Src, Dst: endpoint.Dst}
+	copy(info.Name[:], endpoint.Name)
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), rpmsgCreateEptIoctl, uintptr(unsafe.Pointer(&info)))
+	runtime.KeepAlive(f)
+	if errno != 0 {
+		return "", fmt.Errorf("failed to create RPMsg endpoint %s: %w", endpoint.Name, errno)
+	}
+
+	created, err := p.RPMsgEndpoints()
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range created {
+		isNew := !slices.ContainsFunc(existing, func(e RPMsgEndpoint) bool { return e.DeviceNode == candidate.DeviceNode })
+		if isNew && candidate.Name == endpoint.Name {
+			return candidate.DeviceNode, nil
+		}
+	}
+	return "", fmt.Errorf("created RPMsg endpoint %s but can't find its device node", endpoint.Name)
+}
+
+// DestroyEndpoint destroys an endpoint created by CreateEndpoint. It fails with fs.ErrNotExist
+// if the endpoint is already gone, e.g. because the firmware stopped.
+func (p *sysfsProcessor) DestroyEndpoint(deviceNode string) error {
+	f, err := os.OpenFile(deviceNode, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	if err := unix.IoctlSetInt(int(f.Fd()), rpmsgDestroyEptIoctl, 0); err != nil {
+		return fmt.Errorf("failed to destroy RPMsg endpoint %s: %w", deviceNode, err)
+	}
+	return nil
+}
+
+// rpmsgCtrl returns the processor's /dev/rpmsg_ctrlN. Since Linux 5.18 it belongs to an RPMsg
+// channel of its own; older kernels only register it in the rpmsg class, below the firmware's
+// virtio rpmsg device.
+func (p *sysfsProcessor) rpmsgCtrl() (string, error) {
+	devices, err := p.RPMsgDevices()
+	if err != nil {
+		return "", err
+	}
+	for _, device := range devices {
+		if strings.HasPrefix(filepath.Base(device.DeviceNode), rpmsgCtrlPrefix) {
+			return device.DeviceNode, nil
+		}
+	}
+
+	processorDir, err := filepath.EvalSymlinks(p.devicePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", p.devicePath, err)
+	}
+	entries, err := os.ReadDir(rpmsgClassPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("failed to read rpmsg class %s: %w", rpmsgClassPath, err)
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), rpmsgCtrlPrefix) {
+			continue
+		}
+		ctrlDir, err := filepath.EvalSymlinks(filepath.Join(rpmsgClassPath, entry.Name()))
+		if err == nil && isBelow(ctrlDir, processorDir) {
+			return filepath.Join(rprocDevPath, entry.Name()), nil
+		}
+	}
+	return "", fmt.Errorf("no RPMsg control device for %s: %w", p.name, fs.ErrNotExist)
+}
+
+// RPMsgEndpoints lists the endpoints created on the processor's RPMsg control device.
+func (p *sysfsProcessor) RPMsgEndpoints() ([]RPMsgEndpoint, error) {
+	processorDir, err := filepath.EvalSymlinks(p.devicePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", p.devicePath, err)
+	}
+	entries, err := os.ReadDir(rpmsgClassPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []RPMsgEndpoint{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rpmsg class %s: %w", rpmsgClassPath, err)
+	}
+	endpoints := []RPMsgEndpoint{}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), rpmsgEndpointPrefix) || strings.HasPrefix(entry.Name(), rpmsgCtrlPrefix) {
+			continue
+		}
+		endpointDir, err := filepath.EvalSymlinks(filepath.Join(rpmsgClassPath, entry.Name()))
+		if err != nil || !isBelow(endpointDir, processorDir) {
+			continue
+		}
+		endpoint, err := readRPMsgEndpoint(endpointDir)
+		if err != nil {
+			// The endpoint was destroyed while listing.
+			continue
+		}
+		endpoint.DeviceNode = filepath.Join(rprocDevPath, entry.Name())
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, nil
+}
+
+func readRPMsgEndpoint(endpointDir string) (RPMsgEndpoint, error) {
+	var endpoint RPMsgEndpoint
+	var err error
+	if endpoint.Name, err = readFile(filepath.Join(endpointDir, rpmsgNameFileName)); err != nil {
+		return RPMsgEndpoint{}, err
+	}
+	if endpoint.Src, err = readRPMsgAddress(filepath.Join(endpointDir, rpmsgSrcFileName)); err != nil {
+		return RPMsgEndpoint{}, err
+	}
+	if endpoint.Dst, err = readRPMsgAddress(filepath.Join(endpointDir, rpmsgDstFileName)); err != nil {
+		return RPMsgEndpoint{}, err
+	}
+	return endpoint, nil
+}
@@ -0,0 +1,114 @@
+package remoteproc_test
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRPMsgEndpoints(t *testing.T) {
+	t.Run("parses decimal, hexadecimal and any addresses", func(t *testing.T) {
+		got, err := remoteproc.ParseRPMsgEndpoints("control:0x400:30, sensors:any:any")
+
+		require.NoError(t, err)
+		assert.Equal(t, []remoteproc.RPMsgEndpoint{
+			{Name: "control", Src: 0x400, Dst: 30},
+			{Name: "sensors", Src: remoteproc.AddressAny, Dst: remoteproc.AddressAny},
+		}, got)
+	})
+
+	t.Run("round trips through Spec", func(t *testing.T) {
+		endpoint := remoteproc.RPMsgEndpoint{Name: "control", Src: 0x400, Dst: remoteproc.AddressAny}
+
+		got, err := remoteproc.ParseRPMsgEndpoint(endpoint.Spec())
+
+		require.NoError(t, err)
+		assert.Equal(t, endpoint, got)
+	})
+
+	t.Run("rejects malformed endpoints", func(t *testing.T) {
+		for spec, want := range map[string]string{
+			"control": `invalid RPMsg endpoint "control": expected name:src:dst`,
+			":1:2":    "name must be 1 to 31 bytes long",
+			"a-name-longer-than-the-kernel-allows:1:2": "name must be 1 to 31 bytes long",
+			"control:src:2":         `src: "src" is not an address`,
+			"control:1:0x100000000": `dst: "0x100000000" is not an address`,
+		} {
+			_, err := remoteproc.ParseRPMsgEndpoints(spec)
+
+			assert.ErrorContains(t, err, want, spec)
+		}
+	})
+}
+
+func TestSysfsRPMsgEndpoints(t *testing.T) {
+	t.Run("lists the endpoints created on the processor, any addresses included", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning)
+		otherDevicePath := sysfs.addProcessor(t, 1, "m4", remoteproc.StateRunning)
+		sysfs.addEndpoint(t, devicePath, "rpmsg0", "control", "1024", "30")
+		sysfs.addEndpoint(t, devicePath, "rpmsg1", "sensors", "-1", "-1")
+		sysfs.addEndpoint(t, otherDevicePath, "rpmsg2", "other", "1025", "31")
+		processor := sysfs.open(t, devicePath)
+
+		got, err := processor.RPMsgEndpoints()
+
+		require.NoError(t, err)
+		assert.Equal(t, []remoteproc.RPMsgEndpoint{
+			{Name: "control", Src: 1024, Dst: 30, DeviceNode: sysfs.path("dev", "rpmsg0")},
+			{Name: "sensors", Src: remoteproc.AddressAny, Dst: remoteproc.AddressAny, DeviceNode: sysfs.path("dev", "rpmsg1")},
+		}, got)
+	})
+
+	t.Run("lists no endpoints without the rpmsg class", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		processor := sysfs.open(t, sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning))
+
+		got, err := processor.RPMsgEndpoints()
+
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestSysfsRPMsgCtrl(t *testing.T) {
+	t.Run("finds the control device among the firmware's channels", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning)
+		sysfs.addChannel(t, devicePath, remoteproc.RPMsgDevice{
+			Name: "virtio0.rpmsg_ctrl.0.0", Channel: "rpmsg_ctrl", DeviceNode: "/dev/rpmsg_ctrl0",
+		})
+		processor := sysfs.open(t, devicePath)
+
+		got, err := remoteproc.RPMsgCtrl(processor)
+
+		require.NoError(t, err)
+		assert.Equal(t, sysfs.path("dev", "rpmsg_ctrl0"), got)
+	})
+
+	t.Run("finds the control device in the rpmsg class on kernels before 5.18", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning)
+		otherDevicePath := sysfs.addProcessor(t, 1, "m4", remoteproc.StateRunning)
+		sysfs.addClassCtrl(t, otherDevicePath, "rpmsg_ctrl0")
+		sysfs.addClassCtrl(t, devicePath, "rpmsg_ctrl1")
+		processor := sysfs.open(t, devicePath)
+
+		got, err := remoteproc.RPMsgCtrl(processor)
+
+		require.NoError(t, err)
+		assert.Equal(t, sysfs.path("dev", "rpmsg_ctrl1"), got)
+	})
+
+	t.Run("fails with fs.ErrNotExist until the control device exists", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		processor := sysfs.open(t, sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning))
+
+		_, err := remoteproc.RPMsgCtrl(processor)
+
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
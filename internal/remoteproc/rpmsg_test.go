@@ -0,0 +1,64 @@
+package remoteproc_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSysfsRPMsgDevices(t *testing.T) {
+	t.Run("lists the channels announced by the processor's firmware", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning)
+		otherDevicePath := sysfs.addProcessor(t, 1, "m4", remoteproc.StateRunning)
+		tty := remoteproc.RPMsgDevice{
+			Name:       "virtio0.rpmsg-tty.-1.1024",
+			Channel:    "rpmsg-tty",
+			Src:        0x400,
+			Dst:        0x1,
+			Driver:     "rpmsg_tty",
+			DeviceNode: sysfs.path("dev", "ttyRPMSG0"),
+		}
+		unbound := remoteproc.RPMsgDevice{Name: "virtio0.rpmsg-raw.-1.1025", Channel: "rpmsg-raw", Src: 0x401, Dst: 0x2}
+		sysfs.addChannel(t, devicePath, unbound)
+		sysfs.addChannel(t, devicePath, tty)
+		sysfs.addChannel(t, otherDevicePath, remoteproc.RPMsgDevice{Name: "virtio1.rpmsg-tty.-1.1024", Channel: "rpmsg-tty", Src: 0x400, Dst: 0x1})
+		processor := sysfs.open(t, devicePath)
+
+		got, err := processor.RPMsgDevices()
+
+		require.NoError(t, err)
+		assert.Equal(t, []remoteproc.RPMsgDevice{unbound, tty}, got)
+	})
+
+	t.Run("lists no channels before the rpmsg bus exists", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		processor := sysfs.open(t, sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning))
+
+		got, err := processor.RPMsgDevices()
+
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestSysfsTTYs(t *testing.T) {
+	t.Run("lists the RPMsg TTYs of the processor's firmware", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning)
+		otherDevicePath := sysfs.addProcessor(t, 1, "m4", remoteproc.StateRunning)
+		sysfs.addChannel(t, devicePath, remoteproc.RPMsgDevice{Name: "virtio0.rpmsg-tty.-1.1025", Channel: "rpmsg-tty", DeviceNode: "ttyRPMSG1"})
+		sysfs.addChannel(t, devicePath, remoteproc.RPMsgDevice{Name: "virtio0.rpmsg-tty.-1.1024", Channel: "rpmsg-tty", DeviceNode: "ttyRPMSG0"})
+		sysfs.addChannel(t, otherDevicePath, remoteproc.RPMsgDevice{Name: "virtio1.rpmsg-tty.-1.1024", Channel: "rpmsg-tty", DeviceNode: "ttyRPMSG2"})
+		require.NoError(t, os.MkdirAll(sysfs.path("sys", "class", "tty", "ttyS0"), 0o755))
+		processor := sysfs.open(t, devicePath)
+
+		got, err := processor.TTYs()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ttyRPMSG0", "ttyRPMSG1"}, got)
+	})
+}
@@ -0,0 +1,147 @@
+package remoteproc
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Selector identifies a processor by any combination of its attributes. A processor must
+// match every field that is set; unset fields match anything.
+type Selector struct {
+	// Names matches any of the listed names; candidates are ordered by their position in it.
+	Names    []string
+	NameGlob string
+	NameRe   *regexp.Regexp
+	// DeviceTreeNode is either the full node path (/soc/m33@4c000000) or its full name (m33@4c000000).
+	DeviceTreeNode string
+	ParentDevice   string
+	Index          *int
+}
+
+func (s Selector) String() string {
+	var parts []string
+	if len(s.Names) > 0 {
+		parts = append(parts, "name="+strings.Join(s.Names, ","))
+	}
+	if s.NameGlob != "" {
+		parts = append(parts, "name-glob="+s.NameGlob)
+	}
+	if s.NameRe != nil {
+		parts = append(parts, "name-regex="+s.NameRe.String())
+	}
+	if s.DeviceTreeNode != "" {
+		parts = append(parts, "of-node="+s.DeviceTreeNode)
+	}
+	if s.ParentDevice != "" {
+		parts = append(parts, "device="+s.ParentDevice)
+	}
+	if s.Index != nil {
+		parts = append(parts, fmt.Sprintf("index=%d", *s.Index))
+	}
+	if len(parts) == 1 && len(s.Names) > 0 {
+		return strings.Join(s.Names, ",")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (s Selector) Matches(info Info) bool {
+	if len(s.Names) > 0 && !slices.Contains(s.Names, info.Name) {
+		return false
+	}
+	if s.NameGlob != "" {
+		if ok, _ := path.Match(s.NameGlob, info.Name); !ok {
+			return false
+		}
+	}
+	if s.NameRe != nil && !s.NameRe.MatchString(info.Name) {
+		return false
+	}
+	if s.DeviceTreeNode != "" && info.DeviceTreeNode != s.DeviceTreeNode && path.Base(info.DeviceTreeNode) != s.DeviceTreeNode {
+		return false
+	}
+	if s.ParentDevice != "" && info.ParentDevice != s.ParentDevice {
+		return false
+	}
+	if s.Index != nil && info.Index != *s.Index {
+		return false
+	}
+	return true
+}
+
+// Select returns the single processor matching selector. Matching several processors is an
+// error, since silently picking one would make the target depend on probe order.
+func Select(backend Backend, selector Selector) (Processor, error) {
+	candidates, err := Candidates(backend, selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) > 1 {
+		descriptions := make([]string, len(candidates))
+		for i, candidate := range candidates {
+			descriptions[i] = DescribeCandidate(candidate)
+		}
+		return nil, fmt.Errorf("remote processor %s is ambiguous, candidates: %s", selector, strings.Join(descriptions, "; "))
+	}
+	return candidates[0], nil
+}
+
+// Candidates returns every processor matching selector, ordered by the position of their name
+// in selector.Names and then by index. It errors if nothing matches.
+func Candidates(backend Backend, selector Selector) ([]Processor, error) {
+	processors, err := backend.Processors()
+	if err != nil {
+		return nil, err
+	}
+
+	type match struct {
+		processor Processor
+		info      Info
+	}
+	availableNames := []string{}
+	var matches []match
+	for _, processor := range processors {
+		info, err := processor.Info()
+		if err != nil {
+			continue
+		}
+		availableNames = append(availableNames, info.Name)
+		if selector.Matches(info) {
+			matches = append(matches, match{processor: processor, info: info})
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("remote processor %s does not exist, available remote processors: %s", selector, strings.Join(availableNames, ", "))
+	}
+
+	slices.SortStableFunc(matches, func(a, b match) int {
+		if rank := cmp.Compare(slices.Index(selector.Names, a.info.Name), slices.Index(selector.Names, b.info.Name)); rank != 0 {
+			return rank
+		}
+		return cmp.Compare(a.info.Index, b.info.Index)
+	})
+	candidates := make([]Processor, len(matches))
+	for i, m := range matches {
+		candidates[i] = m.processor
+	}
+	return candidates, nil
+}
+
+// DescribeCandidate renders a processor with the attributes that tell identical ones apart.
+func DescribeCandidate(processor Processor) string {
+	info, err := processor.Info()
+	if err != nil {
+		return processor.Name()
+	}
+	details := []string{fmt.Sprintf("index=%d", info.Index)}
+	if info.ParentDevice != "" {
+		details = append(details, "device="+info.ParentDevice)
+	}
+	if info.DeviceTreeNode != "" {
+		details = append(details, "of-node="+info.DeviceTreeNode)
+	}
+	return fmt.Sprintf("%s (%s)", info.Name, strings.Join(details, ", "))
+}
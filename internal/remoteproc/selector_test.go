@@ -0,0 +1,86 @@
+package remoteproc_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc/remoteproctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect(t *testing.T) {
+	newBackend := func(t *testing.T) *remoteproctest.Backend {
+		backend := remoteproctest.NewBackend(t.TempDir())
+		backend.AddProcessor("imx-rproc").SetParent("imx93-cm33", "/imx93-cm33")
+		backend.AddProcessor("dsp").SetParent("30000000.dsp", "/soc/dsp@30000000")
+		backend.AddProcessor("dsp").SetParent("31000000.dsp", "/soc/dsp@31000000")
+		return backend
+	}
+
+	t.Run("selects processor by unique name", func(t *testing.T) {
+		got, err := remoteproc.Select(newBackend(t), remoteproc.Selector{Names: []string{"imx-rproc"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, "imx-rproc", got.Name())
+	})
+
+	t.Run("errors listing candidates when several processors match", func(t *testing.T) {
+		_, err := remoteproc.Select(newBackend(t), remoteproc.Selector{Names: []string{"dsp"}})
+
+		assert.ErrorContains(t, err, "remote processor dsp is ambiguous, candidates: ")
+		assert.ErrorContains(t, err, "dsp (index=1, device=30000000.dsp, of-node=/soc/dsp@30000000)")
+		assert.ErrorContains(t, err, "dsp (index=2, device=31000000.dsp, of-node=/soc/dsp@31000000)")
+	})
+
+	t.Run("disambiguates identical names by device tree node path or full name", func(t *testing.T) {
+		backend := newBackend(t)
+
+		byPath, err := remoteproc.Select(backend, remoteproc.Selector{Names: []string{"dsp"}, DeviceTreeNode: "/soc/dsp@31000000"})
+		require.NoError(t, err)
+		byFullName, err := remoteproc.Select(backend, remoteproc.Selector{DeviceTreeNode: "dsp@31000000"})
+		require.NoError(t, err)
+
+		assert.Equal(t, byPath.DevicePath(), byFullName.DevicePath())
+		info, err := byPath.Info()
+		require.NoError(t, err)
+		assert.Equal(t, 2, info.Index)
+	})
+
+	t.Run("selects processor by parent device", func(t *testing.T) {
+		got, err := remoteproc.Select(newBackend(t), remoteproc.Selector{ParentDevice: "30000000.dsp"})
+
+		require.NoError(t, err)
+		info, err := got.Info()
+		require.NoError(t, err)
+		assert.Equal(t, 1, info.Index)
+	})
+
+	t.Run("selects processor by sysfs index", func(t *testing.T) {
+		index := 0
+
+		got, err := remoteproc.Select(newBackend(t), remoteproc.Selector{Index: &index})
+
+		require.NoError(t, err)
+		assert.Equal(t, "imx-rproc", got.Name())
+	})
+
+	t.Run("selects processor by name glob and regex", func(t *testing.T) {
+		backend := newBackend(t)
+
+		byGlob, err := remoteproc.Select(backend, remoteproc.Selector{NameGlob: "imx-*"})
+		require.NoError(t, err)
+		byRegex, err := remoteproc.Select(backend, remoteproc.Selector{NameRe: regexp.MustCompile(`^imx`)})
+		require.NoError(t, err)
+
+		assert.Equal(t, "imx-rproc", byGlob.Name())
+		assert.Equal(t, "imx-rproc", byRegex.Name())
+	})
+
+	t.Run("errors listing available processors when nothing matches", func(t *testing.T) {
+		_, err := remoteproc.Select(newBackend(t), remoteproc.Selector{Names: []string{"dsp"}, ParentDevice: "nope"})
+
+		assert.ErrorContains(t, err, "remote processor name=dsp, device=nope does not exist, available remote processors: imx-rproc, dsp, dsp")
+	})
+}
@@ -0,0 +1,282 @@
+package remoteproc
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type sysfsBackend struct {
+	logger *slog.Logger
+}
+
+// NewSysfsBackend returns a backend driving processors through /sys/class/remoteproc.
+func NewSysfsBackend(logger *slog.Logger) Backend {
+	return &sysfsBackend{logger: logger}
+}
+
+func (b *sysfsBackend) Processors() ([]Processor, error) {
+	files, err := os.ReadDir(rprocClassPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remoteproc directory %s: %w", rprocClassPath, err)
+	}
+
+	processors := []Processor{}
+	for _, file := range files {
+		processor, err := b.Open(filepath.Join(rprocClassPath, file.Name()))
+		if err != nil {
+			continue
+		}
+		processors = append(processors, processor)
+	}
+	return processors, nil
+}
+
+func (b *sysfsBackend) Open(devicePath string) (Processor, error) {
+	name, err := readFile(filepath.Join(devicePath, rprocInstanceNameFileName))
+	if err != nil {
+		return nil, err
+	}
+	return &sysfsProcessor{logger: b.logger, devicePath: devicePath, name: name}, nil
+}
+
+func (b *sysfsBackend) FirmwareDir() string {
+	return GetSystemFirmwarePath(b.logger)
+}
+
+type sysfsProcessor struct {
+	logger     *slog.Logger
+	devicePath string
+	name       string
+	// cdev holds /dev/remoteprocN open while the processor runs, when the kernel provides it.
+	cdev *os.File
+	// kmsg is the descriptor of /dev/kmsg, where ReadKernelMessages left off reading it.
+	kmsg *int
+}
+
+func (p *sysfsProcessor) DevicePath() string {
+	return p.devicePath
+}
+
+func (p *sysfsProcessor) Name() string {
+	return p.name
+}
+
+func (p *sysfsProcessor) State() (State, error) {
+	stateFilePath := p.stateFilePath()
+	rawState, err := readFile(stateFilePath)
+	if err != nil {
+		return "", err
+	}
+	state, err := newState(rawState)
+	if err != nil {
+		return "", fmt.Errorf("can't parse state from %s: %w", stateFilePath, err)
+	}
+	return state, nil
+}
+
+func (p *sysfsProcessor) Info() (Info, error) {
+	rawState, err := readFile(p.stateFilePath())
+	if err != nil {
+		return Info{}, err
+	}
+	firmware, err := readFile(p.firmwareFilePath())
+	if err != nil {
+		return Info{}, err
+	}
+	info := Info{
+		Index:      deviceIndex(p.devicePath),
+		Name:       p.name,
+		DevicePath: p.devicePath,
+		// Reported verbatim, so states unknown to the runtime still show up.
+		State:    State(rawState),
+		Firmware: firmware,
+	}
+	info.Coredump, _ = readFile(filepath.Join(p.devicePath, rprocCoredumpFileName))
+	info.Recovery, _ = readFile(filepath.Join(p.devicePath, rprocRecoveryFileName))
+	if parent, err := filepath.EvalSymlinks(filepath.Join(p.devicePath, rprocDeviceLinkName)); err == nil {
+		info.ParentDevice = filepath.Base(parent)
+		if ofNode, err := filepath.EvalSymlinks(filepath.Join(parent, rprocOfNodeLinkName)); err == nil {
+			info.DeviceTreeNode = deviceTreeNodePath(ofNode)
+		}
+	}
+	return info, nil
+}
+
+func (p *sysfsProcessor) SetFirmware(firmwareFilePath string) error {
+	state, err := p.State()
+	if err != nil {
+		return fmt.Errorf("pre-flight state check failed: %w", err)
+	}
+	if state.Running() {
+		return fmt.Errorf("remote processor is already running")
+	}
+
+	firmwareFileName := filepath.Base(firmwareFilePath)
+	if err := os.WriteFile(p.firmwareFilePath(), []byte(firmwareFileName), 0o644); err != nil {
+		return fmt.Errorf("failed to set firmware %s: %w", firmwareFileName, err)
+	}
+	return nil
+}
+
+func (p *sysfsProcessor) Start() error {
+	state, err := p.State()
+	if err != nil {
+		return fmt.Errorf("pre-flight state check failed: %w", err)
+	}
+	if state.Running() {
+		return fmt.Errorf("remote processor is already running")
+	}
+
+	// Firmware that stopped by itself is started again through the device still held open,
+	// since releasing it, even once leaked, would stop the processor.
+	if p.cdev != nil {
+		if _, err := p.cdev.Write([]byte("start")); err != nil {
+			return fmt.Errorf("failed to start remote processor: %w", err)
+		}
+		return nil
+	}
+	cdev, err := openCdev(p.logger, p.devicePath)
+	if err != nil {
+		return fmt.Errorf("failed to start remote processor: %w", err)
+	}
+	if cdev != nil {
+		if _, err := cdev.Write([]byte("start")); err != nil {
+			_ = cdev.Close()
+			return fmt.Errorf("failed to start remote processor: %w", err)
+		}
+		p.cdev = cdev
+		return nil
+	}
+
+	if err := os.WriteFile(p.stateFilePath(), []byte("start"), 0o644); err != nil {
+		return fmt.Errorf("failed to start remote processor: %w", err)
+	}
+	return nil
+}
+
+func (p *sysfsProcessor) Attach() error {
+	state, err := p.State()
+	if err != nil {
+		return fmt.Errorf("pre-flight state check failed: %w", err)
+	}
+	if !state.Running() {
+		return fmt.Errorf("remote processor is %s, not running", state)
+	}
+	if p.cdev != nil {
+		return nil
+	}
+	cdev, err := openCdev(p.logger, p.devicePath)
+	if err != nil {
+		return fmt.Errorf("failed to attach to remote processor: %w", err)
+	}
+	p.cdev = cdev
+	return nil
+}
+
+func (p *sysfsProcessor) Stop() error {
+	if p.cdev != nil {
+		_, err := p.cdev.Write([]byte("stop"))
+		// Closing also stops a still running processor, thanks to shutdown-on-release.
+		_ = p.cdev.Close()
+		p.cdev = nil
+		if err != nil {
+			return fmt.Errorf("failed to stop remote processor: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(p.stateFilePath(), []byte("stop"), 0o644); err != nil {
+		return fmt.Errorf("failed to stop remote processor: %w", err)
+	}
+	return nil
+}
+
+func (p *sysfsProcessor) Detach() error {
+	state, err := p.State()
+	if err != nil {
+		return fmt.Errorf("pre-flight state check failed: %w", err)
+	}
+	// The kernel only detaches from firmware it attached to, not from firmware it booted.
+	if state != StateAttached {
+		return fmt.Errorf("remote processor is %s, not attached", state)
+	}
+	if p.cdev != nil {
+		_, err := p.cdev.Write([]byte("detach"))
+		if err != nil {
+			return fmt.Errorf("failed to detach remote processor: %w", err)
+		}
+		// Once detached, releasing the device leaves the firmware running.
+		_ = p.cdev.Close()
+		p.cdev = nil
+		return nil
+	}
+	if err := os.WriteFile(p.stateFilePath(), []byte("detach"), 0o644); err != nil {
+		return fmt.Errorf("failed to detach remote processor: %w", err)
+	}
+	return nil
+}
+
+// deviceIndex extracts N from a remoteprocN device path, returning -1 if there is none.
+func deviceIndex(devicePath string) int {
+	index, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(devicePath), rprocDevicePrefix))
+	if err != nil {
+		return -1
+	}
+	return index
+}
+
+// deviceTreeNodePath converts a resolved of_node link into its device tree path, e.g. /soc/m33@0.
+func deviceTreeNodePath(ofNode string) string {
+	base, err := filepath.EvalSymlinks(deviceTreeBasePath)
+	if err != nil {
+		base = deviceTreeBasePath
+	}
+	rel, err := filepath.Rel(base, ofNode)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ofNode
+	}
+	return "/" + rel
+}
+
+func (p *sysfsProcessor) stateFilePath() string {
+	return filepath.Join(p.devicePath, rprocStateFileName)
+}
+
+func (p *sysfsProcessor) firmwareFilePath() string {
+	return filepath.Join(p.devicePath, rprocFirmwareFileName)
+}
+
+func (p *sysfsProcessor) TraceBuffers() ([]string, error) {
+	entries, err := os.ReadDir(p.debugfsPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read debugfs directory %s: %w", p.debugfsPath(), err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), rprocTracePrefix) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (p *sysfsProcessor) ReadTrace(name string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(p.debugfsPath(), name))
+	if err != nil {
+		return nil, err
+	}
+	// The kernel stops at the first NUL, but be lenient with trailing padding.
+	if i := bytes.IndexByte(content, 0); i >= 0 {
+		content = content[:i]
+	}
+	return content, nil
+}
+
+// debugfsPath returns /sys/kernel/debug/remoteproc/remoteprocN, which holds the trace buffers.
+func (p *sysfsProcessor) debugfsPath() string {
+	return filepath.Join(rprocDebugfsPath, filepath.Base(p.devicePath))
+}
@@ -0,0 +1,384 @@
+package remoteproc_test
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSysfsState(t *testing.T) {
+	t.Run("reports firmware booted by the bootloader as attached or detached", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateAttached)
+		processor := sysfs.open(t, devicePath)
+
+		attached, err := processor.State()
+		require.NoError(t, err)
+		sysfs.setState(t, devicePath, remoteproc.StateDetached)
+		detached, err := processor.State()
+		require.NoError(t, err)
+
+		assert.Equal(t, remoteproc.StateAttached, attached)
+		assert.True(t, attached.Running())
+		assert.Equal(t, remoteproc.StateDetached, detached)
+		assert.False(t, detached.Running())
+	})
+}
+
+func TestSysfsSetFirmware(t *testing.T) {
+	t.Run("refuses to replace firmware the kernel attached to", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateAttached)
+		processor := sysfs.open(t, devicePath)
+
+		err := processor.SetFirmware("/lib/firmware/other.elf")
+
+		assert.ErrorContains(t, err, "already running")
+		assert.Equal(t, "rproc-firmware\n", readFixture(t, filepath.Join(devicePath, "firmware")))
+	})
+}
+
+func TestSysfsStart(t *testing.T) {
+	t.Run("attaches to detached firmware through the character device", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateDetached)
+		cdevPath := sysfs.addCdev(t, 0)
+		processor := sysfs.open(t, devicePath)
+
+		require.NoError(t, processor.Start())
+
+		assert.Equal(t, "start", readFixture(t, cdevPath))
+	})
+
+	t.Run("refuses to start the processor of attached firmware", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateAttached)
+		cdevPath := sysfs.addCdev(t, 0)
+		processor := sysfs.open(t, devicePath)
+
+		err := processor.Start()
+
+		assert.ErrorContains(t, err, "already running")
+		assert.Empty(t, readFixture(t, cdevPath))
+	})
+
+	t.Run("writes start through the character device, keeping it open", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateOffline)
+		cdevPath := sysfs.addCdev(t, 0)
+		processor := sysfs.open(t, devicePath)
+		openFiles := countOpenFiles(t)
+
+		require.NoError(t, processor.Start())
+
+		assert.Equal(t, "start", readFixture(t, cdevPath))
+		assert.Equal(t, "offline\n", readFixture(t, filepath.Join(devicePath, "state")))
+		assert.Equal(t, openFiles+1, countOpenFiles(t))
+	})
+
+	t.Run("falls back to sysfs without the character device", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateOffline)
+		processor := sysfs.open(t, devicePath)
+		openFiles := countOpenFiles(t)
+
+		require.NoError(t, processor.Start())
+
+		assert.Equal(t, "start", readFixture(t, filepath.Join(devicePath, "state")))
+		assert.Equal(t, openFiles, countOpenFiles(t))
+	})
+
+	t.Run("falls back to sysfs when the character device can't be opened", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateOffline)
+		// A directory can't be opened for writing, as a device without permission couldn't.
+		require.NoError(t, os.MkdirAll(sysfs.path("dev", "remoteproc0"), 0o755))
+		processor := sysfs.open(t, devicePath)
+
+		require.NoError(t, processor.Start())
+
+		assert.Equal(t, "start", readFixture(t, filepath.Join(devicePath, "state")))
+	})
+
+	t.Run("refuses to start a running processor", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning)
+		cdevPath := sysfs.addCdev(t, 0)
+		processor := sysfs.open(t, devicePath)
+
+		err := processor.Start()
+
+		assert.ErrorContains(t, err, "already running")
+		assert.Empty(t, readFixture(t, cdevPath))
+	})
+
+	t.Run("restarts firmware that stopped by itself through the character device it holds", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateOffline)
+		cdevPath := sysfs.addCdev(t, 0)
+		processor := sysfs.open(t, devicePath)
+		require.NoError(t, processor.Start())
+		sysfs.setState(t, devicePath, remoteproc.StateOffline)
+		openFiles := countOpenFiles(t)
+
+		require.NoError(t, processor.Start())
+
+		assert.Equal(t, openFiles, countOpenFiles(t))
+		assert.Equal(t, "startstart", readFixture(t, cdevPath))
+		require.NoError(t, processor.Stop())
+		assert.Equal(t, openFiles-1, countOpenFiles(t))
+	})
+}
+
+func TestSysfsAttach(t *testing.T) {
+	t.Run("holds the character device of firmware attached to at boot", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateAttached)
+		cdevPath := sysfs.addCdev(t, 0)
+		processor := sysfs.open(t, devicePath)
+		openFiles := countOpenFiles(t)
+
+		require.NoError(t, processor.Attach())
+
+		assert.Equal(t, openFiles+1, countOpenFiles(t))
+		require.NoError(t, processor.Detach())
+		assert.Equal(t, "detach", readFixture(t, cdevPath))
+		assert.Equal(t, openFiles, countOpenFiles(t))
+	})
+
+	t.Run("leaves firmware to sysfs without the character device", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning)
+		processor := sysfs.open(t, devicePath)
+		openFiles := countOpenFiles(t)
+
+		require.NoError(t, processor.Attach())
+
+		assert.Equal(t, openFiles, countOpenFiles(t))
+	})
+
+	t.Run("refuses to attach to a processor that isn't running", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateDetached)
+		sysfs.addCdev(t, 0)
+		processor := sysfs.open(t, devicePath)
+
+		err := processor.Attach()
+
+		assert.EqualError(t, err, "remote processor is detached, not running")
+	})
+}
+
+func TestSysfsStop(t *testing.T) {
+	t.Run("writes stop through the character device and closes it", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateOffline)
+		cdevPath := sysfs.addCdev(t, 0)
+		processor := sysfs.open(t, devicePath)
+		openFiles := countOpenFiles(t)
+		require.NoError(t, processor.Start())
+		sysfs.setState(t, devicePath, remoteproc.StateRunning)
+
+		require.NoError(t, processor.Stop())
+
+		assert.Equal(t, "startstop", readFixture(t, cdevPath))
+		assert.Equal(t, "running\n", readFixture(t, filepath.Join(devicePath, "state")))
+		assert.Equal(t, openFiles, countOpenFiles(t))
+	})
+
+	t.Run("falls back to sysfs for a processor started without the character device", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning)
+		processor := sysfs.open(t, devicePath)
+
+		require.NoError(t, processor.Stop())
+
+		assert.Equal(t, "stop", readFixture(t, filepath.Join(devicePath, "state")))
+	})
+}
+
+func TestSysfsDetach(t *testing.T) {
+	t.Run("writes detach through the character device and closes it", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateDetached)
+		cdevPath := sysfs.addCdev(t, 0)
+		processor := sysfs.open(t, devicePath)
+		openFiles := countOpenFiles(t)
+		require.NoError(t, processor.Start())
+		sysfs.setState(t, devicePath, remoteproc.StateAttached)
+
+		require.NoError(t, processor.Detach())
+
+		assert.Equal(t, "startdetach", readFixture(t, cdevPath))
+		assert.Equal(t, openFiles, countOpenFiles(t))
+	})
+
+	t.Run("falls back to sysfs for firmware attached to at boot", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateAttached)
+		sysfs.addCdev(t, 0)
+		processor := sysfs.open(t, devicePath)
+
+		require.NoError(t, processor.Detach())
+
+		assert.Equal(t, "detach", readFixture(t, filepath.Join(devicePath, "state")))
+	})
+
+	t.Run("refuses to detach firmware the kernel booted", func(t *testing.T) {
+		sysfs := newFakeSysfs(t)
+		devicePath := sysfs.addProcessor(t, 0, "m33", remoteproc.StateRunning)
+		processor := sysfs.open(t, devicePath)
+
+		err := processor.Detach()
+
+		assert.EqualError(t, err, "remote processor is running, not attached")
+		assert.Equal(t, "running\n", readFixture(t, filepath.Join(devicePath, "state")))
+	})
+}
+
+// fakeSysfs is a fixture tree standing in for the kernel's remoteproc interfaces.
+type fakeSysfs struct {
+	root string
+}
+
+func newFakeSysfs(t *testing.T) fakeSysfs {
+	t.Helper()
+	// Resolved, as the backend compares resolved sysfs paths.
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	remoteproc.UseRoot(t, root)
+	return fakeSysfs{root: root}
+}
+
+func (f fakeSysfs) path(segments ...string) string {
+	return filepath.Join(append([]string{f.root}, segments...)...)
+}
+
+// addProcessor creates remoteprocN, returning its device path.
+func (f fakeSysfs) addProcessor(t *testing.T, index int, name string, state remoteproc.State) string {
+	t.Helper()
+	devicePath := f.path("sys", "class", "remoteproc", "remoteproc"+strconv.Itoa(index))
+	writeFixture(t, filepath.Join(devicePath, "name"), name+"\n")
+	writeFixture(t, filepath.Join(devicePath, "firmware"), "rproc-firmware\n")
+	f.setState(t, devicePath, state)
+	return devicePath
+}
+
+// addParent links the processor to its parent device, e.g. 4c000000.m33.
+func (f fakeSysfs) addParent(t *testing.T, devicePath, name string) {
+	t.Helper()
+	parentDir := f.path("sys", "devices", "platform", name)
+	require.NoError(t, os.MkdirAll(parentDir, 0o755))
+	symlinkFixture(t, parentDir, filepath.Join(devicePath, "device"))
+}
+
+// addCdev creates /dev/remoteprocN, returning its path.
+func (f fakeSysfs) addCdev(t *testing.T, index int) string {
+	t.Helper()
+	cdevPath := f.path("dev", "remoteproc"+strconv.Itoa(index))
+	writeFixture(t, cdevPath, "")
+	return cdevPath
+}
+
+// addChannel announces an RPMsg channel below the processor, bound to device.Driver and with
+// the device node below /dev named by device.DeviceNode, if any.
+func (f fakeSysfs) addChannel(t *testing.T, devicePath string, device remoteproc.RPMsgDevice) {
+	t.Helper()
+	deviceDir := filepath.Join(devicePath, "virtio0", device.Name)
+	writeFixture(t, filepath.Join(deviceDir, "name"), device.Channel+"\n")
+	writeFixture(t, filepath.Join(deviceDir, "src"), fmt.Sprintf("0x%x\n", device.Src))
+	writeFixture(t, filepath.Join(deviceDir, "dst"), fmt.Sprintf("0x%x\n", device.Dst))
+	symlinkFixture(t, deviceDir, f.path("sys", "bus", "rpmsg", "devices", device.Name))
+	if device.Driver != "" {
+		driverDir := f.path("sys", "bus", "rpmsg", "drivers", device.Driver)
+		require.NoError(t, os.MkdirAll(driverDir, 0o755))
+		symlinkFixture(t, driverDir, filepath.Join(deviceDir, "driver"))
+	}
+	if device.DeviceNode != "" {
+		node := filepath.Base(device.DeviceNode)
+		class := "rpmsg"
+		if strings.HasPrefix(node, "ttyRPMSG") {
+			class = "tty"
+			symlinkFixture(t, deviceDir, f.path("sys", "class", "tty", node, "device"))
+		}
+		require.NoError(t, os.MkdirAll(filepath.Join(deviceDir, class, node), 0o755))
+	}
+}
+
+// addCoredump creates /sys/class/devcoredump/<name> holding a dump of the processor, returning
+// the path of its data.
+func (f fakeSysfs) addCoredump(t *testing.T, name, devicePath, content string) string {
+	t.Helper()
+	dumpDir := f.path("sys", "class", "devcoredump", name)
+	dataPath := filepath.Join(dumpDir, "data")
+	writeFixture(t, dataPath, content)
+	symlinkFixture(t, devicePath, filepath.Join(dumpDir, "failing_device"))
+	return dataPath
+}
+
+// addEndpoint creates an rpmsg_char endpoint below the processor's RPMsg control device, with
+// its addresses as rpmsg_char prints them.
+func (f fakeSysfs) addEndpoint(t *testing.T, devicePath, node, name, src, dst string) {
+	t.Helper()
+	endpointDir := filepath.Join(devicePath, "virtio0", "virtio0.rpmsg_ctrl.0.0", "rpmsg", node)
+	writeFixture(t, filepath.Join(endpointDir, "name"), name+"\n")
+	writeFixture(t, filepath.Join(endpointDir, "src"), src+"\n")
+	writeFixture(t, filepath.Join(endpointDir, "dst"), dst+"\n")
+	symlinkFixture(t, endpointDir, f.path("sys", "class", "rpmsg", node))
+}
+
+// addClassCtrl registers an RPMsg control device in the rpmsg class only, below the processor's
+// virtio rpmsg device, as kernels before 5.18 do.
+func (f fakeSysfs) addClassCtrl(t *testing.T, devicePath, node string) {
+	t.Helper()
+	ctrlDir := filepath.Join(devicePath, "virtio0", "rpmsg", node)
+	require.NoError(t, os.MkdirAll(ctrlDir, 0o755))
+	symlinkFixture(t, ctrlDir, f.path("sys", "class", "rpmsg", node))
+}
+
+func (f fakeSysfs) setState(t *testing.T, devicePath string, state remoteproc.State) {
+	t.Helper()
+	writeFixture(t, filepath.Join(devicePath, "state"), string(state)+"\n")
+}
+
+func (f fakeSysfs) open(t *testing.T, devicePath string) remoteproc.Processor {
+	t.Helper()
+	processor, err := remoteproc.NewSysfsBackend(slog.New(slog.NewTextHandler(io.Discard, nil))).Open(devicePath)
+	require.NoError(t, err)
+	return processor
+}
+
+func writeFixture(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func symlinkFixture(t *testing.T, target, path string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.Symlink(target, path))
+}
+
+func readFixture(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func countOpenFiles(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	require.NoError(t, err)
+	return len(entries)
+}
@@ -0,0 +1,87 @@
+package remoteproc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/arm/remoteproc-runtime/internal/rootpath"
+	"golang.org/x/sys/unix"
+)
+
+const rpmsgTTYPrefix = "ttyRPMSG"
+
+var ttyClassPath = rootpath.Join("sys", "class", "tty")
+
+// TTYs lists the RPMsg TTYs (ttyRPMSGn) created for the running firmware's rpmsg-tty
+// endpoints. The kernel creates them below the processor's device once it boots.
+func (p *sysfsProcessor) TTYs() ([]string, error) {
+	processorDir, err := filepath.EvalSymlinks(p.devicePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", p.devicePath, err)
+	}
+	entries, err := os.ReadDir(ttyClassPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tty directory %s: %w", ttyClassPath, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), rpmsgTTYPrefix) {
+			continue
+		}
+		device, err := filepath.EvalSymlinks(filepath.Join(ttyClassPath, entry.Name(), rprocDeviceLinkName))
+		if err != nil {
+			continue
+		}
+		if isBelow(device, processorDir) {
+			names = append(names, entry.Name())
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// OpenTTY opens /dev/<name> in raw mode, so bytes pass to and from the firmware unaltered.
+func (p *sysfsProcessor) OpenTTY(name string) (io.ReadWriteCloser, error) {
+	return openRawTTY(filepath.Join(rprocDevPath, name))
+}
+
+// OpenRPMsg opens an RPMsg device node, setting TTYs to raw mode like OpenTTY.
+func (p *sysfsProcessor) OpenRPMsg(deviceNode string) (io.ReadWriteCloser, error) {
+	if strings.HasPrefix(filepath.Base(deviceNode), rpmsgTTYPrefix) {
+		return openRawTTY(deviceNode)
+	}
+	return os.OpenFile(deviceNode, os.O_RDWR, 0)
+}
+
+func openRawTTY(path string) (io.ReadWriteCloser, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := makeRaw(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to set %s to raw mode: %w", path, err)
+	}
+	return f, nil
+}
+
+// makeRaw applies cfmakeraw(3) to a terminal.
+func makeRaw(f *os.File) error {
+	fd := int(f.Fd())
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return err
+	}
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	return unix.IoctlSetTermios(fd, unix.TCSETS, termios)
+}
@@ -0,0 +1,100 @@
+package runtime_test
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttach(t *testing.T) {
+	t.Run("create and start attach to firmware booted by the bootloader", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m4")
+		processor.ForceState(remoteproc.StateDetached)
+		containerID := testID(t)
+		bundlePath := writeBundle(t, &specs.Spec{
+			Version: specs.Version,
+			Process: &specs.Process{},
+			Root:    &specs.Root{Path: "rootfs"},
+			Annotations: map[string]string{
+				oci.SpecName:   "m4",
+				oci.SpecAttach: "true",
+			},
+		})
+
+		require.NoError(t, runtime.Create(logger(), host, containerID, bundlePath, runtime.CreateOptions{}))
+		assertProcessorState(t, processor, remoteproc.StateDetached)
+
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		assertStatus(t, containerID, specs.StateRunning)
+		assertProcessorState(t, processor, remoteproc.StateAttached)
+		assert.Empty(t, processor.Firmware())
+
+		require.NoError(t, runtime.Kill(host, containerID, syscall.SIGTERM))
+		assertStatus(t, containerID, specs.StateStopped)
+		assertProcessorState(t, processor, remoteproc.StateOffline)
+		require.NoError(t, runtime.Delete(logger(), host, containerID, false))
+	})
+
+	t.Run("adopts firmware the kernel is attached to as is", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m4")
+		processor.ForceState(remoteproc.StateAttached)
+		containerID := testID(t)
+
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:   "m4",
+			oci.SpecAttach: "true",
+		}), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+
+		assertStatus(t, containerID, specs.StateRunning)
+		assertProcessorState(t, processor, remoteproc.StateAttached)
+		assert.Equal(t, 0, processor.StartCount())
+	})
+
+	t.Run("pool mode claims a processor with firmware to attach to", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m4a")
+		m4b := backend.AddProcessor("m4b")
+		m4b.ForceState(remoteproc.StateDetached)
+		containerID := testID(t)
+
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:   "m4a,m4b",
+			oci.SpecAttach: "true",
+		}), runtime.CreateOptions{}))
+
+		assertDriverPath(t, containerID, m4b.DevicePath())
+	})
+
+	t.Run("create rejects a processor without firmware", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m4")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:   "m4",
+			oci.SpecAttach: "true",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "processor m4 has no firmware to attach to, it is offline")
+	})
+
+	t.Run("create rejects an invalid value", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m4")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:   "m4",
+			oci.SpecAttach: "yes",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, `invalid remoteproc.attach "yes": must be true or false`)
+	})
+}
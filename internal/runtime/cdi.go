@@ -0,0 +1,179 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	cdispecs "tags.cncf.io/container-device-interface/specs-go"
+)
+
+const (
+	// ProcessorCDIKind is the Container Device Interface kind processors are exported under, so
+	// a firmware container selects its processor with --device arm.com/remoteproc=<processor>.
+	ProcessorCDIKind = "arm.com/remoteproc"
+	// RPMsgCDIKind is the Container Device Interface kind the RPMsg devices are exported under, so
+	// a companion container asks for them with --device vendor.arm.com/rpmsg=<processor>.
+	RPMsgCDIKind = "vendor.arm.com/rpmsg"
+	// DefaultCDISpecDir is where CDI looks for specs generated at runtime.
+	DefaultCDISpecDir = "/var/run/cdi"
+
+	processorCDISpecFileName = "arm.com-remoteproc.json"
+	rpmsgCDISpecFileName     = "vendor.arm.com-rpmsg.json"
+)
+
+// ProcessorCDISpec describes every processor as a CDI device named after it. Instead of device
+// nodes, the device injects the environment variables create reads the processor selection from.
+// Processors sharing a name are told apart by their index, e.g. dsp-1.
+func ProcessorCDISpec(host Host) (*cdispecs.Spec, error) {
+	processors, err := cdiProcessors(host)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]cdispecs.Device, 0, len(processors))
+	for _, processor := range processors {
+		env := []string{oci.EnvName + "=" + processor.info.Name}
+		if processor.ambiguous {
+			env = append(env, oci.EnvIndex+"="+strconv.Itoa(processor.info.Index))
+		}
+		devices = append(devices, cdispecs.Device{
+			Name:           processor.deviceName,
+			ContainerEdits: cdispecs.ContainerEdits{Env: env},
+		})
+	}
+	return newCDISpec(ProcessorCDIKind, devices)
+}
+
+// RPMsgCDISpec describes the RPMsg device nodes of every processor as a CDI device named like
+// in ProcessorCDISpec: the TTYs and character devices of the channels its firmware announced,
+// and the endpoints created on it. Device nodes only exist while the firmware runs, so processors
+// without any are left out.
+func RPMsgCDISpec(host Host) (*cdispecs.Spec, error) {
+	processors, err := cdiProcessors(host)
+	if err != nil {
+		return nil, err
+	}
+	devices := []cdispecs.Device{}
+	for _, processor := range processors {
+		deviceNodes, err := remoteproc.RPMsgDeviceNodes(processor.processor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list RPMsg devices of %s: %w", processor.info.Name, err)
+		}
+		if len(deviceNodes) == 0 {
+			continue
+		}
+		device := cdispecs.Device{Name: processor.deviceName}
+		for _, deviceNode := range deviceNodes {
+			device.ContainerEdits.DeviceNodes = append(device.ContainerEdits.DeviceNodes, &cdispecs.DeviceNode{Path: deviceNode})
+		}
+		devices = append(devices, device)
+	}
+	return newCDISpec(RPMsgCDIKind, devices)
+}
+
+// WriteCDISpecs writes ProcessorCDISpec and RPMsgCDISpec to dir, replacing the previous ones,
+// and returns the files' paths.
+func WriteCDISpecs(host Host, dir string) ([]string, error) {
+	processorSpec, err := ProcessorCDISpec(host)
+	if err != nil {
+		return nil, err
+	}
+	rpmsgSpec, err := RPMsgCDISpec(host)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create CDI spec directory: %w", err)
+	}
+	var specPaths []string
+	for fileName, spec := range map[string]*cdispecs.Spec{
+		processorCDISpecFileName: processorSpec,
+		rpmsgCDISpecFileName:     rpmsgSpec,
+	} {
+		specPath := filepath.Join(dir, fileName)
+		if err := writeCDISpec(specPath, spec); err != nil {
+			return nil, err
+		}
+		specPaths = append(specPaths, specPath)
+	}
+	sort.Strings(specPaths)
+	return specPaths, nil
+}
+
+type cdiProcessor struct {
+	processor  remoteproc.Processor
+	info       remoteproc.Info
+	deviceName string
+	// ambiguous is set when other processors share the processor's name.
+	ambiguous bool
+}
+
+func cdiProcessors(host Host) ([]cdiProcessor, error) {
+	processors, err := host.Backend.Processors()
+	if err != nil {
+		return nil, err
+	}
+	cdiProcessors := make([]cdiProcessor, len(processors))
+	nameCount := map[string]int{}
+	for i, processor := range processors {
+		info, err := processor.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe remote processor %s: %w", processor.Name(), err)
+		}
+		cdiProcessors[i] = cdiProcessor{processor: processor, info: info}
+		nameCount[info.Name]++
+	}
+	for i := range cdiProcessors {
+		p := &cdiProcessors[i]
+		p.deviceName = p.info.Name
+		if nameCount[p.info.Name] > 1 {
+			p.ambiguous = true
+			p.deviceName = fmt.Sprintf("%s-%d", p.info.Name, p.info.Index)
+		}
+	}
+	sort.Slice(cdiProcessors, func(i, j int) bool { return cdiProcessors[i].deviceName < cdiProcessors[j].deviceName })
+	return cdiProcessors, nil
+}
+
+func newCDISpec(kind string, devices []cdispecs.Device) (*cdispecs.Spec, error) {
+	spec := &cdispecs.Spec{Kind: kind, Devices: devices}
+	// Claim the oldest version covering what the spec uses, so older container engines accept it.
+	version, err := cdispecs.MinimumRequiredVersion(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine CDI spec version: %w", err)
+	}
+	spec.Version = version
+	return spec, nil
+}
+
+func writeCDISpec(specPath string, spec *cdispecs.Spec) error {
+	content, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal CDI spec to JSON: %w", err)
+	}
+	// Proxies refresh the specs concurrently, so each writes a file of its own and renames it over.
+	tmp, err := os.CreateTemp(filepath.Dir(specPath), "."+filepath.Base(specPath)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create CDI spec file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write CDI spec file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), specPath); err != nil {
+		return fmt.Errorf("failed to write CDI spec file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package runtime_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc/remoteproctest"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cdispecs "tags.cncf.io/container-device-interface/specs-go"
+)
+
+func TestRPMsgCDISpec(t *testing.T) {
+	t.Run("describes the RPMsg device nodes of processors running firmware", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		m33 := backend.AddProcessor("m33")
+		m33.AddRPMsgDevice(ttyChannel)
+		m33.EnableRPMsgCtrl()
+		backend.AddProcessor("dsp").AddRPMsgDevice(ttyChannel)
+		runFirmware(t, host, testID(t), m33, map[string]string{oci.SpecName: "m33"})
+		_, err := m33.CreateEndpoint(remoteproc.RPMsgEndpoint{Name: "control", Src: 0x400, Dst: 0x1})
+		require.NoError(t, err)
+
+		spec, err := runtime.RPMsgCDISpec(host)
+
+		require.NoError(t, err)
+		assert.Equal(t, runtime.RPMsgCDIKind, spec.Kind)
+		assert.Equal(t, []cdispecs.Device{{
+			Name: "m33",
+			ContainerEdits: cdispecs.ContainerEdits{DeviceNodes: []*cdispecs.DeviceNode{
+				{Path: "/dev/rpmsg0"},
+				{Path: "/dev/ttyRPMSG0"},
+			}},
+		}}, spec.Devices)
+	})
+
+	t.Run("tells processors sharing a name apart by their index", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		for _, index := range []string{"0", "1"} {
+			processor := backend.AddProcessor("dsp")
+			processor.AddRPMsgDevice(ttyChannel)
+			runFirmware(t, host, testID(t)+"-"+index, processor, map[string]string{oci.SpecName: "dsp", oci.SpecIndex: index})
+		}
+
+		spec, err := runtime.RPMsgCDISpec(host)
+
+		require.NoError(t, err)
+		require.Len(t, spec.Devices, 2)
+		assert.Equal(t, "dsp-0", spec.Devices[0].Name)
+		assert.Equal(t, "dsp-1", spec.Devices[1].Name)
+	})
+}
+
+func TestProcessorCDISpec(t *testing.T) {
+	t.Run("describes every processor, whether it runs firmware or not", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		backend.AddProcessor("dsp")
+		backend.AddProcessor("dsp")
+
+		spec, err := runtime.ProcessorCDISpec(host)
+
+		require.NoError(t, err)
+		assert.Equal(t, runtime.ProcessorCDIKind, spec.Kind)
+		assert.Equal(t, []cdispecs.Device{
+			{Name: "dsp-1", ContainerEdits: cdispecs.ContainerEdits{Env: []string{"REMOTEPROC_NAME=dsp", "REMOTEPROC_INDEX=1"}}},
+			{Name: "dsp-2", ContainerEdits: cdispecs.ContainerEdits{Env: []string{"REMOTEPROC_NAME=dsp", "REMOTEPROC_INDEX=2"}}},
+			{Name: "m33", ContainerEdits: cdispecs.ContainerEdits{Env: []string{"REMOTEPROC_NAME=m33"}}},
+		}, spec.Devices)
+	})
+
+	t.Run("create selects the processor whose device was injected", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("dsp")
+		second := backend.AddProcessor("dsp")
+		spec, err := runtime.ProcessorCDISpec(host)
+		require.NoError(t, err)
+		containerID := testID(t)
+		// The container engine appends the device's environment to the process's.
+		bundlePath := writeBundle(t, &specs.Spec{
+			Version: specs.Version,
+			Process: &specs.Process{
+				Args: []string{"firmware.elf"},
+				Env:  append([]string{"PATH=/bin"}, spec.Devices[1].ContainerEdits.Env...),
+			},
+			Root: &specs.Root{Path: "rootfs"},
+		})
+
+		require.NoError(t, runtime.Create(logger(), host, containerID, bundlePath, runtime.CreateOptions{}))
+
+		assertDriverPath(t, containerID, second.DevicePath())
+	})
+}
+
+func TestWriteCDISpecs(t *testing.T) {
+	t.Run("writes the specs where CDI picks them up", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.AddRPMsgDevice(ttyChannel)
+		runFirmware(t, host, testID(t), processor, map[string]string{oci.SpecName: "m33"})
+		dir := filepath.Join(t.TempDir(), "cdi")
+
+		specPaths, err := runtime.WriteCDISpecs(host, dir)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(dir, "arm.com-remoteproc.json"),
+			filepath.Join(dir, "vendor.arm.com-rpmsg.json"),
+		}, specPaths)
+		for _, specPath := range specPaths {
+			content, err := os.ReadFile(specPath)
+			require.NoError(t, err)
+			var spec cdispecs.Spec
+			require.NoError(t, json.Unmarshal(content, &spec))
+			assert.NoError(t, cdispecs.ValidateVersion(&spec))
+			assert.Len(t, spec.Devices, 1)
+		}
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2, "temporary files should be gone")
+	})
+}
+
+// runFirmware runs a container selecting processor through annotations.
+func runFirmware(t *testing.T, host runtime.Host, containerID string, processor *remoteproctest.Processor, annotations map[string]string) {
+	t.Helper()
+	require.NoError(t, runtime.Create(logger(), host, containerID, generateBundleWithAnnotations(t, annotations), runtime.CreateOptions{}))
+	require.NoError(t, runtime.Start(logger(), host, containerID))
+	assertProcessorState(t, processor, remoteproc.StateRunning)
+}
@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// claimProcessor picks the processor a new container runs on and takes its ownership lock.
+// By default the selector must match exactly one processor. In pool mode, enabled by listing
+// several names or by the pool annotation, the first candidate that is offline, or has firmware
+// to attach to when attach is set, and not owned by another container is taken.
+//
+// Callers must hold oci.LockClaims until the lock is claimed for the container.
+func claimProcessor(logger *slog.Logger, host Host, annotations map[string]string, attach bool) (remoteproc.Processor, *oci.ProcessorLock, error) {
+	selector, err := selectorFromAnnotations(annotations)
+	if err != nil {
+		return nil, nil, err
+	}
+	pool := len(selector.Names) > 1
+	if rawPool, ok := annotations[oci.SpecPool]; ok {
+		pool, err = strconv.ParseBool(rawPool)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s %q: must be true or false", oci.SpecPool, rawPool)
+		}
+	}
+	if !pool {
+		processor, err := remoteproc.Select(host.Backend, selector)
+		if err != nil {
+			return nil, nil, err
+		}
+		lock, err := lockProcessor(logger, processor)
+		var owned *oci.ProcessorOwnedError
+		if errors.As(err, &owned) {
+			return nil, nil, fmt.Errorf("processor %s is owned by container %s", processor.Name(), owned.Owner)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return processor, lock, nil
+	}
+
+	candidates, err := remoteproc.Candidates(host.Backend, selector)
+	if err != nil {
+		return nil, nil, err
+	}
+	var unavailable []string
+	for _, candidate := range candidates {
+		description := remoteproc.DescribeCandidate(candidate)
+		lock, err := lockProcessor(logger, candidate)
+		var owned *oci.ProcessorOwnedError
+		if errors.As(err, &owned) {
+			unavailable = append(unavailable, fmt.Sprintf("%s owned by container %s", description, owned.Owner))
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		state, err := candidate.State()
+		if err != nil {
+			lock.Close()
+			unavailable = append(unavailable, fmt.Sprintf("%s in unknown state: %v", description, err))
+			continue
+		}
+		usable := state == remoteproc.StateOffline
+		if attach {
+			usable = attachable(state)
+		}
+		if !usable {
+			lock.Close()
+			unavailable = append(unavailable, fmt.Sprintf("%s is %s", description, state))
+			continue
+		}
+		return candidate, lock, nil
+	}
+	return nil, nil, fmt.Errorf("no free remote processor matching %s: %s", selector, strings.Join(unavailable, "; "))
+}
+
+// lockProcessor takes the ownership lock of processor. A lock file still naming a container
+// whose proxy has exited is reclaimed, since nothing is holding the processor any more.
+func lockProcessor(logger *slog.Logger, processor remoteproc.Processor) (*oci.ProcessorLock, error) {
+	lock, err := oci.TryLockProcessor(processor.DevicePath())
+	if err != nil {
+		return nil, err
+	}
+	if previousOwner := lock.PreviousOwner(); previousOwner != "" {
+		logger.Warn("reclaiming processor from container whose proxy is no longer running",
+			"processor", processor.Name(), "previousOwner", previousOwner)
+	}
+	return lock, nil
+}
+
+// attachable reports whether a processor in state runs firmware a container can attach to.
+func attachable(state remoteproc.State) bool {
+	return state.Running() || state == remoteproc.StateDetached
+}
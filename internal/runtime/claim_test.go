@@ -0,0 +1,141 @@
+package runtime_test
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessorPool(t *testing.T) {
+	t.Run("claims the first free processor among listed names", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		dsp0 := backend.AddProcessor("dsp0")
+		dsp1 := backend.AddProcessor("dsp1")
+		bundlePath := generateBundle(t, "dsp0,dsp1")
+		first, second := testID(t)+"-1", testID(t)+"-2"
+
+		require.NoError(t, runtime.Create(logger(), host, first, bundlePath, runtime.CreateOptions{}))
+		require.NoError(t, runtime.Create(logger(), host, second, bundlePath, runtime.CreateOptions{}))
+
+		assertDriverPath(t, first, dsp0.DevicePath())
+		assertDriverPath(t, second, dsp1.DevicePath())
+	})
+
+	t.Run("pool annotation lets identically named processors be shared", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("dsp")
+		dsp1 := backend.AddProcessor("dsp")
+		bundlePath := generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName: "dsp",
+			oci.SpecPool: "true",
+		})
+		first, second := testID(t)+"-1", testID(t)+"-2"
+		require.NoError(t, runtime.Create(logger(), host, first, bundlePath, runtime.CreateOptions{}))
+
+		require.NoError(t, runtime.Create(logger(), host, second, bundlePath, runtime.CreateOptions{}))
+
+		assertDriverPath(t, second, dsp1.DevicePath())
+	})
+
+	t.Run("skips processors that are not offline", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("dsp0").ForceState(remoteproc.StateRunning)
+		dsp1 := backend.AddProcessor("dsp1")
+		containerID := testID(t)
+
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "dsp0,dsp1"), runtime.CreateOptions{}))
+
+		assertDriverPath(t, containerID, dsp1.DevicePath())
+	})
+
+	t.Run("rejects selectors that can't select a processor", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("dsp0")
+
+		for _, names := range []string{"", " , "} {
+			err := runtime.Create(logger(), host, testID(t), generateBundle(t, names), runtime.CreateOptions{})
+
+			assert.ErrorContains(t, err, fmt.Sprintf("invalid remoteproc.name %q: must name at least one processor", names))
+		}
+
+		bundlePath := generateBundleWithAnnotations(t, map[string]string{oci.SpecNameGlob: "dsp["})
+		err := runtime.Create(logger(), host, testID(t), bundlePath, runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, `invalid remoteproc.name-glob "dsp[": syntax error in pattern`)
+	})
+
+	t.Run("errors explaining why each candidate is unavailable", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("dsp0")
+		backend.AddProcessor("dsp1").ForceState(remoteproc.StateCrashed)
+		bundlePath := generateBundle(t, "dsp0,dsp1")
+		owner := testID(t) + "-owner"
+		require.NoError(t, runtime.Create(logger(), host, owner, bundlePath, runtime.CreateOptions{}))
+
+		err := runtime.Create(logger(), host, testID(t), bundlePath, runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "no free remote processor matching dsp0,dsp1")
+		assert.ErrorContains(t, err, "dsp0 (index=0) owned by container "+owner)
+		assert.ErrorContains(t, err, "dsp1 (index=1) is crashed")
+	})
+
+	t.Run("stopped containers don't hold on to their processor", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		dsp0 := backend.AddProcessor("dsp0")
+		backend.AddProcessor("dsp1")
+		bundlePath := generateBundle(t, "dsp0,dsp1")
+		stopped := testID(t) + "-stopped"
+		require.NoError(t, runtime.Create(logger(), host, stopped, bundlePath, runtime.CreateOptions{}))
+		require.NoError(t, runtime.Kill(host, stopped, syscall.SIGTERM))
+		waitForProxy(t, launcher, stopped)
+		containerID := testID(t)
+
+		require.NoError(t, runtime.Create(logger(), host, containerID, bundlePath, runtime.CreateOptions{}))
+
+		assertDriverPath(t, containerID, dsp0.DevicePath())
+	})
+
+	t.Run("concurrent creates never claim the same processor", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		const poolSize = 4
+		names := ""
+		for i := range poolSize {
+			backend.AddProcessor(fmt.Sprintf("dsp%d", i))
+			names += fmt.Sprintf("dsp%d,", i)
+		}
+		bundlePath := generateBundle(t, names)
+
+		var wg sync.WaitGroup
+		errs := make([]error, poolSize)
+		for i := range poolSize {
+			wg.Go(func() {
+				errs[i] = runtime.Create(logger(), host, fmt.Sprintf("%s-%d", testID(t), i), bundlePath, runtime.CreateOptions{})
+			})
+		}
+		wg.Wait()
+
+		claimed := map[string]bool{}
+		for i := range poolSize {
+			require.NoError(t, errs[i])
+			state, err := runtime.State(fmt.Sprintf("%s-%d", testID(t), i))
+			require.NoError(t, err)
+			claimed[state.Annotations[oci.StateDriverPath]] = true
+		}
+		assert.Len(t, claimed, poolSize)
+	})
+}
+
+func assertDriverPath(t *testing.T, containerID string, want string) {
+	t.Helper()
+	state, err := runtime.State(containerID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, want, state.Annotations[oci.StateDriverPath])
+	}
+}
@@ -0,0 +1,68 @@
+package runtime
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/containerd/console"
+	"golang.org/x/sys/unix"
+)
+
+// openConsole allocates the container's terminal and, like runc, sends the pty master to the
+// caller listening on consoleSocket. It returns the pty slave, which the proxy bridges to the
+// firmware's RPMsg TTY.
+func openConsole(consoleSocket string) (*os.File, error) {
+	master, slavePath, err := console.NewPty()
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate terminal: %w", err)
+	}
+	defer func() { _ = master.Close() }()
+
+	slave, err := os.OpenFile(slavePath, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open terminal %s: %w", slavePath, err)
+	}
+	if err := setConsoleMode(slave); err != nil {
+		_ = slave.Close()
+		return nil, fmt.Errorf("failed to configure terminal %s: %w", slavePath, err)
+	}
+	if err := sendConsoleMaster(consoleSocket, master); err != nil {
+		_ = slave.Close()
+		return nil, err
+	}
+	return slave, nil
+}
+
+// setConsoleMode passes keystrokes through untouched, since the firmware's shell does its own
+// echo and line editing, but keeps output post-processing so trace output's bare newlines
+// still return the cursor.
+func setConsoleMode(f *os.File) error {
+	fd := int(f.Fd())
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return err
+	}
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	return unix.IoctlSetTermios(fd, unix.TCSETS, termios)
+}
+
+func sendConsoleMaster(consoleSocket string, master console.Console) error {
+	conn, err := net.Dial("unix", consoleSocket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to console socket: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("console socket %s is not a unix socket", consoleSocket)
+	}
+	rights := unix.UnixRights(int(master.Fd()))
+	if _, _, err := unixConn.WriteMsgUnix([]byte(master.Name()), rights, nil); err != nil {
+		return fmt.Errorf("failed to send terminal to console socket: %w", err)
+	}
+	return nil
+}
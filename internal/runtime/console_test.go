@@ -0,0 +1,87 @@
+package runtime_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	runc "github.com/containerd/go-runc"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsole(t *testing.T) {
+	t.Run("bridges the terminal sent to the console socket to the firmware's RPMsg TTY", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.AddTTY("ttyRPMSG0")
+		socket := newConsoleSocket(t)
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateTerminalBundle(t, "m33"), runtime.CreateOptions{ConsoleSocket: socket.Path()}))
+		console, err := socket.ReceiveMaster()
+		require.NoError(t, err)
+		defer func() { _ = console.Close() }()
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		require.Eventually(t, func() bool {
+			return processor.TTYOpen("ttyRPMSG0")
+		}, 2*time.Second, 10*time.Millisecond)
+
+		go func() { _ = processor.WriteTTY("ttyRPMSG0", "uart:~$ ") }()
+		prompt := make([]byte, len("uart:~$ "))
+		_, err = console.Read(prompt)
+		require.NoError(t, err)
+		_, err = console.Write([]byte("help\r"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "uart:~$ ", string(prompt))
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.Equal(c, "help\r", processor.TTYInput("ttyRPMSG0"))
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("create requires a console socket for containers with a terminal", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateTerminalBundle(t, "m33"), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "process.terminal requires a console socket")
+	})
+
+	t.Run("create rejects a console socket for containers without a terminal", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		socket := newConsoleSocket(t)
+
+		err := runtime.Create(logger(), host, testID(t), generateBundle(t, "m33"), runtime.CreateOptions{ConsoleSocket: socket.Path()})
+
+		assert.ErrorContains(t, err, "a console socket was provided but process.terminal is false")
+	})
+}
+
+func newConsoleSocket(t *testing.T) *runc.Socket {
+	t.Helper()
+	socket, err := runc.NewConsoleSocket(filepath.Join(t.TempDir(), "console.sock"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = socket.Close() })
+	return socket
+}
+
+func generateTerminalBundle(t *testing.T, processorName string) string {
+	t.Helper()
+	bundlePath := generateBundle(t, processorName)
+	configPath := filepath.Join(bundlePath, "config.json")
+	configData, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	var spec specs.Spec
+	require.NoError(t, json.Unmarshal(configData, &spec))
+	spec.Process.Terminal = true
+	configData, err = json.Marshal(spec)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(configPath, configData, 0o644))
+	return bundlePath
+}
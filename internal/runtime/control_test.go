@@ -0,0 +1,99 @@
+package runtime_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControlSocket(t *testing.T) {
+	t.Run("status reports the running firmware", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+
+		reply, err := proxy.SendControl(containerID, proxy.ControlStatus, time.Second)
+
+		require.NoError(t, err)
+		assert.Equal(t, remoteproc.StateRunning, reply.State)
+		assert.NotNil(t, reply.Status)
+	})
+
+	t.Run("requests that don't fit the lifecycle are refused", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+
+		_, err := proxy.SendControl(containerID, proxy.ControlStart, time.Second)
+
+		assert.EqualError(t, err, "firmware already started")
+	})
+
+	t.Run("detaching firmware never started has the proxy exit", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+
+		_, err := proxy.SendControl(containerID, proxy.ControlDetach, time.Second)
+
+		require.NoError(t, err)
+		waitForProxy(t, launcher, containerID)
+		assert.Equal(t, 0, processor.StartCount())
+	})
+
+	t.Run("reports the proxy exited once it is gone", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Kill(host, containerID, syscall.SIGKILL))
+		waitForProxy(t, launcher, containerID)
+
+		_, err := proxy.SendControl(containerID, proxy.ControlStatus, time.Second)
+
+		assert.ErrorIs(t, err, proxy.ErrProxyExited)
+	})
+
+	t.Run("is removed along with the container", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+
+		require.NoError(t, runtime.Delete(logger(), host, containerID, true))
+
+		_, err := proxy.SendControl(containerID, proxy.ControlStatus, time.Second)
+		assert.ErrorIs(t, err, proxy.ErrNoControlSocket)
+	})
+}
+
+func TestLiveStateDetectsExitedProxy(t *testing.T) {
+	t.Run("reports and records a container stopped once its firmware stopped", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+
+		processor.ForceState(remoteproc.StateOffline)
+		waitForProxy(t, launcher, containerID)
+		state, err := runtime.LiveState(logger(), host, containerID)
+
+		require.NoError(t, err)
+		assert.Equal(t, specs.StateStopped, state.Status)
+		assertStatus(t, containerID, specs.StateStopped)
+	})
+}
@@ -0,0 +1,86 @@
+package runtime_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoredump(t *testing.T) {
+	t.Run("sets the coredump mode and records where the coredump went", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		dir := t.TempDir()
+		containerID := testID(t)
+		runFirmware(t, host, containerID, processor, map[string]string{
+			oci.SpecName:        "m33",
+			oci.SpecCoredump:    "inline",
+			oci.SpecCoredumpDir: dir,
+		})
+		assert.Equal(t, remoteproc.CoredumpInline, processor.CoredumpMode())
+
+		processor.AddCoredump([]byte("ELF core"))
+
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			state, err := runtime.State(containerID)
+			if assert.NoError(c, err) {
+				assert.Equal(c, dir, filepath.Dir(state.Annotations[oci.OptionalStateCoredump]))
+			}
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("saves coredumps to the container's state directory by default", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		runFirmware(t, host, containerID, processor, map[string]string{
+			oci.SpecName:     "m33",
+			oci.SpecCoredump: "enabled",
+		})
+
+		processor.AddCoredump([]byte("ELF core"))
+
+		stateDir, err := oci.StateDir(containerID)
+		require.NoError(t, err)
+		var path string
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			state, err := runtime.State(containerID)
+			if assert.NoError(c, err) {
+				path = state.Annotations[oci.OptionalStateCoredump]
+				assert.Equal(c, stateDir, filepath.Dir(path))
+			}
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.FileExists(t, path)
+	})
+
+	t.Run("create rejects unknown modes", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:     "m33",
+			oci.SpecCoredump: "always",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, `invalid remoteproc.coredump "always": must be one of disabled, enabled, inline`)
+	})
+
+	t.Run("create rejects a relative directory", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:        "m33",
+			oci.SpecCoredump:    "enabled",
+			oci.SpecCoredumpDir: "crashes",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "must be an absolute path")
+	})
+}
@@ -1,10 +1,16 @@
 package runtime
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/arm/remoteproc-runtime/internal/oci"
 	"github.com/arm/remoteproc-runtime/internal/proxy"
@@ -12,65 +18,338 @@ import (
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
-func Create(logger *slog.Logger, containerID string, bundlePath string, pidFile string) error {
+// CreateOptions holds the optional settings of Create.
+type CreateOptions struct {
+	// PidFile receives the proxy process PID.
+	PidFile string
+	// ConsoleSocket is an AF_UNIX socket receiving the pty master of a container with
+	// process.terminal set, following runc's --console-socket protocol.
+	ConsoleSocket string
+}
+
+func Create(logger *slog.Logger, host Host, containerID string, bundlePath string, opts CreateOptions) error {
+	if err := oci.ValidateContainerID(containerID); err != nil {
+		return err
+	}
 	spec, err := oci.ReadSpec(bundlePath)
 	if err != nil {
 		return fmt.Errorf("failed to read container specification: %w", err)
 	}
+	terminal := spec.Process != nil && spec.Process.Terminal
+	if terminal && opts.ConsoleSocket == "" {
+		return fmt.Errorf("process.terminal requires a console socket")
+	}
+	if !terminal && opts.ConsoleSocket != "" {
+		return fmt.Errorf("a console socket was provided but process.terminal is false")
+	}
 
-	name := spec.Annotations[oci.SpecName]
-	devicePath, err := remoteproc.FindDevicePath(name)
+	attach := false
+	if rawAttach, ok := spec.Annotations[oci.SpecAttach]; ok {
+		attach, err = strconv.ParseBool(rawAttach)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: must be true or false", oci.SpecAttach, rawAttach)
+		}
+	}
+	var firmwarePath string
+	var resourceTable *remoteproc.ResourceTable
+	// Attached containers adopt the firmware running on the processor, leaving theirs unused.
+	if !attach {
+		firmwarePath, resourceTable, err = loadFirmware(spec, bundlePath)
+		if err != nil {
+			return err
+		}
+	}
+	var endpoints []remoteproc.RPMsgEndpoint
+	if value, ok := spec.Annotations[oci.SpecRPMsgEndpoints]; ok {
+		endpoints, err = remoteproc.ParseRPMsgEndpoints(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s annotation: %w", oci.SpecRPMsgEndpoints, err)
+		}
+	}
+	refreshCDI := false
+	if rawRefresh, ok := spec.Annotations[oci.SpecCDIRefresh]; ok {
+		refreshCDI, err = strconv.ParseBool(rawRefresh)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: must be true or false", oci.SpecCDIRefresh, rawRefresh)
+		}
+	}
+	shutdown, err := gracefulShutdownFromAnnotations(spec.Annotations)
 	if err != nil {
-		return fmt.Errorf("can't determine remoteproc path: %w", err)
+		return err
+	}
+	if _, err := readinessFromAnnotations(spec.Annotations); err != nil {
+		return err
+	}
+	healthCheck, err := healthCheckFromAnnotations(spec.Annotations)
+	if err != nil {
+		return err
+	}
+	restart, err := restartPolicyFromAnnotations(spec.Annotations)
+	if err != nil {
+		return err
+	}
+	coredumpMode, coredumpDir, err := coredumpFromAnnotations(containerID, spec.Annotations)
+	if err != nil {
+		return err
+	}
+	recovery := spec.Annotations[oci.SpecRecovery]
+	if _, ok := spec.Annotations[oci.SpecRecovery]; ok && !slices.Contains(proxy.RecoveryModes, recovery) {
+		return fmt.Errorf("invalid %s %q: must be one of %s", oci.SpecRecovery, recovery, strings.Join(proxy.RecoveryModes, ", "))
 	}
 
-	firmwareName, err := extractFirmwareName(spec)
+	unlockClaims, err := oci.LockClaims()
 	if err != nil {
-		return fmt.Errorf("can't extract firmware name: %w", err)
+		return err
 	}
-	absRootFS := spec.Root.Path
-	if !filepath.IsAbs(absRootFS) {
-		absRootFS = filepath.Join(bundlePath, absRootFS)
+	defer unlockClaims()
+
+	processor, lock, err := claimProcessor(logger, host, spec.Annotations, attach)
+	if err != nil {
+		return fmt.Errorf("can't determine remoteproc path: %w", err)
 	}
-	firmwarePath := filepath.Join(absRootFS, firmwareName)
-	if err := validateFirmwareExists(firmwarePath); err != nil {
+	defer lock.Close()
+	if attach {
+		if state, err := processor.State(); err != nil {
+			return fmt.Errorf("failed to get remoteproc state: %w", err)
+		} else if !attachable(state) {
+			return fmt.Errorf("processor %s has no firmware to attach to, it is %s", processor.Name(), state)
+		}
+	}
+	devicePath := processor.DevicePath()
+	if err := lock.Claim(containerID); err != nil {
 		return err
 	}
+	needRelease := true
+	defer func() {
+		if needRelease {
+			_ = oci.ReleaseProcessor(devicePath, containerID)
+		}
+	}()
+	if coredumpMode != "" {
+		if err := processor.SetCoredump(coredumpMode); err != nil {
+			return err
+		}
+	}
+	if recovery != "" {
+		mode := recovery
+		if mode == proxy.RecoverOnce {
+			// The proxy disables recovery once the kernel has used it.
+			mode = remoteproc.RecoveryEnabled
+		}
+		if err := processor.SetRecovery(mode); err != nil {
+			return err
+		}
+	}
 
 	var namespaces []specs.LinuxNamespace
 	if spec.Linux != nil {
 		namespaces = spec.Linux.Namespaces
 	}
 
-	pid, err := proxy.NewProcess(logger, namespaces, devicePath)
+	var consoleFile *os.File
+	if terminal {
+		consoleFile, err = openConsole(opts.ConsoleSocket)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = consoleFile.Close() }()
+	}
+
+	// What an earlier container by the same ID reported doesn't describe this one.
+	if err := oci.RemoveProxyStatus(containerID); err != nil {
+		return err
+	}
+
+	control, err := proxy.ListenControl(containerID)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = control.Close() }()
+	defer func() {
+		if needRelease {
+			_ = oci.RemoveControlSocket(containerID)
+		}
+	}()
+
+	pid, err := host.Proxy.Launch(logger, proxy.Options{
+		DevicePath:     devicePath,
+		Namespaces:     namespaces,
+		OwnershipLock:  lock.File(),
+		Console:        consoleFile,
+		ContainerID:    containerID,
+		RPMsgEndpoints: endpoints,
+		RefreshCDI:     refreshCDI,
+		Shutdown:       shutdown,
+		HealthCheck:    healthCheck,
+		Restart:        restart,
+		CoredumpDir:    coredumpDir,
+		Recovery:       recovery,
+		Attach:         attach,
+		Control:        control,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to start proxy process: %w", err)
 	}
 	needCleanup := true
 	defer func() {
 		if needCleanup {
-			_ = proxy.StopFirmware(pid)
+			_ = proxy.StopFirmware(host.Proxy, pid)
 		}
 	}()
 
 	state := oci.NewState(containerID, bundlePath)
 	state.Pid = pid
 	state.Annotations[oci.StateDriverPath] = devicePath
-	state.Annotations[oci.StateFirmwarePath] = firmwarePath
+	if attach {
+		state.Annotations[oci.SpecAttach] = "true"
+	} else {
+		state.Annotations[oci.StateFirmwarePath] = firmwarePath
+	}
+	maps.Copy(state.Annotations, resourceTableAnnotations(resourceTable))
+	if shutdown != nil {
+		state.Annotations[oci.SpecShutdownTimeout] = shutdown.Timeout.String()
+	}
+	for _, key := range readyAnnotations {
+		if value, ok := spec.Annotations[key]; ok {
+			state.Annotations[key] = value
+		}
+	}
 	if err := oci.WriteState(state); err != nil {
 		return err
 	}
 
-	if pidFile != "" {
-		if err := writePidFile(pidFile, pid); err != nil {
+	if opts.PidFile != "" {
+		if err := writePidFile(opts.PidFile, pid); err != nil {
 			return fmt.Errorf("failed to write PID file: %w", err)
 		}
 	}
 
 	needCleanup = false
+	needRelease = false
 	return nil
 }
 
+// restartPolicyFromAnnotations reads the restart policy, returning nil when firmware isn't
+// restarted.
+func restartPolicyFromAnnotations(annotations map[string]string) (*proxy.RestartPolicy, error) {
+	rawPolicy, ok := annotations[oci.SpecRestartPolicy]
+	if !ok {
+		if _, ok := annotations[oci.SpecRestartBackoff]; ok {
+			return nil, fmt.Errorf("%s requires %s", oci.SpecRestartBackoff, oci.SpecRestartPolicy)
+		}
+		return nil, nil
+	}
+	policy, err := proxy.ParseRestartPolicy(rawPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", oci.SpecRestartPolicy, err)
+	}
+	if rawBackoff, ok := annotations[oci.SpecRestartBackoff]; ok && policy != nil {
+		backoff, err := time.ParseDuration(rawBackoff)
+		if err != nil || backoff <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration, e.g. 1s", oci.SpecRestartBackoff, rawBackoff)
+		}
+		policy.Backoff = backoff
+	}
+	return policy, nil
+}
+
+// coredumpFromAnnotations reads the coredump mode to set, empty to leave it be, and the
+// directory the proxy saves coredumps to, empty unless the mode has the kernel make them.
+func coredumpFromAnnotations(containerID string, annotations map[string]string) (string, string, error) {
+	mode, ok := annotations[oci.SpecCoredump]
+	if !ok {
+		if _, ok := annotations[oci.SpecCoredumpDir]; ok {
+			return "", "", fmt.Errorf("%s requires %s", oci.SpecCoredumpDir, oci.SpecCoredump)
+		}
+		return "", "", nil
+	}
+	if !slices.Contains(remoteproc.CoredumpModes, mode) {
+		return "", "", fmt.Errorf("invalid %s %q: must be one of %s", oci.SpecCoredump, mode, strings.Join(remoteproc.CoredumpModes, ", "))
+	}
+	if mode == remoteproc.CoredumpDisabled {
+		return mode, "", nil
+	}
+	dir, ok := annotations[oci.SpecCoredumpDir]
+	if !ok {
+		stateDir, err := oci.StateDir(containerID)
+		if err != nil {
+			return "", "", err
+		}
+		return mode, stateDir, nil
+	}
+	if !filepath.IsAbs(dir) {
+		return "", "", fmt.Errorf("invalid %s %q: must be an absolute path", oci.SpecCoredumpDir, dir)
+	}
+	return mode, dir, nil
+}
+
+// gracefulShutdownFromAnnotations reads the shutdown handshake configuration, returning nil
+// when no channel is set.
+func gracefulShutdownFromAnnotations(annotations map[string]string) (*proxy.GracefulShutdown, error) {
+	channel, ok := annotations[oci.SpecShutdownChannel]
+	if !ok {
+		for _, key := range []string{oci.SpecShutdownMessage, oci.SpecShutdownTimeout} {
+			if _, ok := annotations[key]; ok {
+				return nil, fmt.Errorf("%s requires %s", key, oci.SpecShutdownChannel)
+			}
+		}
+		return nil, nil
+	}
+	if channel == "" {
+		return nil, fmt.Errorf("invalid %s: must not be empty", oci.SpecShutdownChannel)
+	}
+	shutdown := &proxy.GracefulShutdown{
+		Channel: channel,
+		Message: []byte(proxy.DefaultShutdownMessage),
+		Timeout: proxy.DefaultShutdownTimeout,
+	}
+	if rawMessage, ok := annotations[oci.SpecShutdownMessage]; ok {
+		message, err := proxy.ParseMessage(rawMessage)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %w", oci.SpecShutdownMessage, err)
+		}
+		shutdown.Message = message
+	}
+	if rawTimeout, ok := annotations[oci.SpecShutdownTimeout]; ok {
+		timeout, err := time.ParseDuration(rawTimeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration, e.g. 10s", oci.SpecShutdownTimeout, rawTimeout)
+		}
+		shutdown.Timeout = timeout
+	}
+	return shutdown, nil
+}
+
+// loadFirmware finds the firmware the container's process names in its root filesystem, checks
+// it meets the requirements the annotations set, and reads its resource table, nil if it has
+// none.
+func loadFirmware(spec *specs.Spec, bundlePath string) (string, *remoteproc.ResourceTable, error) {
+	firmwareName, err := extractFirmwareName(spec)
+	if err != nil {
+		return "", nil, fmt.Errorf("can't extract firmware name: %w", err)
+	}
+	absRootFS := spec.Root.Path
+	if !filepath.IsAbs(absRootFS) {
+		absRootFS = filepath.Join(bundlePath, absRootFS)
+	}
+	firmwarePath := filepath.Join(absRootFS, firmwareName)
+	if err := validateFirmwareExists(firmwarePath); err != nil {
+		return "", nil, err
+	}
+	requirements, err := firmwareRequirementsFromAnnotations(spec.Annotations)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := remoteproc.ValidateFirmware(firmwarePath, requirements); err != nil {
+		return "", nil, fmt.Errorf("invalid firmware: %w", err)
+	}
+	resourceTable, err := remoteproc.ReadResourceTable(firmwarePath)
+	if err != nil && !errors.Is(err, remoteproc.ErrNoResourceTable) {
+		return "", nil, fmt.Errorf("invalid firmware: %w", err)
+	}
+	return firmwarePath, resourceTable, nil
+}
+
 func extractFirmwareName(spec *specs.Spec) (string, error) {
 	if len(spec.Process.Args) != 1 {
 		return "", fmt.Errorf("expected exactly one process argument")
@@ -10,16 +10,16 @@ import (
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
-func Delete(logger *slog.Logger, containerID string, force bool) error {
+func Delete(logger *slog.Logger, host Host, containerID string, force bool) error {
 	if force {
-		forceDelete(logger, containerID)
+		forceDelete(logger, host, containerID)
 		return nil
 	} else {
-		return delete(containerID)
+		return delete(logger, host, containerID)
 	}
 }
 
-func delete(containerID string) error {
+func delete(logger *slog.Logger, host Host, containerID string) error {
 	state, err := oci.ReadState(containerID)
 	if err != nil {
 		return fmt.Errorf("failed to read state: %w", err)
@@ -36,6 +36,14 @@ func delete(containerID string) error {
 		}
 	}
 
+	if err := destroyRPMsgEndpoints(host, state); err != nil {
+		logger.Warn("failed to destroy RPMsg endpoints", "error", err)
+	}
+
+	if err := releaseProcessor(state); err != nil {
+		return fmt.Errorf("failed to release processor: %w", err)
+	}
+
 	if err := oci.RemoveState(containerID); err != nil {
 		return fmt.Errorf("failed to remove state: %w", err)
 	}
@@ -43,7 +51,7 @@ func delete(containerID string) error {
 	return nil
 }
 
-func forceDelete(logger *slog.Logger, containerID string) {
+func forceDelete(logger *slog.Logger, host Host, containerID string) {
 	state, err := oci.ReadState(containerID)
 	if err != nil {
 		logger.Error("failed to read state", "error", err)
@@ -51,7 +59,7 @@ func forceDelete(logger *slog.Logger, containerID string) {
 	}
 
 	if state.Status == specs.StateRunning {
-		if err := Kill(containerID, syscall.SIGKILL); err != nil {
+		if err := Kill(host, containerID, syscall.SIGKILL); err != nil {
 			logger.Error("failed to kill container", "error", err)
 		}
 	}
@@ -63,7 +71,24 @@ func forceDelete(logger *slog.Logger, containerID string) {
 		}
 	}
 
+	if err := destroyRPMsgEndpoints(host, state); err != nil {
+		logger.Error("failed to destroy RPMsg endpoints", "error", err)
+	}
+
+	if err := releaseProcessor(state); err != nil {
+		logger.Error("failed to release processor", "error", err)
+	}
+
 	if err := oci.RemoveState(containerID); err != nil {
 		logger.Error("failed to remove state", "error", err)
 	}
 }
+
+func releaseProcessor(state *specs.State) error {
+	unlockClaims, err := oci.LockClaims()
+	if err != nil {
+		return err
+	}
+	defer unlockClaims()
+	return oci.ReleaseProcessor(state.Annotations[oci.StateDriverPath], state.ID)
+}
@@ -0,0 +1,53 @@
+package runtime
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// detachTimeout is how long the proxy gets to detach from the firmware and exit.
+const detachTimeout = 5 * time.Second
+
+// Detach stops the container without stopping its firmware: the proxy detaches the kernel from
+// the firmware and exits, leaving the firmware running on the processor for a container in
+// attach mode to adopt later. Only firmware the kernel attached to can be detached.
+func Detach(host Host, containerID string) error {
+	state, err := oci.ReadState(containerID)
+	if err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+	if state.Status != specs.StateRunning {
+		return fmt.Errorf("cannot detach container %s, it is %s", containerID, state.Status)
+	}
+	processor, err := host.Backend.Open(state.Annotations[oci.StateDriverPath])
+	if err != nil {
+		return fmt.Errorf("failed to open remote processor: %w", err)
+	}
+	processorState, err := processor.State()
+	if err != nil {
+		return fmt.Errorf("failed to get remoteproc state: %w", err)
+	}
+	if processorState != remoteproc.StateAttached {
+		return fmt.Errorf("cannot detach container %s, processor is %s: only firmware the kernel attached to can be detached", containerID, processorState)
+	}
+
+	// A proxy failing to detach keeps the firmware running attached, and the container with it.
+	if err := proxy.DetachFirmware(host.Proxy, containerID, state.Pid); err != nil {
+		return fmt.Errorf("failed to detach firmware: %w", err)
+	}
+	if err := host.Proxy.AwaitExit(state.Pid, detachTimeout); err != nil {
+		return fmt.Errorf("firmware wasn't detached: %w", err)
+	}
+
+	state.Status = specs.StateStopped
+	state.Annotations[oci.OptionalStateDetachTime] = time.Now().UTC().Format(time.RFC3339)
+	if err := oci.WriteState(state); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package runtime_test
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc/remoteproctest"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetach(t *testing.T) {
+	t.Run("stops the container, leaving the firmware for a later container to attach to", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m4")
+		processor.ForceState(remoteproc.StateDetached)
+		first, second := testID(t)+"-1", testID(t)+"-2"
+		attachFirmware(t, host, first, processor)
+
+		require.NoError(t, runtime.Detach(host, first))
+
+		assertStatus(t, first, specs.StateStopped)
+		assertProcessorState(t, processor, remoteproc.StateDetached)
+		assert.Equal(t, 0, processor.StopCount())
+		state, err := runtime.State(first)
+		require.NoError(t, err)
+		assert.Contains(t, state.Annotations, oci.OptionalStateDetachTime)
+		require.NoError(t, runtime.Delete(logger(), host, first, false))
+
+		attachFirmware(t, host, second, processor)
+	})
+
+	t.Run("kill with SIGUSR2 detaches the firmware", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m4")
+		processor.ForceState(remoteproc.StateAttached)
+		containerID := testID(t)
+		attachFirmware(t, host, containerID, processor)
+
+		require.NoError(t, runtime.Kill(host, containerID, syscall.SIGUSR2))
+
+		assertStatus(t, containerID, specs.StateStopped)
+		assertProcessorState(t, processor, remoteproc.StateDetached)
+	})
+
+	t.Run("refuses firmware the kernel booted", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m4")
+		containerID := testID(t)
+		runFirmware(t, host, containerID, processor, map[string]string{oci.SpecName: "m4"})
+
+		err := runtime.Detach(host, containerID)
+
+		assert.ErrorContains(t, err, "processor is running: only firmware the kernel attached to can be detached")
+		assertStatus(t, containerID, specs.StateRunning)
+		assertProcessorState(t, processor, remoteproc.StateRunning)
+	})
+
+	t.Run("refuses a container that isn't running", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m4")
+		processor.ForceState(remoteproc.StateAttached)
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:   "m4",
+			oci.SpecAttach: "true",
+		}), runtime.CreateOptions{}))
+
+		err := runtime.Detach(host, containerID)
+
+		assert.ErrorContains(t, err, "it is created")
+	})
+}
+
+// attachFirmware creates and starts a container attaching to the firmware running on processor.
+func attachFirmware(t *testing.T, host runtime.Host, containerID string, processor *remoteproctest.Processor) {
+	t.Helper()
+	require.NoError(t, runtime.Create(logger(), host, containerID, generateBundleWithAnnotations(t, map[string]string{
+		oci.SpecName:   processor.Name(),
+		oci.SpecAttach: "true",
+	}), runtime.CreateOptions{}))
+	require.NoError(t, runtime.Start(logger(), host, containerID))
+	assertProcessorState(t, processor, remoteproc.StateAttached)
+}
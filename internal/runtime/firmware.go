@@ -0,0 +1,56 @@
+package runtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+func firmwareRequirementsFromAnnotations(annotations map[string]string) (remoteproc.FirmwareRequirements, error) {
+	requirements := remoteproc.FirmwareRequirements{Arch: annotations[oci.SpecArch]}
+	switch resourceTable := annotations[oci.SpecResourceTable]; resourceTable {
+	case "", "optional":
+	case "required":
+		requirements.RequireResourceTable = true
+	default:
+		return remoteproc.FirmwareRequirements{}, fmt.Errorf("invalid %s %q: must be required or optional", oci.SpecResourceTable, resourceTable)
+	}
+	return requirements, nil
+}
+
+// resourceTableAnnotations summarises the deployed resource table, one annotation per entry type.
+func resourceTableAnnotations(table *remoteproc.ResourceTable) map[string]string {
+	if table == nil {
+		return nil
+	}
+	var carveouts, devMems, traces, vdevs []string
+	for _, entry := range table.Entries {
+		switch {
+		case entry.Carveout != nil:
+			carveouts = append(carveouts, entry.Carveout.String())
+		case entry.DevMem != nil:
+			devMems = append(devMems, entry.DevMem.String())
+		case entry.Trace != nil:
+			traces = append(traces, entry.Trace.String())
+		case entry.Vdev != nil:
+			vdevs = append(vdevs, entry.Vdev.String())
+		}
+	}
+	annotations := map[string]string{
+		oci.OptionalStateResourceTableVersion: strconv.FormatUint(uint64(table.Version), 10),
+	}
+	for key, values := range map[string][]string{
+		oci.OptionalStateResourceTableCarveouts: carveouts,
+		oci.OptionalStateResourceTableDevMems:   devMems,
+		oci.OptionalStateResourceTableTraces:    traces,
+		oci.OptionalStateResourceTableVdevs:     vdevs,
+	} {
+		if len(values) > 0 {
+			annotations[key] = strings.Join(values, "; ")
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,86 @@
+package runtime
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Health reports the outcome of the last health check of a running container's firmware, one
+// of the oci.Health values, and why it failed if it did.
+func Health(containerID string) (string, string, error) {
+	state, err := State(containerID)
+	if err != nil {
+		return "", "", err
+	}
+	if state.Status != specs.StateRunning {
+		return "", "", fmt.Errorf("container %s is %s, only running firmware is health checked", containerID, state.Status)
+	}
+	health, ok := state.Annotations[oci.OptionalStateHealth]
+	if !ok {
+		return "", "", fmt.Errorf("container %s has no health check, configure one with the remoteproc.health annotations", containerID)
+	}
+	return health, state.Annotations[oci.OptionalStateHealthFailure], nil
+}
+
+// healthCheckFromAnnotations reads the health check configuration, returning nil when none of
+// its annotations is set.
+func healthCheckFromAnnotations(annotations map[string]string) (*proxy.HealthCheck, error) {
+	configured := false
+	for _, key := range []string{oci.SpecHealthInterval, oci.SpecHealthRPMsgChannel, oci.SpecHealthPing, oci.SpecHealthTimeout, oci.SpecHealthHeartbeat} {
+		if _, ok := annotations[key]; ok {
+			configured = true
+		}
+	}
+	if !configured {
+		return nil, nil
+	}
+	check := &proxy.HealthCheck{Interval: proxy.DefaultHealthInterval}
+	if raw, ok := annotations[oci.SpecHealthInterval]; ok {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration, e.g. 10s", oci.SpecHealthInterval, raw)
+		}
+		check.Interval = interval
+	}
+	if heartbeat, ok := annotations[oci.SpecHealthHeartbeat]; ok {
+		if heartbeat == "" {
+			return nil, fmt.Errorf("invalid %s: must not be empty", oci.SpecHealthHeartbeat)
+		}
+		check.Heartbeat = heartbeat
+	}
+
+	channel, ok := annotations[oci.SpecHealthRPMsgChannel]
+	if !ok {
+		for _, key := range []string{oci.SpecHealthPing, oci.SpecHealthTimeout} {
+			if _, ok := annotations[key]; ok {
+				return nil, fmt.Errorf("%s requires %s", key, oci.SpecHealthRPMsgChannel)
+			}
+		}
+		return check, nil
+	}
+	if channel == "" {
+		return nil, fmt.Errorf("invalid %s: must not be empty", oci.SpecHealthRPMsgChannel)
+	}
+	check.RPMsgChannel = channel
+	check.Ping = []byte(proxy.DefaultHealthPing)
+	check.Timeout = proxy.DefaultHealthTimeout
+	if raw, ok := annotations[oci.SpecHealthPing]; ok {
+		ping, err := proxy.ParseMessage(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %w", oci.SpecHealthPing, err)
+		}
+		check.Ping = ping
+	}
+	if raw, ok := annotations[oci.SpecHealthTimeout]; ok {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration, e.g. 5s", oci.SpecHealthTimeout, raw)
+		}
+		check.Timeout = timeout
+	}
+	return check, nil
+}
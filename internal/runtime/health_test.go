@@ -0,0 +1,70 @@
+package runtime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	t.Run("reports the outcome of the firmware's health check", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.AddRPMsgDevice(remoteproc.RPMsgDevice{Channel: "rpmsg-health", DeviceNode: "/dev/ttyRPMSG2"})
+		containerID := testID(t)
+		runFirmware(t, host, containerID, processor, map[string]string{
+			oci.SpecName:               "m33",
+			oci.SpecHealthInterval:     "20ms",
+			oci.SpecHealthRPMsgChannel: "rpmsg-health",
+			oci.SpecHealthTimeout:      "50ms",
+		})
+
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			health, failure, err := runtime.Health(containerID)
+			if assert.NoError(c, err) {
+				assert.Equal(c, oci.HealthUnhealthy, health)
+				assert.Equal(c, "no reply to ping on rpmsg-health within 50ms", failure)
+			}
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("errors for firmware without a health check", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		runFirmware(t, host, containerID, processor, map[string]string{oci.SpecName: "m33"})
+
+		_, _, err := runtime.Health(containerID)
+
+		assert.ErrorContains(t, err, "has no health check")
+	})
+
+	t.Run("create rejects a ping without a channel", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:       "m33",
+			oci.SpecHealthPing: "ping",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "remoteproc.health.ping requires remoteproc.health.rpmsg-channel")
+	})
+
+	t.Run("create rejects an invalid interval", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:           "m33",
+			oci.SpecHealthInterval: "0s",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, `invalid remoteproc.health.interval "0s"`)
+	})
+}
@@ -0,0 +1,22 @@
+package runtime
+
+import (
+	"log/slog"
+
+	"github.com/arm/remoteproc-runtime/internal/proxy"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+// Host bundles the host facilities the container lifecycle is driven through.
+type Host struct {
+	Backend remoteproc.Backend
+	Proxy   proxy.Launcher
+}
+
+// NewHost returns a Host controlling real processors through sysfs and proxy child processes.
+func NewHost(logger *slog.Logger) Host {
+	return Host{
+		Backend: remoteproc.NewSysfsBackend(logger),
+		Proxy:   proxy.ExecLauncher{},
+	}
+}
@@ -1,23 +1,51 @@
 package runtime
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"syscall"
+	"time"
 
 	"github.com/arm/remoteproc-runtime/internal/oci"
 	"github.com/arm/remoteproc-runtime/internal/proxy"
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
-func Kill(containerID string, signal syscall.Signal) error {
+// shutdownMargin is how long the proxy gets on top of the graceful shutdown timeout to stop
+// the processor and exit.
+const shutdownMargin = 5 * time.Second
+
+// Kill signals the container's proxy. SIGTERM and SIGINT are sent as a stop request over the
+// proxy's control socket, waiting for the proxy to finish the graceful shutdown handshake, if
+// any, and stop the processor, so the container is only reported stopped once the firmware
+// really is. SIGUSR2 detaches the firmware instead, see Detach. proxy.IgnoredSignals are
+// delivered without effect, leaving the container as it is.
+func Kill(host Host, containerID string, signal syscall.Signal) error {
+	if signal == syscall.SIGUSR2 {
+		return Detach(host, containerID)
+	}
+	ignored := slices.Contains(proxy.IgnoredSignals, os.Signal(signal))
+	if !ignored && signal != syscall.SIGKILL && signal != syscall.SIGTERM && signal != syscall.SIGINT {
+		return fmt.Errorf("unsupported signal %s", signal)
+	}
 	state, err := oci.ReadState(containerID)
 	if err != nil {
 		return fmt.Errorf("failed to read state: %w", err)
 	}
 
+	if ignored {
+		if state.Pid > 0 {
+			if err := host.Proxy.Signal(state.Pid, signal); err != nil {
+				return fmt.Errorf("failed to send signal: %w", err)
+			}
+		}
+		return nil
+	}
 	if state.Pid > 0 {
-		if err := proxy.SendSignal(state.Pid, signal); err != nil {
-			return fmt.Errorf("failed to send signal: %w", err)
+		if err := stopProxy(host, state, signal); err != nil {
+			return err
 		}
 	}
 
@@ -27,3 +55,44 @@ func Kill(containerID string, signal syscall.Signal) error {
 	}
 	return nil
 }
+
+// stopProxy has the container's proxy stop the firmware, or kills it with SIGKILL, returning
+// once it exited. SIGTERM and SIGINT become a stop request if the proxy has a control socket;
+// otherwise the proxy is signalled, and given the graceful shutdown timeout, if any, to exit.
+func stopProxy(host Host, state *specs.State, signal syscall.Signal) error {
+	var timeout time.Duration
+	if signal != syscall.SIGKILL {
+		if rawTimeout, graceful := state.Annotations[oci.SpecShutdownTimeout]; graceful {
+			var err error
+			timeout, err = time.ParseDuration(rawTimeout)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q in state: %w", oci.SpecShutdownTimeout, rawTimeout, err)
+			}
+		}
+		_, err := proxy.SendControl(state.ID, proxy.ControlStop, timeout+shutdownMargin)
+		switch {
+		case err == nil:
+			// The proxy exits right after replying.
+			if err := host.Proxy.AwaitExit(state.Pid, shutdownMargin); err != nil {
+				return fmt.Errorf("proxy didn't exit: %w", err)
+			}
+			return nil
+		case errors.Is(err, proxy.ErrProxyExited):
+			// Nothing is left to stop.
+			return nil
+		case !errors.Is(err, proxy.ErrNoControlSocket):
+			return fmt.Errorf("failed to stop firmware: %w", err)
+		}
+	}
+
+	if err := host.Proxy.Signal(state.Pid, signal); err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return nil
+		}
+		return fmt.Errorf("failed to send signal: %w", err)
+	}
+	if err := host.Proxy.AwaitExit(state.Pid, timeout+shutdownMargin); err != nil {
+		return fmt.Errorf("proxy didn't exit: %w", err)
+	}
+	return nil
+}
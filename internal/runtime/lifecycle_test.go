@@ -0,0 +1,371 @@
+package runtime_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy/proxytest"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc/remoteproctest"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// The state directory is resolved once per process, so all tests share one.
+	runtimeDir, err := os.MkdirTemp("", "remoteproc-runtime-test")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create runtime dir: %v\n", err)
+		os.Exit(1)
+	}
+	_ = os.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	code := m.Run()
+	_ = os.RemoveAll(runtimeDir)
+	os.Exit(code)
+}
+
+func TestLifecycle(t *testing.T) {
+	t.Run("create, start, kill and delete drive the processor", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		bundlePath := generateBundle(t, "m33")
+
+		require.NoError(t, runtime.Create(logger(), host, containerID, bundlePath, runtime.CreateOptions{}))
+		assertStatus(t, containerID, specs.StateCreated)
+		assertProcessorState(t, processor, remoteproc.StateOffline)
+
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		assertStatus(t, containerID, specs.StateRunning)
+		assertProcessorState(t, processor, remoteproc.StateRunning)
+		assert.Regexp(t, `^firmware_.+\.elf$`, processor.Firmware())
+
+		require.NoError(t, runtime.Kill(host, containerID, syscall.SIGTERM))
+		assertStatus(t, containerID, specs.StateStopped)
+		assertProcessorState(t, processor, remoteproc.StateOffline)
+
+		require.NoError(t, runtime.Delete(logger(), host, containerID, false))
+		_, err := runtime.State(containerID)
+		assert.Error(t, err)
+		assert.NoFileExists(t, filepath.Join(backend.FirmwareDir(), processor.Firmware()))
+	})
+
+	t.Run("create forgets what the proxy of an earlier container by the same ID reported", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		containerID := testID(t)
+		stateDir, err := oci.StateDir(containerID)
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(stateDir, 0o755))
+		require.NoError(t, oci.WriteProxyStatus(containerID, oci.ProxyStatus{Annotations: map[string]string{oci.OptionalStateRestartCount: "3"}}))
+
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+
+		state, err := runtime.State(containerID)
+		require.NoError(t, err)
+		assert.NotContains(t, state.Annotations, oci.OptionalStateRestartCount)
+	})
+
+	t.Run("create rejects container IDs that can't name a state directory of their own", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		bundlePath := generateBundle(t, "m33")
+
+		for _, containerID := range []string{"", ".runtime", "..", "a/b"} {
+			err := runtime.Create(logger(), host, containerID, bundlePath, runtime.CreateOptions{})
+
+			assert.ErrorContains(t, err, "invalid container ID", containerID)
+		}
+	})
+
+	t.Run("create errors when requested processor doesn't exist", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("some-processor")
+		bundlePath := generateBundle(t, "other-processor")
+
+		err := runtime.Create(logger(), host, testID(t), bundlePath, runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "remote processor other-processor does not exist, available remote processors: some-processor")
+	})
+
+	t.Run("create rejects firmware built for another architecture", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		containerID := testID(t)
+		bundlePath := generateBundleWithAnnotations(t, map[string]string{oci.SpecName: "m33", oci.SpecArch: "riscv32"})
+
+		err := runtime.Create(logger(), host, containerID, bundlePath, runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "invalid firmware")
+		assert.ErrorContains(t, err, "is built for EM_ARM, expected EM_RISCV for architecture riscv32")
+		_, err = runtime.State(containerID)
+		assert.Error(t, err)
+	})
+
+	t.Run("create rejects an invalid resource table requirement", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		bundlePath := generateBundleWithAnnotations(t, map[string]string{oci.SpecName: "m33", oci.SpecResourceTable: "sometimes"})
+
+		err := runtime.Create(logger(), host, testID(t), bundlePath, runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, `invalid remoteproc.resource-table "sometimes": must be required or optional`)
+	})
+
+	t.Run("create records the firmware's resource table in the state", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		containerID := testID(t)
+		bundlePath := generateBundle(t, "m33")
+		image := remoteproctest.NewFirmware()
+		image.ResourceTable = remoteproctest.NewResourceTable().
+			Carveout("vdev0buffer", remoteproc.AddressAny, remoteproc.AddressAny, 0x40000).
+			Trace("trace0", 0x20010000, 0x1000).
+			Bytes()
+		require.NoError(t, os.WriteFile(filepath.Join(bundlePath, "rootfs", "firmware.elf"), image.Bytes(), 0o644))
+
+		require.NoError(t, runtime.Create(logger(), host, containerID, bundlePath, runtime.CreateOptions{}))
+
+		state, err := runtime.State(containerID)
+		require.NoError(t, err)
+		assert.Equal(t, "1", state.Annotations[oci.OptionalStateResourceTableVersion])
+		assert.Equal(t, "vdev0buffer da=any pa=any len=0x40000", state.Annotations[oci.OptionalStateResourceTableCarveouts])
+		assert.Equal(t, "trace0 da=0x20010000 len=0x1000", state.Annotations[oci.OptionalStateResourceTableTraces])
+		assert.NotContains(t, state.Annotations, oci.OptionalStateResourceTableVdevs)
+	})
+
+	t.Run("create writes proxy pid to pid file", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		containerID := testID(t)
+		bundlePath := generateBundle(t, "m33")
+		pidFile := filepath.Join(t.TempDir(), "container.pid")
+
+		require.NoError(t, runtime.Create(logger(), host, containerID, bundlePath, runtime.CreateOptions{PidFile: pidFile}))
+
+		state, err := runtime.State(containerID)
+		require.NoError(t, err)
+		content, err := os.ReadFile(pidFile)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%d", state.Pid), string(content))
+	})
+
+	t.Run("proxy exits when the processor crashes", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		assertProcessorState(t, processor, remoteproc.StateRunning)
+
+		processor.Crash()
+
+		state, err := runtime.State(containerID)
+		require.NoError(t, err)
+		assert.ErrorContains(t, launcher.Wait(state.Pid), "remoteproc not running, current state: crashed")
+	})
+
+	t.Run("start fails with the reason the processor refused to start", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.RefuseStart(remoteproctest.ErrRefused)
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+
+		err := runtime.Start(logger(), host, containerID)
+
+		assert.ErrorContains(t, err, remoteproctest.ErrRefused.Error())
+		assertStatus(t, containerID, specs.StateStopped)
+		state, err := runtime.State(containerID)
+		require.NoError(t, err)
+		assert.ErrorIs(t, launcher.Wait(state.Pid), remoteproctest.ErrRefused)
+		assertProcessorState(t, processor, remoteproc.StateOffline)
+	})
+
+	t.Run("firmware trace output is written to the proxy's stdout", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.AddTraceBuffer("trace0", 256)
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		assertProcessorState(t, processor, remoteproc.StateRunning)
+
+		processor.WriteTrace("trace0", "hello from m33\n")
+
+		state, err := runtime.State(containerID)
+		require.NoError(t, err)
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.Equal(c, "hello from m33\n", launcher.Stdout(state.Pid))
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("slow starting processor eventually runs", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.SetStartDelay(100 * time.Millisecond)
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+
+		assertProcessorState(t, processor, remoteproc.StateRunning)
+	})
+
+	t.Run("SIGKILL leaves the processor running", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		assertProcessorState(t, processor, remoteproc.StateRunning)
+
+		require.NoError(t, runtime.Kill(host, containerID, syscall.SIGKILL))
+
+		state, err := runtime.State(containerID)
+		require.NoError(t, err)
+		assert.True(t, launcher.Exited(state.Pid))
+		assertProcessorState(t, processor, remoteproc.StateRunning)
+	})
+
+	t.Run("signals the proxy ignores leave the container running", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		assertProcessorState(t, processor, remoteproc.StateRunning)
+
+		require.NoError(t, runtime.Kill(host, containerID, syscall.SIGHUP))
+
+		assertStatus(t, containerID, specs.StateRunning)
+		state, err := runtime.State(containerID)
+		require.NoError(t, err)
+		assert.False(t, launcher.Exited(state.Pid))
+		assertProcessorState(t, processor, remoteproc.StateRunning)
+		assert.Equal(t, 0, processor.StopCount())
+	})
+
+	t.Run("kill refuses signals which would take the proxy down unannounced", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+
+		err := runtime.Kill(host, containerID, syscall.SIGSEGV)
+
+		assert.EqualError(t, err, "unsupported signal segmentation fault")
+		assertStatus(t, containerID, specs.StateRunning)
+		state, err := runtime.State(containerID)
+		require.NoError(t, err)
+		assert.False(t, launcher.Exited(state.Pid))
+	})
+
+	t.Run("delete refuses running container unless forced", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+
+		err := runtime.Delete(logger(), host, containerID, false)
+		assert.ErrorContains(t, err, "cannot delete running container")
+
+		require.NoError(t, runtime.Delete(logger(), host, containerID, true))
+		_, err = runtime.State(containerID)
+		assert.Error(t, err)
+		assert.Equal(t, 0, processor.StopCount(), "SIGKILL should not stop the processor")
+	})
+}
+
+func newHost(t *testing.T) (runtime.Host, *remoteproctest.Backend, *proxytest.Launcher) {
+	t.Helper()
+	backend := remoteproctest.NewBackend(t.TempDir())
+	launcher := proxytest.NewLauncher(backend)
+	return runtime.Host{Backend: backend, Proxy: launcher}, backend, launcher
+}
+
+func logger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+var (
+	testIDs  sync.Map
+	testRuns atomic.Int64
+)
+
+// testID names the test's container after the test, numbered so that reruns with -count don't
+// come across the state of earlier runs.
+func testID(t *testing.T) string {
+	name := strings.NewReplacer("/", "-", " ", "-", ",", "").Replace(t.Name())
+	id, _ := testIDs.LoadOrStore(t, fmt.Sprintf("%s-%d", name, testRuns.Add(1)))
+	return id.(string)
+}
+
+func generateBundle(t *testing.T, processorName string) string {
+	t.Helper()
+	return generateBundleWithAnnotations(t, map[string]string{oci.SpecName: processorName})
+}
+
+func generateBundleWithAnnotations(t *testing.T, annotations map[string]string) string {
+	t.Helper()
+	return writeBundle(t, &specs.Spec{
+		Version:     specs.Version,
+		Process:     &specs.Process{Args: []string{"firmware.elf"}},
+		Root:        &specs.Root{Path: "rootfs"},
+		Annotations: annotations,
+	})
+}
+
+// writeBundle writes spec along with a firmware.elf in its rootfs.
+func writeBundle(t *testing.T, spec *specs.Spec) string {
+	t.Helper()
+	bundlePath := t.TempDir()
+	rootfs := filepath.Join(bundlePath, "rootfs")
+	require.NoError(t, os.MkdirAll(rootfs, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "firmware.elf"), remoteproctest.NewFirmware().Bytes(), 0o644))
+
+	configData, err := json.MarshalIndent(spec, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(bundlePath, "config.json"), configData, 0o644))
+	return bundlePath
+}
+
+func assertStatus(t *testing.T, containerID string, want specs.ContainerState) {
+	t.Helper()
+	state, err := runtime.State(containerID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, want, state.Status)
+	}
+}
+
+func assertProcessorState(t *testing.T, processor remoteproc.Processor, want remoteproc.State) {
+	t.Helper()
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		got, err := processor.State()
+		if assert.NoError(c, err) {
+			assert.Equal(c, want, got)
+		}
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func waitForProxy(t *testing.T, launcher *proxytest.Launcher, containerID string) {
+	t.Helper()
+	state, err := runtime.State(containerID)
+	require.NoError(t, err)
+	_ = launcher.Wait(state.Pid)
+}
@@ -0,0 +1,69 @@
+package runtime
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// ProcessorInfo describes a remote processor along with the container owning it, if any.
+type ProcessorInfo struct {
+	remoteproc.Info
+	Owner *Owner `json:"owner,omitempty"`
+}
+
+type Owner struct {
+	ContainerID string               `json:"containerId"`
+	Status      specs.ContainerState `json:"status"`
+}
+
+// ListProcessors describes the host's processors. Processors which can't be described, e.g.
+// because they went away while being listed, are left out with a warning.
+func ListProcessors(logger *slog.Logger, host Host) ([]ProcessorInfo, error) {
+	processors, err := host.Backend.Processors()
+	if err != nil {
+		return nil, err
+	}
+	owners, err := processorOwners()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]ProcessorInfo, 0, len(processors))
+	for _, processor := range processors {
+		info, err := processor.Info()
+		if err != nil {
+			logger.Warn("failed to describe remote processor", "name", processor.Name(), "device", processor.DevicePath(), "error", err)
+			continue
+		}
+		processorInfo := ProcessorInfo{Info: info}
+		if owner, ok := owners[processor.DevicePath()]; ok {
+			processorInfo.Owner = &owner
+		}
+		infos = append(infos, processorInfo)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Index < infos[j].Index })
+	return infos, nil
+}
+
+// processorOwners maps device paths to the containers created against them. A container that
+// is still active takes precedence over stopped ones awaiting deletion.
+func processorOwners() (map[string]Owner, error) {
+	states, err := oci.ListStates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	owners := map[string]Owner{}
+	for _, state := range states {
+		devicePath := state.Annotations[oci.StateDriverPath]
+		if existing, ok := owners[devicePath]; ok && existing.Status != specs.StateStopped {
+			continue
+		}
+		owners[devicePath] = Owner{ContainerID: state.ID, Status: state.Status}
+	}
+	return owners, nil
+}
@@ -0,0 +1,53 @@
+package runtime_test
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListProcessors(t *testing.T) {
+	t.Run("reports every processor with its attributes and owning container", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("idle-dsp")
+		m33 := backend.AddProcessor("busy-m33")
+		m33.SetParent("4c000000.m33", "/soc/m33@4c000000")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "busy-m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		assertProcessorState(t, m33, remoteproc.StateRunning)
+
+		got, err := runtime.ListProcessors(logger(), host)
+
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, 0, got[0].Index)
+		assert.Equal(t, "idle-dsp", got[0].Name)
+		assert.Equal(t, remoteproc.StateOffline, got[0].State)
+		assert.Nil(t, got[0].Owner)
+		assert.Equal(t, 1, got[1].Index)
+		assert.Equal(t, "busy-m33", got[1].Name)
+		assert.Equal(t, remoteproc.StateRunning, got[1].State)
+		assert.Equal(t, m33.Firmware(), got[1].Firmware)
+		assert.Equal(t, "4c000000.m33", got[1].ParentDevice)
+		assert.Equal(t, "/soc/m33@4c000000", got[1].DeviceTreeNode)
+		assert.Equal(t, &runtime.Owner{ContainerID: containerID, Status: specs.StateRunning}, got[1].Owner)
+	})
+
+	t.Run("leaves out processors which can't be described", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("gone").FailInfo(fs.ErrNotExist)
+		backend.AddProcessor("m33")
+
+		got, err := runtime.ListProcessors(logger(), host)
+
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "m33", got[0].Name)
+	})
+}
@@ -0,0 +1,79 @@
+package runtime_test
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessorOwnership(t *testing.T) {
+	t.Run("create refuses a processor owned by another container", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		owner := testID(t) + "-owner"
+		require.NoError(t, runtime.Create(logger(), host, owner, generateBundle(t, "m33"), runtime.CreateOptions{}))
+
+		err := runtime.Create(logger(), host, testID(t), generateBundle(t, "m33"), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "processor m33 is owned by container "+owner)
+		_, err = runtime.State(testID(t))
+		assert.Error(t, err)
+	})
+
+	t.Run("ownership is kept while the container is running", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		owner := testID(t) + "-owner"
+		require.NoError(t, runtime.Create(logger(), host, owner, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, owner))
+
+		err := runtime.Create(logger(), host, testID(t), generateBundle(t, "m33"), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "processor m33 is owned by container "+owner)
+	})
+
+	t.Run("delete releases the processor", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		backend.AddProcessor("m33")
+		previous := testID(t) + "-previous"
+		require.NoError(t, runtime.Create(logger(), host, previous, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Kill(host, previous, syscall.SIGTERM))
+		waitForProxy(t, launcher, previous)
+		require.NoError(t, runtime.Delete(logger(), host, previous, false))
+
+		assert.NoError(t, runtime.Create(logger(), host, testID(t), generateBundle(t, "m33"), runtime.CreateOptions{}))
+	})
+
+	t.Run("lock left behind by a dead proxy is reclaimed", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		processor := backend.AddProcessor("m33")
+		dead := testID(t) + "-dead"
+		require.NoError(t, runtime.Create(logger(), host, dead, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Kill(host, dead, syscall.SIGKILL))
+		waitForProxy(t, launcher, dead)
+		containerID := testID(t)
+
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+
+		assertDriverPath(t, containerID, processor.DevicePath())
+	})
+
+	t.Run("deleting the previous owner keeps the new owner's claim", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		backend.AddProcessor("m33")
+		dead := testID(t) + "-dead"
+		require.NoError(t, runtime.Create(logger(), host, dead, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Kill(host, dead, syscall.SIGKILL))
+		waitForProxy(t, launcher, dead)
+		owner := testID(t) + "-owner"
+		require.NoError(t, runtime.Create(logger(), host, owner, generateBundle(t, "m33"), runtime.CreateOptions{}))
+
+		require.NoError(t, runtime.Delete(logger(), host, dead, false))
+
+		err := runtime.Create(logger(), host, testID(t), generateBundle(t, "m33"), runtime.CreateOptions{})
+		assert.ErrorContains(t, err, "processor m33 is owned by container "+owner)
+	})
+}
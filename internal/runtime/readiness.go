@@ -0,0 +1,156 @@
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+const (
+	defaultReadyTimeout = 30 * time.Second
+	readyPollInterval   = 50 * time.Millisecond
+)
+
+// readyAnnotations are the annotations configuring readiness, copied from the spec to the state
+// so start finds them.
+var readyAnnotations = []string{oci.SpecReadyRunningFor, oci.SpecReadyRPMsgChannel, oci.SpecReadyTrace, oci.SpecReadyTimeout}
+
+// readiness holds the conditions the firmware must meet, all of them, before start returns.
+type readiness struct {
+	runningFor   time.Duration
+	rpmsgChannel string
+	trace        string
+	timeout      time.Duration
+}
+
+// readinessFromAnnotations reads the readiness conditions, returning nil when there are none.
+func readinessFromAnnotations(annotations map[string]string) (*readiness, error) {
+	r := &readiness{timeout: defaultReadyTimeout}
+	configured := false
+	if raw, ok := annotations[oci.SpecReadyRunningFor]; ok {
+		runningFor, err := time.ParseDuration(raw)
+		if err != nil || runningFor < 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a duration, e.g. 2s", oci.SpecReadyRunningFor, raw)
+		}
+		r.runningFor = runningFor
+		configured = true
+	}
+	if channel, ok := annotations[oci.SpecReadyRPMsgChannel]; ok {
+		if channel == "" {
+			return nil, fmt.Errorf("invalid %s: must not be empty", oci.SpecReadyRPMsgChannel)
+		}
+		r.rpmsgChannel = channel
+		configured = true
+	}
+	if trace, ok := annotations[oci.SpecReadyTrace]; ok {
+		if trace == "" {
+			return nil, fmt.Errorf("invalid %s: must not be empty", oci.SpecReadyTrace)
+		}
+		r.trace = trace
+		configured = true
+	}
+	if raw, ok := annotations[oci.SpecReadyTimeout]; ok {
+		if !configured {
+			return nil, fmt.Errorf("%s requires one of %s", oci.SpecReadyTimeout, strings.Join(readyAnnotations[:3], ", "))
+		}
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration, e.g. 30s", oci.SpecReadyTimeout, raw)
+		}
+		r.timeout = timeout
+	}
+	if !configured {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// await polls the processor until the firmware is ready. It fails once the timeout expires, or
+// as soon as the proxy exits, which it does when the processor fails to start or stops running.
+func (r *readiness) await(host Host, processor remoteproc.Processor, pid int) error {
+	deadline := time.Now().Add(r.timeout)
+	var runningSince time.Time
+	for {
+		state, err := processor.State()
+		if err != nil {
+			return fmt.Errorf("failed to get remoteproc state: %w", err)
+		}
+		if !state.Running() {
+			runningSince = time.Time{}
+		} else if runningSince.IsZero() {
+			runningSince = time.Now()
+		}
+		var unmet []string
+		if state.Running() {
+			unmet, err = r.unmet(processor, runningSince)
+			if err != nil {
+				return err
+			}
+			if len(unmet) == 0 {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			if !state.Running() {
+				unmet = []string{"processor is " + string(state)}
+			}
+			return fmt.Errorf("firmware not ready after %s: %s", r.timeout, strings.Join(unmet, ", "))
+		}
+		if err := host.Proxy.AwaitExit(pid, readyPollInterval); err == nil {
+			if state, err := processor.State(); err == nil {
+				return fmt.Errorf("firmware stopped before becoming ready, processor is %s", state)
+			}
+			return fmt.Errorf("firmware stopped before becoming ready")
+		}
+	}
+}
+
+// unmet describes the conditions a running firmware doesn't meet yet.
+func (r *readiness) unmet(processor remoteproc.Processor, runningSince time.Time) ([]string, error) {
+	var unmet []string
+	if time.Since(runningSince) < r.runningFor {
+		unmet = append(unmet, fmt.Sprintf("not running for %s yet", r.runningFor))
+	}
+	if r.rpmsgChannel != "" {
+		devices, err := processor.RPMsgDevices()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list RPMsg devices: %w", err)
+		}
+		if !slices.ContainsFunc(devices, func(d remoteproc.RPMsgDevice) bool { return d.Channel == r.rpmsgChannel }) {
+			unmet = append(unmet, fmt.Sprintf("RPMsg channel %s not announced", r.rpmsgChannel))
+		}
+	}
+	if r.trace != "" {
+		found, err := tracesContain(processor, r.trace)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			unmet = append(unmet, fmt.Sprintf("%q not traced", r.trace))
+		}
+	}
+	return unmet, nil
+}
+
+func tracesContain(processor remoteproc.Processor, text string) (bool, error) {
+	names, err := processor.TraceBuffers()
+	if err != nil {
+		return false, fmt.Errorf("failed to list trace buffers: %w", err)
+	}
+	for _, name := range names {
+		content, err := processor.ReadTrace(name)
+		if err != nil {
+			// The buffer goes away if the processor stops meanwhile.
+			continue
+		}
+		if bytes.Contains(content, []byte(text)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,157 @@
+package runtime_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc/remoteproctest"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	t.Run("start waits for the sentinel in the trace buffer", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.AddTraceBuffer("trace0", 256)
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:       "m33",
+			oci.SpecReadyTrace: "boot done",
+		}), runtime.CreateOptions{}))
+		traced := make(chan struct{})
+		go func() {
+			defer close(traced)
+			assertProcessorState(t, processor, remoteproc.StateRunning)
+			time.Sleep(100 * time.Millisecond)
+			processor.WriteTrace("trace0", "booting\nboot done\n")
+		}()
+
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+
+		select {
+		case <-traced:
+		default:
+			t.Fatal("start returned before the firmware traced its sentinel")
+		}
+		assertStatus(t, containerID, specs.StateRunning)
+	})
+
+	t.Run("start waits until the processor has been running long enough", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:            "m33",
+			oci.SpecReadyRunningFor: "200ms",
+		}), runtime.CreateOptions{}))
+
+		start := time.Now()
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+
+		assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+		assertStatus(t, containerID, specs.StateRunning)
+	})
+
+	t.Run("start waits for the RPMsg channel to be announced", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:              "m33",
+			oci.SpecReadyRPMsgChannel: "rpmsg-tty",
+		}), runtime.CreateOptions{}))
+		announced := make(chan struct{})
+		go func() {
+			defer close(announced)
+			assertProcessorState(t, processor, remoteproc.StateRunning)
+			time.Sleep(100 * time.Millisecond)
+			processor.AddRPMsgDevice(ttyChannel)
+		}()
+
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+
+		select {
+		case <-announced:
+		default:
+			t.Fatal("start returned before the firmware announced its RPMsg channel")
+		}
+		assertStatus(t, containerID, specs.StateRunning)
+	})
+
+	t.Run("start fails and stops firmware that isn't ready in time", func(t *testing.T) {
+		host, backend, launcher := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.AddTraceBuffer("trace0", 256)
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:         "m33",
+			oci.SpecReadyTrace:   "boot done",
+			oci.SpecReadyTimeout: "200ms",
+		}), runtime.CreateOptions{}))
+
+		err := runtime.Start(logger(), host, containerID)
+
+		assert.ErrorContains(t, err, `firmware not ready after 200ms: "boot done" not traced`)
+		assertStatus(t, containerID, specs.StateStopped)
+		assertProcessorState(t, processor, remoteproc.StateOffline)
+		waitForProxy(t, launcher, containerID)
+		assertNoStoredFirmware(t, backend)
+		require.NoError(t, runtime.Delete(logger(), host, containerID, false))
+	})
+
+	t.Run("start fails as soon as the firmware crashes", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:              "m33",
+			oci.SpecReadyRPMsgChannel: "rpmsg-tty",
+		}), runtime.CreateOptions{}))
+		go func() {
+			assertProcessorState(t, processor, remoteproc.StateRunning)
+			processor.Crash()
+		}()
+
+		err := runtime.Start(logger(), host, containerID)
+
+		assert.ErrorContains(t, err, "firmware stopped before becoming ready, processor is crashed")
+		assertStatus(t, containerID, specs.StateStopped)
+	})
+
+	t.Run("create rejects an invalid condition", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:            "m33",
+			oci.SpecReadyRunningFor: "long",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, `invalid remoteproc.ready.running-for "long"`)
+	})
+
+	t.Run("create rejects a timeout without a condition", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:         "m33",
+			oci.SpecReadyTimeout: "10s",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "remoteproc.ready.timeout requires one of")
+	})
+}
+
+func assertNoStoredFirmware(t *testing.T, backend *remoteproctest.Backend) {
+	t.Helper()
+	entries, err := os.ReadDir(backend.FirmwareDir())
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
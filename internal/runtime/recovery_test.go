@@ -0,0 +1,60 @@
+package runtime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecovery(t *testing.T) {
+	t.Run("sets the recovery mode and records why the firmware crashed", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		runFirmware(t, host, containerID, processor, map[string]string{
+			oci.SpecName:     "m33",
+			oci.SpecRecovery: "disabled",
+		})
+		assert.Equal(t, remoteproc.RecoveryDisabled, processor.Recovery())
+
+		processor.LogKernelMessage("remoteproc remoteproc0: crash detected in m33: type watchdog")
+		processor.Crash()
+
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			state, err := runtime.State(containerID)
+			if assert.NoError(c, err) {
+				assert.Equal(c, "watchdog", state.Annotations[oci.OptionalStateCrashType])
+				assert.Equal(c, "crash detected in m33: type watchdog", state.Annotations[oci.OptionalStateCrashReason])
+			}
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("enables recovery for recover-once", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		require.NoError(t, processor.SetRecovery(remoteproc.RecoveryDisabled))
+		runFirmware(t, host, testID(t), processor, map[string]string{
+			oci.SpecName:     "m33",
+			oci.SpecRecovery: "recover-once",
+		})
+
+		assert.Equal(t, remoteproc.RecoveryEnabled, processor.Recovery())
+	})
+
+	t.Run("create rejects unknown modes", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:     "m33",
+			oci.SpecRecovery: "always",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, `invalid remoteproc.recovery "always": must be one of enabled, disabled, recover-once`)
+	})
+}
@@ -0,0 +1,61 @@
+package runtime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartPolicy(t *testing.T) {
+	t.Run("records restarts of crashed firmware in the state", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		containerID := testID(t)
+		runFirmware(t, host, containerID, processor, map[string]string{
+			oci.SpecName:           "m33",
+			oci.SpecRestartPolicy:  "on-failure:1",
+			oci.SpecRestartBackoff: "10ms",
+		})
+
+		processor.Crash()
+
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			state, err := runtime.State(containerID)
+			if assert.NoError(c, err) {
+				assert.Equal(c, "1", state.Annotations[oci.OptionalStateRestartCount])
+				assert.Contains(c, state.Annotations, oci.OptionalStateRestartLastCrash)
+			}
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, 2, processor.StartCount())
+		assertStatus(t, containerID, specs.StateRunning)
+	})
+
+	t.Run("create rejects an invalid policy", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:          "m33",
+			oci.SpecRestartPolicy: "on-failure:0",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "invalid remoteproc.restart annotation")
+	})
+
+	t.Run("create rejects a backoff without a policy", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:           "m33",
+			oci.SpecRestartBackoff: "1s",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "remoteproc.restart.backoff requires remoteproc.restart")
+	})
+}
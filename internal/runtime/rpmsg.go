@@ -0,0 +1,70 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// RPMsgDevices lists the RPMsg channels announced by a running container's firmware.
+func RPMsgDevices(host Host, containerID string) ([]remoteproc.RPMsgDevice, error) {
+	state, err := oci.ReadState(containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	if state.Status != specs.StateRunning {
+		return nil, fmt.Errorf("container %s is %s, RPMsg channels are only announced while it runs", containerID, state.Status)
+	}
+	return rpmsgDevices(host, state)
+}
+
+func rpmsgDevices(host Host, state *specs.State) ([]remoteproc.RPMsgDevice, error) {
+	processor, err := host.Backend.Open(state.Annotations[oci.StateDriverPath])
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remote processor: %w", err)
+	}
+	devices, err := processor.RPMsgDevices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list RPMsg devices: %w", err)
+	}
+	return devices, nil
+}
+
+func rpmsgAnnotations(devices []remoteproc.RPMsgDevice) map[string]string {
+	if len(devices) == 0 {
+		return nil
+	}
+	descriptions := make([]string, len(devices))
+	for i, device := range devices {
+		descriptions[i] = device.String()
+	}
+	return map[string]string{oci.OptionalStateRPMsgDevices: strings.Join(descriptions, "; ")}
+}
+
+// destroyRPMsgEndpoints destroys the endpoints the container's proxy created and didn't get to
+// destroy, because it was killed.
+func destroyRPMsgEndpoints(host Host, state *specs.State) error {
+	status, err := oci.ReadProxyStatus(state.ID)
+	if err != nil {
+		return err
+	}
+	if len(status.RPMsgEndpoints) == 0 {
+		return nil
+	}
+	processor, err := host.Backend.Open(state.Annotations[oci.StateDriverPath])
+	if err != nil {
+		return fmt.Errorf("failed to open remote processor: %w", err)
+	}
+	var errs []error
+	for _, deviceNode := range status.RPMsgEndpoints {
+		if err := processor.DestroyEndpoint(deviceNode); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
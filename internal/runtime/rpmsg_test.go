@@ -0,0 +1,150 @@
+package runtime_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc/remoteproctest"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ttyChannel = remoteproc.RPMsgDevice{
+	Name:       "virtio0.rpmsg-tty.-1.1024",
+	Channel:    "rpmsg-tty",
+	Src:        0x400,
+	Dst:        0x1,
+	Driver:     "rpmsg_tty",
+	DeviceNode: "/dev/ttyRPMSG0",
+}
+
+func TestRPMsgDevices(t *testing.T) {
+	t.Run("lists the channels announced by a running container's firmware", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.AddRPMsgDevice(ttyChannel)
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		assertProcessorState(t, processor, remoteproc.StateRunning)
+
+		devices, err := runtime.RPMsgDevices(host, containerID)
+
+		require.NoError(t, err)
+		assert.Equal(t, []remoteproc.RPMsgDevice{ttyChannel}, devices)
+	})
+
+	t.Run("refuses containers that aren't running", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+
+		_, err := runtime.RPMsgDevices(host, containerID)
+
+		assert.ErrorContains(t, err, "is created, RPMsg channels are only announced while it runs")
+	})
+
+	t.Run("live state records the channels of running containers", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.AddRPMsgDevice(ttyChannel)
+		containerID := testID(t)
+		require.NoError(t, runtime.Create(logger(), host, containerID, generateBundle(t, "m33"), runtime.CreateOptions{}))
+		created, err := runtime.LiveState(logger(), host, containerID)
+		require.NoError(t, err)
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		assertProcessorState(t, processor, remoteproc.StateRunning)
+
+		running, err := runtime.LiveState(logger(), host, containerID)
+
+		require.NoError(t, err)
+		assert.NotContains(t, created.Annotations, oci.OptionalStateRPMsgDevices)
+		assert.Equal(t, "rpmsg-tty src=0x400 dst=0x1 driver=rpmsg_tty dev=/dev/ttyRPMSG0", running.Annotations[oci.OptionalStateRPMsgDevices])
+	})
+}
+
+func TestRPMsgEndpoints(t *testing.T) {
+	t.Run("state records the endpoints created for a running container", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.EnableRPMsgCtrl()
+		containerID := testID(t)
+		bundle := generateEndpointsBundle(t, "m33", "control:0x400:0x1")
+		require.NoError(t, runtime.Create(logger(), host, containerID, bundle, runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			state, err := runtime.State(containerID)
+			if assert.NoError(c, err) {
+				assert.Equal(c, "control src=0x400 dst=0x1 dev=/dev/rpmsg0", state.Annotations[oci.OptionalStateRPMsgEndpoints])
+			}
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("stopping the container destroys its endpoints", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.EnableRPMsgCtrl()
+		containerID := testID(t)
+		bundle := generateEndpointsBundle(t, "m33", "control:0x400:0x1")
+		require.NoError(t, runtime.Create(logger(), host, containerID, bundle, runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		waitForEndpoints(t, processor, 1)
+
+		require.NoError(t, runtime.Kill(host, containerID, syscall.SIGTERM))
+
+		waitForEndpoints(t, processor, 0)
+	})
+
+	t.Run("deleting a killed container destroys the endpoints it left behind", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.EnableRPMsgCtrl()
+		containerID := testID(t)
+		bundle := generateEndpointsBundle(t, "m33", "control:0x400:0x1")
+		require.NoError(t, runtime.Create(logger(), host, containerID, bundle, runtime.CreateOptions{}))
+		require.NoError(t, runtime.Start(logger(), host, containerID))
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			state, err := runtime.State(containerID)
+			if assert.NoError(c, err) {
+				assert.Contains(c, state.Annotations, oci.OptionalStateRPMsgEndpoints)
+			}
+		}, time.Second, time.Millisecond)
+		require.NoError(t, runtime.Kill(host, containerID, syscall.SIGKILL))
+		require.Len(t, processor.Endpoints(), 1)
+
+		require.NoError(t, runtime.Delete(logger(), host, containerID, false))
+
+		assert.Empty(t, processor.Endpoints())
+	})
+
+	t.Run("create rejects malformed endpoints", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+		bundle := generateEndpointsBundle(t, "m33", "control:0x400")
+
+		err := runtime.Create(logger(), host, testID(t), bundle, runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, `invalid remoteproc.rpmsg.endpoints annotation: invalid RPMsg endpoint "control:0x400": expected name:src:dst`)
+	})
+}
+
+func generateEndpointsBundle(t *testing.T, processorName string, endpoints string) string {
+	t.Helper()
+	return generateBundleWithAnnotations(t, map[string]string{
+		oci.SpecName:           processorName,
+		oci.SpecRPMsgEndpoints: endpoints,
+	})
+}
+
+func waitForEndpoints(t *testing.T, processor *remoteproctest.Processor, count int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return len(processor.Endpoints()) == count
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,51 @@
+package runtime
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+)
+
+func selectorFromAnnotations(annotations map[string]string) (remoteproc.Selector, error) {
+	selector := remoteproc.Selector{
+		NameGlob:       annotations[oci.SpecNameGlob],
+		DeviceTreeNode: annotations[oci.SpecDeviceTreeNode],
+		ParentDevice:   annotations[oci.SpecParentDevice],
+	}
+	if names, ok := annotations[oci.SpecName]; ok {
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				selector.Names = append(selector.Names, name)
+			}
+		}
+		if len(selector.Names) == 0 {
+			return remoteproc.Selector{}, fmt.Errorf("invalid %s %q: must name at least one processor", oci.SpecName, names)
+		}
+	}
+	if selector.NameGlob != "" {
+		// path.Match only reports a malformed pattern once it gets to it, so it is checked upfront.
+		if _, err := path.Match(selector.NameGlob, ""); err != nil {
+			return remoteproc.Selector{}, fmt.Errorf("invalid %s %q: %w", oci.SpecNameGlob, selector.NameGlob, err)
+		}
+	}
+	if pattern, ok := annotations[oci.SpecNameRegex]; ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return remoteproc.Selector{}, fmt.Errorf("invalid %s: %w", oci.SpecNameRegex, err)
+		}
+		selector.NameRe = re
+	}
+	if rawIndex, ok := annotations[oci.SpecIndex]; ok {
+		index, err := strconv.Atoi(rawIndex)
+		if err != nil || index < 0 {
+			return remoteproc.Selector{}, fmt.Errorf("invalid %s %q: must be a non-negative integer", oci.SpecIndex, rawIndex)
+		}
+		selector.Index = &index
+	}
+	return selector, nil
+}
@@ -0,0 +1,70 @@
+package runtime_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/remoteproc"
+	"github.com/arm/remoteproc-runtime/internal/runtime"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGracefulShutdown(t *testing.T) {
+	t.Run("kill returns once the firmware has shut down", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		processor := backend.AddProcessor("m33")
+		processor.AddRPMsgDevice(ttyChannel)
+		processor.HandleRPMsg(ttyChannel.DeviceNode, func([]byte) []byte {
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				processor.ForceState(remoteproc.StateOffline)
+			}()
+			return nil
+		})
+		containerID := testID(t)
+		runFirmware(t, host, containerID, processor, map[string]string{
+			oci.SpecName:            "m33",
+			oci.SpecShutdownChannel: "rpmsg-tty",
+			oci.SpecShutdownMessage: `halt\n`,
+			oci.SpecShutdownTimeout: "10s",
+		})
+
+		require.NoError(t, runtime.Kill(host, containerID, syscall.SIGTERM))
+
+		state, err := processor.State()
+		require.NoError(t, err)
+		assert.Equal(t, remoteproc.StateOffline, state)
+		assert.Equal(t, "halt\n", processor.RPMsgInput(ttyChannel.DeviceNode))
+		assert.Equal(t, 0, processor.StopCount())
+		assertStatus(t, containerID, specs.StateStopped)
+	})
+
+	t.Run("create rejects an invalid timeout", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:            "m33",
+			oci.SpecShutdownChannel: "rpmsg-tty",
+			oci.SpecShutdownTimeout: "soon",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, `invalid remoteproc.shutdown.timeout "soon"`)
+	})
+
+	t.Run("create rejects shutdown settings without a channel", func(t *testing.T) {
+		host, backend, _ := newHost(t)
+		backend.AddProcessor("m33")
+
+		err := runtime.Create(logger(), host, testID(t), generateBundleWithAnnotations(t, map[string]string{
+			oci.SpecName:            "m33",
+			oci.SpecShutdownMessage: "halt",
+		}), runtime.CreateOptions{})
+
+		assert.ErrorContains(t, err, "remoteproc.shutdown.message requires remoteproc.shutdown.channel")
+	})
+}
@@ -11,36 +11,63 @@ import (
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
-func Start(logger *slog.Logger, containerID string) error {
+func Start(logger *slog.Logger, host Host, containerID string) error {
 	state, err := oci.ReadState(containerID)
 	if err != nil {
 		return fmt.Errorf("failed to read state: %w", err)
 	}
-	sourceFirmwarePath := state.Annotations[oci.StateFirmwarePath]
-	destFirmwareDir := remoteproc.GetSystemFirmwarePath(logger)
-	storedFirmwarePath, err := remoteproc.StoreFirmware(sourceFirmwarePath, destFirmwareDir)
+	processor, err := host.Backend.Open(state.Annotations[oci.StateDriverPath])
 	if err != nil {
-		return fmt.Errorf("failed to store firmware file %s to %s: %w", sourceFirmwarePath, destFirmwareDir, err)
+		return fmt.Errorf("failed to open remote processor: %w", err)
 	}
-	state.Annotations[oci.OptionalStateStoredFirmwarePath] = storedFirmwarePath
+	var storedFirmwarePath string
 	needCleanup := true
 	defer func() {
-		if needCleanup {
+		if needCleanup && storedFirmwarePath != "" {
 			_ = os.Remove(storedFirmwarePath)
 		}
 	}()
+	if state.Annotations[oci.SpecAttach] == "true" {
+		// The proxy adopts the firmware already there, which may have gone away since create.
+		processorState, err := processor.State()
+		if err != nil {
+			return fmt.Errorf("failed to get remoteproc state: %w", err)
+		}
+		if !attachable(processorState) {
+			return fmt.Errorf("no firmware to attach to, processor is %s", processorState)
+		}
+	} else {
+		sourceFirmwarePath := state.Annotations[oci.StateFirmwarePath]
+		destFirmwareDir := host.Backend.FirmwareDir()
+		storedFirmwarePath, err = remoteproc.StoreFirmware(sourceFirmwarePath, destFirmwareDir)
+		if err != nil {
+			return fmt.Errorf("failed to store firmware file %s to %s: %w", sourceFirmwarePath, destFirmwareDir, err)
+		}
+		if err := processor.SetFirmware(storedFirmwarePath); err != nil {
+			return fmt.Errorf("failed to set firmware: %w", err)
+		}
+	}
 
-	if err := remoteproc.SetFirmware(
-		state.Annotations[oci.StateDriverPath],
-		storedFirmwarePath,
-	); err != nil {
-		return fmt.Errorf("failed to set firmware: %w", err)
+	readiness, err := readinessFromAnnotations(state.Annotations)
+	if err != nil {
+		return err
 	}
 
-	if err := proxy.StartFirmware(state.Pid); err != nil {
+	if err := proxy.StartFirmware(host.Proxy, containerID, state.Pid); err != nil {
+		// The proxy exits once it fails to start the firmware.
+		stopUnreadyFirmware(logger, host, state)
 		return fmt.Errorf("failed to start firmware: %w", err)
 	}
+	if readiness != nil {
+		if err := readiness.await(host, processor, state.Pid); err != nil {
+			stopUnreadyFirmware(logger, host, state)
+			return err
+		}
+	}
 
+	if storedFirmwarePath != "" {
+		state.Annotations[oci.OptionalStateStoredFirmwarePath] = storedFirmwarePath
+	}
 	state.Status = specs.StateRunning
 	if err := oci.WriteState(state); err != nil {
 		return fmt.Errorf("failed to write state: %w", err)
@@ -49,3 +76,17 @@ func Start(logger *slog.Logger, containerID string) error {
 	needCleanup = false
 	return nil
 }
+
+// stopUnreadyFirmware stops the proxy of firmware that failed to start or become ready and
+// records the container as stopped. The stored firmware is removed by start.
+func stopUnreadyFirmware(logger *slog.Logger, host Host, state *specs.State) {
+	if err := proxy.StopFirmware(host.Proxy, state.Pid); err != nil {
+		logger.Debug("failed to stop proxy", "error", err)
+	} else if err := host.Proxy.AwaitExit(state.Pid, shutdownMargin); err != nil {
+		logger.Warn("proxy didn't exit", "error", err)
+	}
+	state.Status = specs.StateStopped
+	if err := oci.WriteState(state); err != nil {
+		logger.Warn("failed to write state", "error", err)
+	}
+}
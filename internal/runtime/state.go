@@ -1,16 +1,81 @@
 package runtime
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"time"
 
 	"github.com/arm/remoteproc-runtime/internal/oci"
+	"github.com/arm/remoteproc-runtime/internal/proxy"
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
+// State returns the container's state, along with the annotations its proxy reported.
 func State(containerID string) (*specs.State, error) {
 	state, err := oci.ReadState(containerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read state: %w", err)
 	}
+	status, err := oci.ReadProxyStatus(containerID)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(state.Annotations, status.Annotations)
+	return state, nil
+}
+
+// statusTimeout is how long the proxy gets to reply to a status request.
+const statusTimeout = 5 * time.Second
+
+// LiveState is State plus what the firmware of a running container currently exposes. RPMsg
+// channels come and go with the firmware, so they are looked up rather than stored. Failing to
+// look them up doesn't fail the state query. A running container whose proxy is gone is
+// reported, and recorded, as stopped.
+func LiveState(logger *slog.Logger, host Host, containerID string) (*specs.State, error) {
+	state, err := State(containerID)
+	if err != nil {
+		return nil, err
+	}
+	if state.Status != specs.StateRunning {
+		return state, nil
+	}
+	if err := checkProxy(logger, state); err != nil {
+		return nil, err
+	}
+	if state.Status != specs.StateRunning {
+		return state, nil
+	}
+	devices, err := rpmsgDevices(host, state)
+	if err != nil {
+		logger.Warn("failed to look up RPMsg channels", "error", err)
+		return state, nil
+	}
+	maps.Copy(state.Annotations, rpmsgAnnotations(devices))
 	return state, nil
 }
+
+// checkProxy marks the container stopped if its proxy exited, e.g. because the firmware
+// stopped for good.
+func checkProxy(logger *slog.Logger, state *specs.State) error {
+	_, err := proxy.SendControl(state.ID, proxy.ControlStatus, statusTimeout)
+	switch {
+	case err == nil, errors.Is(err, proxy.ErrNoControlSocket):
+		return nil
+	case errors.Is(err, proxy.ErrProxyExited):
+		stored, err := oci.ReadState(state.ID)
+		if err != nil {
+			return fmt.Errorf("failed to read state: %w", err)
+		}
+		stored.Status = specs.StateStopped
+		if err := oci.WriteState(stored); err != nil {
+			return fmt.Errorf("failed to write state: %w", err)
+		}
+		state.Status = specs.StateStopped
+		return nil
+	default:
+		logger.Warn("failed to query proxy status", "error", err)
+		return nil
+	}
+}
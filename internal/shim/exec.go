@@ -0,0 +1,219 @@
+package shim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/oci"
+	eventstypes "github.com/containerd/containerd/api/events"
+	taskAPI "github.com/containerd/containerd/api/runtime/task/v2"
+	ttypes "github.com/containerd/containerd/api/types/task"
+	"github.com/containerd/containerd/v2/pkg/protobuf"
+	"github.com/containerd/errdefs"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// execProcess is a process exec'd in the container. Firmware can't run Linux processes, so its
+// arguments are handed to the runtime's exec, which runs them as one of its built-in targets,
+// e.g. health for engine health checks.
+type execProcess struct {
+	containerID string
+	args        []string
+	stdout      string
+	stderr      string
+
+	cmd        *exec.Cmd
+	exitStatus uint32
+	exitedAt   time.Time
+	done       chan struct{}
+}
+
+func newExecProcess(r *taskAPI.ExecProcessRequest) (*execProcess, error) {
+	if r.Terminal {
+		return nil, fmt.Errorf("exec with a terminal: %w", errdefs.ErrNotImplemented)
+	}
+	// containerd encodes the process spec, not being a protobuf message, as JSON.
+	var process specs.Process
+	if err := json.Unmarshal(r.Spec.GetValue(), &process); err != nil {
+		return nil, fmt.Errorf("invalid exec process: %w: %w", errdefs.ErrInvalidArgument, err)
+	}
+	if len(process.Args) == 0 {
+		return nil, fmt.Errorf("exec process has no arguments: %w", errdefs.ErrInvalidArgument)
+	}
+	return &execProcess{
+		containerID: r.ID,
+		args:        oci.UnwrapShell(process.Args),
+		stdout:      r.Stdout,
+		stderr:      r.Stderr,
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// start runs the runtime's exec, copying its output to the exec's stdout and stderr, and
+// calls exited once it is over.
+func (p *execProcess) start(ctx context.Context, exited func()) (int, error) {
+	stdout, err := openOutput(ctx, p.stdout)
+	if err != nil {
+		return 0, err
+	}
+	stderr, err := openOutput(ctx, p.stderr)
+	if err != nil {
+		closeOutputs(stdout)
+		return 0, err
+	}
+	p.cmd = exec.Command(runtimeBinName, append([]string{"exec", p.containerID}, p.args...)...)
+	if stdout != nil {
+		p.cmd.Stdout = stdout
+	}
+	if stderr != nil {
+		p.cmd.Stderr = stderr
+	}
+	if err := p.cmd.Start(); err != nil {
+		closeOutputs(stdout, stderr)
+		return 0, fmt.Errorf("runtime exec failed: %w", err)
+	}
+	go func() {
+		err := p.cmd.Wait()
+		closeOutputs(stdout, stderr)
+		var exitErr *exec.ExitError
+		switch {
+		case errors.As(err, &exitErr):
+			p.exitStatus = uint32(exitErr.ExitCode())
+		case err != nil:
+			p.exitStatus = 255
+		}
+		p.exitedAt = time.Now().UTC()
+		close(p.done)
+		exited()
+	}()
+	return p.cmd.Process.Pid, nil
+}
+
+func (p *execProcess) pid() int {
+	if p.cmd == nil || p.cmd.Process == nil {
+		return 0
+	}
+	return p.cmd.Process.Pid
+}
+
+func (p *execProcess) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *execProcess) kill(signal syscall.Signal) error {
+	if p.cmd == nil || p.exited() {
+		return nil
+	}
+	return p.cmd.Process.Signal(signal)
+}
+
+func closeOutputs(outputs ...io.WriteCloser) {
+	for _, output := range outputs {
+		if output != nil {
+			_ = output.Close()
+		}
+	}
+}
+
+func (s *remoteprocTaskService) execProcess(execID string) (*execProcess, error) {
+	s.execsMu.Lock()
+	defer s.execsMu.Unlock()
+	process, ok := s.execs[execID]
+	if !ok {
+		return nil, fmt.Errorf("exec %s: %w", execID, errdefs.ErrNotFound)
+	}
+	return process, nil
+}
+
+func (s *remoteprocTaskService) startExec(ctx context.Context, r *taskAPI.StartRequest) (*taskAPI.StartResponse, error) {
+	process, err := s.execProcess(r.ExecID)
+	if err != nil {
+		return nil, err
+	}
+	pid, err := process.start(ctx, func() {
+		s.send(&eventstypes.TaskExit{
+			ContainerID: r.ID,
+			ID:          r.ExecID,
+			Pid:         uint32(process.pid()),
+			ExitStatus:  process.exitStatus,
+			ExitedAt:    protobuf.ToTimestamp(process.exitedAt),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.send(&eventstypes.TaskExecStarted{
+		ContainerID: r.ID,
+		ExecID:      r.ExecID,
+		Pid:         uint32(pid),
+	})
+	return &taskAPI.StartResponse{Pid: uint32(pid)}, nil
+}
+
+func (s *remoteprocTaskService) execState(r *taskAPI.StateRequest) (*taskAPI.StateResponse, error) {
+	process, err := s.execProcess(r.ExecID)
+	if err != nil {
+		return nil, err
+	}
+	response := &taskAPI.StateResponse{
+		ID:     r.ExecID,
+		Pid:    uint32(process.pid()),
+		Stdout: process.stdout,
+		Stderr: process.stderr,
+		Status: ttypes.Status_CREATED,
+	}
+	switch {
+	case process.exited():
+		response.Status = ttypes.Status_STOPPED
+		response.ExitStatus = process.exitStatus
+		response.ExitedAt = protobuf.ToTimestamp(process.exitedAt)
+	case process.cmd != nil:
+		response.Status = ttypes.Status_RUNNING
+	}
+	return response, nil
+}
+
+func (s *remoteprocTaskService) waitExec(ctx context.Context, r *taskAPI.WaitRequest) (*taskAPI.WaitResponse, error) {
+	process, err := s.execProcess(r.ExecID)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-process.done:
+		return &taskAPI.WaitResponse{
+			ExitStatus: process.exitStatus,
+			ExitedAt:   protobuf.ToTimestamp(process.exitedAt),
+		}, nil
+	}
+}
+
+func (s *remoteprocTaskService) deleteExec(r *taskAPI.DeleteRequest) (*taskAPI.DeleteResponse, error) {
+	process, err := s.execProcess(r.ExecID)
+	if err != nil {
+		return nil, err
+	}
+	if process.cmd != nil && !process.exited() {
+		return nil, fmt.Errorf("exec %s is still running: %w", r.ExecID, errdefs.ErrFailedPrecondition)
+	}
+	s.execsMu.Lock()
+	delete(s.execs, r.ExecID)
+	s.execsMu.Unlock()
+	return &taskAPI.DeleteResponse{
+		Pid:        uint32(process.pid()),
+		ExitStatus: process.exitStatus,
+		ExitedAt:   protobuf.ToTimestamp(process.exitedAt),
+	}, nil
+}
@@ -0,0 +1,297 @@
+package shim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/containerd/console"
+	"github.com/containerd/fifo"
+	runc "github.com/containerd/go-runc"
+)
+
+// binaryLoggerStartTimeout bounds how long a binary:// logger may take to signal it is ready.
+const binaryLoggerStartTimeout = 10 * time.Second
+
+// TaskIO connects the stdio containerd provides for a task to the proxy process. The proxy
+// writes the firmware's trace output to its stdout, which is copied to the task's stdout.
+// Without a terminal, firmware has no stdin or stderr: stdin is drained so writers don't
+// block, and stderr is kept open until the task goes away so readers see EOF alongside stdout.
+// With a terminal, the runtime hands over the pty bridged to the firmware's RPMsg TTY, which
+// takes the task's stdin and feeds its stdout.
+type TaskIO struct {
+	// ProxyStdout is the write end to hand to the runtime's create, nil if the task has no
+	// stdout or has a terminal.
+	ProxyStdout *os.File
+	// ConsoleSocket receives the terminal from the runtime's create, nil without a terminal.
+	ConsoleSocket *runc.Socket
+
+	proxyStdoutReader *os.File
+	console           console.Console
+	stdin             io.ReadCloser
+	stdout            io.WriteCloser
+	stderr            io.WriteCloser
+	logger            *exec.Cmd
+
+	copied    chan struct{}
+	closeOnce sync.Once
+	stdinOnce sync.Once
+}
+
+// NewTaskIO opens the task's stdio. Each of stdin, stdout and stderr is either empty, a FIFO
+// path, or, for stdout and stderr, a file:// or binary:// URI.
+func NewTaskIO(ctx context.Context, containerID, namespace, stdin, stdout, stderr string, terminal bool) (_ *TaskIO, retErr error) {
+	tio := &TaskIO{copied: make(chan struct{})}
+	defer func() {
+		if retErr != nil {
+			tio.Close()
+		}
+	}()
+
+	if stdin != "" {
+		f, err := fifo.OpenFifo(context.Background(), stdin, syscall.O_RDONLY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open stdin %s: %w", stdin, err)
+		}
+		tio.stdin = f
+		if !terminal {
+			go func() { _, _ = io.Copy(io.Discard, f) }()
+		}
+	}
+
+	if err := tio.openOutputs(ctx, containerID, namespace, stdout, stderr); err != nil {
+		return nil, err
+	}
+
+	if terminal {
+		socket, err := runc.NewTempConsoleSocket()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create console socket: %w", err)
+		}
+		tio.ConsoleSocket = socket
+		return tio, nil
+	}
+	if tio.stdout == nil {
+		close(tio.copied)
+		return tio, nil
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	tio.proxyStdoutReader = r
+	tio.ProxyStdout = w
+	go func() {
+		defer close(tio.copied)
+		_, _ = io.Copy(tio.stdout, r)
+	}()
+	return tio, nil
+}
+
+func (tio *TaskIO) openOutputs(ctx context.Context, containerID, namespace, stdout, stderr string) error {
+	if stdout == "" {
+		var err error
+		tio.stderr, err = openOutput(ctx, stderr)
+		return err
+	}
+	u, err := url.Parse(stdout)
+	if err != nil {
+		return fmt.Errorf("invalid stdout %q: %w", stdout, err)
+	}
+	switch u.Scheme {
+	case "binary":
+		// Like containerd, the logger gets both streams and stderr carries the same URI.
+		return tio.startBinaryLogger(u, containerID, namespace)
+	case "file":
+		// stderr is the same file and nothing is written to it.
+		file, err := openLogFile(u.Path)
+		if err != nil {
+			return err
+		}
+		tio.stdout = file
+		return nil
+	}
+	if tio.stdout, err = openOutput(ctx, stdout); err != nil {
+		return err
+	}
+	tio.stderr, err = openOutput(ctx, stderr)
+	return err
+}
+
+func openOutput(ctx context.Context, path string) (io.WriteCloser, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := fifo.OpenFifo(ctx, path, syscall.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return f, nil
+}
+
+func openLogFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return file, nil
+}
+
+// startBinaryLogger runs a logging binary following containerd's protocol: it reads stdout
+// from fd 3 and stderr from fd 4, and closes fd 5 once it is ready.
+func (tio *TaskIO) startBinaryLogger(u *url.URL, containerID, namespace string) error {
+	var args []string
+	for k, vs := range u.Query() {
+		args = append(args, k)
+		if len(vs) > 0 {
+			args = append(args, vs[0])
+		}
+	}
+	cmd := exec.Command(u.Path, args...)
+	cmd.Env = append(os.Environ(), "CONTAINER_ID="+containerID, "CONTAINER_NAMESPACE="+namespace)
+
+	var pipes []*os.File
+	defer func() {
+		for _, p := range pipes {
+			_ = p.Close()
+		}
+	}()
+	newPipe := func() (*os.File, *os.File, error) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create logger pipe: %w", err)
+		}
+		pipes = append(pipes, r, w)
+		return r, w, nil
+	}
+	stdoutR, stdoutW, err := newPipe()
+	if err != nil {
+		return err
+	}
+	stderrR, stderrW, err := newPipe()
+	if err != nil {
+		return err
+	}
+	readyR, readyW, err := newPipe()
+	if err != nil {
+		return err
+	}
+	cmd.ExtraFiles = []*os.File{stdoutR, stderrR, readyW}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start logger %s: %w", u.Path, err)
+	}
+	tio.logger = cmd
+	tio.stdout = stdoutW
+	tio.stderr = stderrW
+	// The logger holds its own copies of these now; closing ours lets readyR see EOF.
+	pipes = []*os.File{stdoutR, stderrR, readyW, readyR}
+	_ = readyW.Close()
+
+	_ = readyR.SetReadDeadline(time.Now().Add(binaryLoggerStartTimeout))
+	if _, err := readyR.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("logger %s did not become ready: %w", u.Path, err)
+	}
+	return nil
+}
+
+// ProxyStarted releases the shim's copy of the proxy's stdout once create has handed it to the
+// proxy, so the copy ends when the proxy exits.
+func (tio *TaskIO) ProxyStarted() {
+	if tio.ProxyStdout != nil {
+		_ = tio.ProxyStdout.Close()
+	}
+}
+
+// AttachConsole receives the terminal the runtime's create sent to ConsoleSocket and connects
+// it to the task's stdin and stdout.
+func (tio *TaskIO) AttachConsole() error {
+	defer func() {
+		_ = tio.ConsoleSocket.Close()
+		tio.ConsoleSocket = nil
+	}()
+	con, err := tio.ConsoleSocket.ReceiveMaster()
+	if err != nil {
+		return fmt.Errorf("failed to receive console: %w", err)
+	}
+	tio.console = con
+	if tio.stdin != nil {
+		go func() { _, _ = io.Copy(con, tio.stdin) }()
+	}
+	go func() {
+		defer close(tio.copied)
+		if tio.stdout == nil {
+			_, _ = io.Copy(io.Discard, con)
+			return
+		}
+		// Reading fails with EIO once the proxy, the last holder of the pty slave, exits.
+		_, _ = io.Copy(tio.stdout, con)
+	}()
+	return nil
+}
+
+// Resize sets the terminal's window size.
+func (tio *TaskIO) Resize(width, height uint32) error {
+	if tio.console == nil {
+		return fmt.Errorf("task has no terminal")
+	}
+	return tio.console.Resize(console.WinSize{Width: uint16(width), Height: uint16(height)})
+}
+
+// CloseStdin stops reading the task's stdin.
+func (tio *TaskIO) CloseStdin() {
+	tio.stdinOnce.Do(func() {
+		if tio.stdin != nil {
+			_ = tio.stdin.Close()
+		}
+	})
+}
+
+// Report writes a line about the task to its stderr, which the proxy leaves unused, if it has
+// one of its own.
+func (tio *TaskIO) Report(message string) {
+	if tio.stderr != nil {
+		_, _ = io.WriteString(tio.stderr, message+"\n")
+	}
+}
+
+// Wait waits up to timeout for the proxy's output to be copied, which completes once the proxy exits.
+func (tio *TaskIO) Wait(timeout time.Duration) bool {
+	select {
+	case <-tio.copied:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Close tears down the task's stdio, letting readers see EOF and waiting for a binary logger to exit.
+func (tio *TaskIO) Close() {
+	tio.closeOnce.Do(func() {
+		tio.CloseStdin()
+		for _, f := range []io.Closer{tio.ProxyStdout, tio.proxyStdoutReader, tio.stdout, tio.stderr} {
+			if f != nil {
+				_ = f.Close()
+			}
+		}
+		if tio.ConsoleSocket != nil {
+			_ = tio.ConsoleSocket.Close()
+		}
+		if tio.console != nil {
+			_ = tio.console.Close()
+		}
+		if tio.logger != nil {
+			_ = tio.logger.Wait()
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package shim_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/arm/remoteproc-runtime/internal/shim"
+	"github.com/containerd/fifo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskIO(t *testing.T) {
+	t.Run("copies proxy output to stdout FIFO and closes stderr with it", func(t *testing.T) {
+		dir := t.TempDir()
+		stdoutPath := filepath.Join(dir, "stdout")
+		stderrPath := filepath.Join(dir, "stderr")
+		// Like containerd's client, open the read ends before the task is created.
+		stdout := openReader(t, stdoutPath)
+		stderr := openReader(t, stderrPath)
+
+		taskIO, err := shim.NewTaskIO(context.Background(), "test", "default", "", stdoutPath, stderrPath, false)
+		require.NoError(t, err)
+		writeProxyOutput(t, taskIO, "booting\n")
+		require.True(t, taskIO.Wait(time.Second))
+		taskIO.Close()
+
+		assert.Equal(t, "booting\n", readAll(t, stdout))
+		assert.Empty(t, readAll(t, stderr))
+	})
+
+	t.Run("reports to the stderr FIFO, which the proxy leaves unused", func(t *testing.T) {
+		dir := t.TempDir()
+		stdoutPath := filepath.Join(dir, "stdout")
+		stderrPath := filepath.Join(dir, "stderr")
+		stdout := openReader(t, stdoutPath)
+		stderr := openReader(t, stderrPath)
+
+		taskIO, err := shim.NewTaskIO(context.Background(), "test", "default", "", stdoutPath, stderrPath, false)
+		require.NoError(t, err)
+		writeProxyOutput(t, taskIO, "booting\n")
+		require.True(t, taskIO.Wait(time.Second))
+		taskIO.Report("firmware crashed: watchdog")
+		taskIO.Close()
+
+		assert.Equal(t, "booting\n", readAll(t, stdout))
+		assert.Equal(t, "firmware crashed: watchdog\n", readAll(t, stderr))
+	})
+
+	t.Run("appends proxy output to file:// logs", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "logs", "container.log")
+		uri := "file://" + logPath
+
+		taskIO, err := shim.NewTaskIO(context.Background(), "test", "default", "", uri, uri, false)
+		require.NoError(t, err)
+		writeProxyOutput(t, taskIO, "booting\n")
+		require.True(t, taskIO.Wait(time.Second))
+		taskIO.Close()
+
+		content, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Equal(t, "booting\n", string(content))
+	})
+
+	t.Run("hands proxy output to binary:// loggers", func(t *testing.T) {
+		dir := t.TempDir()
+		logger := filepath.Join(dir, "logger")
+		require.NoError(t, os.WriteFile(logger, []byte("#!/bin/sh\nexec 5>&-\ncat <&3 > \"$(dirname \"$0\")/$CONTAINER_NAMESPACE-$CONTAINER_ID.log\"\n"), 0o755))
+		uri := "binary://" + logger
+
+		taskIO, err := shim.NewTaskIO(context.Background(), "test", "default", "", uri, uri, false)
+		require.NoError(t, err)
+		writeProxyOutput(t, taskIO, "booting\n")
+		require.True(t, taskIO.Wait(time.Second))
+		taskIO.Close()
+
+		content, err := os.ReadFile(filepath.Join(dir, "default-test.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "booting\n", string(content))
+	})
+
+	t.Run("has nothing for the proxy to write to without stdout", func(t *testing.T) {
+		taskIO, err := shim.NewTaskIO(context.Background(), "test", "default", "", "", "", false)
+		require.NoError(t, err)
+		defer taskIO.Close()
+
+		assert.Nil(t, taskIO.ProxyStdout)
+		assert.True(t, taskIO.Wait(time.Second))
+	})
+
+	t.Run("gives up waiting while the proxy is still running", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "container.log")
+		taskIO, err := shim.NewTaskIO(context.Background(), "test", "default", "", "file://"+logPath, "", false)
+		require.NoError(t, err)
+		defer taskIO.Close()
+
+		assert.False(t, taskIO.Wait(10*time.Millisecond))
+	})
+}
+
+// writeProxyOutput stands in for a proxy that writes to its stdout and exits.
+func writeProxyOutput(t *testing.T, taskIO *shim.TaskIO, text string) {
+	t.Helper()
+	_, err := taskIO.ProxyStdout.WriteString(text)
+	require.NoError(t, err)
+	taskIO.ProxyStarted()
+}
+
+func openReader(t *testing.T, path string) io.ReadCloser {
+	t.Helper()
+	reader, err := fifo.OpenFifo(context.Background(), path, syscall.O_RDONLY|syscall.O_CREAT|syscall.O_NONBLOCK, 0o700)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = reader.Close() })
+	return reader
+}
+
+func readAll(t *testing.T, r io.Reader) string {
+	t.Helper()
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(content)
+}
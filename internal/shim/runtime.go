@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
 	"syscall"
 
@@ -12,8 +13,18 @@ import (
 
 const runtimeBinName = "remoteproc-runtime"
 
-func executeCreate(containerID string, bundlePath string) error {
-	cmd := exec.Command(runtimeBinName, "create", "--bundle", bundlePath, containerID)
+// executeCreate runs the runtime's create. The proxy process it starts inherits stdout, which
+// may be nil to discard the proxy's output. A non-empty consoleSocket receives the terminal of
+// containers with process.terminal set.
+func executeCreate(containerID string, bundlePath string, stdout *os.File, consoleSocket string) error {
+	args := []string{"create", "--bundle", bundlePath}
+	if consoleSocket != "" {
+		args = append(args, "--console-socket", consoleSocket)
+	}
+	cmd := exec.Command(runtimeBinName, append(args, containerID)...)
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
@@ -10,6 +10,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/arm/remoteproc-runtime/internal/oci"
 	eventstypes "github.com/containerd/containerd/api/events"
 	taskAPI "github.com/containerd/containerd/api/runtime/task/v2"
 	ttypes "github.com/containerd/containerd/api/types/task"
@@ -61,6 +62,8 @@ func newTaskService(ctx context.Context, publisher containerdshim.Publisher, sd
 		shutdown:       sd,
 		logger:         log.G(ctx),
 		processWatcher: nil,
+		io:             map[string]*TaskIO{},
+		execs:          map[string]*execProcess{},
 	}
 
 	sd.RegisterCallback(func(context.Context) error {
@@ -82,8 +85,17 @@ type remoteprocTaskService struct {
 
 	processWatcherMu sync.Mutex
 	processWatcher   *ProcessWatcher
+
+	ioMu sync.Mutex
+	io   map[string]*TaskIO
+
+	execsMu sync.Mutex
+	execs   map[string]*execProcess
 }
 
+// taskIODrainTimeout bounds how long Delete waits for the proxy's remaining output.
+const taskIODrainTimeout = 5 * time.Second
+
 // RegisterTTRPC allows TTRPC services to be registered with the underlying server
 func (s *remoteprocTaskService) RegisterTTRPC(server *ttrpc.Server) error {
 	taskAPI.RegisterTTRPCTaskService(server, s)
@@ -99,13 +111,37 @@ func (s *remoteprocTaskService) Create(ctx context.Context, r *taskAPI.CreateTas
 	if err := mount.All(toMount, rootFS); err != nil {
 		return nil, fmt.Errorf("failed to mount rootfs: %w", err)
 	}
-	err := executeCreate(r.ID, r.Bundle)
+	ns, _ := namespaces.Namespace(ctx)
+	taskIO, err := NewTaskIO(ctx, r.ID, ns, r.Stdin, r.Stdout, r.Stderr, r.Terminal)
 	if err != nil {
+		if err := mount.UnmountMounts(toMount, rootFS, 0); err != nil {
+			s.logger.WithError(err).Warn("failed to cleanup rootfs mount")
+		}
+		return nil, fmt.Errorf("failed to open task IO: %w", err)
+	}
+	var consoleSocket string
+	if taskIO.ConsoleSocket != nil {
+		consoleSocket = taskIO.ConsoleSocket.Path()
+	}
+	err = executeCreate(r.ID, r.Bundle, taskIO.ProxyStdout, consoleSocket)
+	taskIO.ProxyStarted()
+	if err == nil && taskIO.ConsoleSocket != nil {
+		if err = taskIO.AttachConsole(); err != nil {
+			if err := executeDelete(r.ID); err != nil {
+				s.logger.WithError(err).Warn("failed to delete container without console")
+			}
+		}
+	}
+	if err != nil {
+		taskIO.Close()
 		if err := mount.UnmountMounts(toMount, rootFS, 0); err != nil {
 			s.logger.WithError(err).Warn("failed to cleanup rootfs mount")
 		}
 		return nil, err
 	}
+	s.ioMu.Lock()
+	s.io[r.ID] = taskIO
+	s.ioMu.Unlock()
 
 	pid, err := getPid(r.ID)
 	if err != nil {
@@ -116,7 +152,12 @@ func (s *remoteprocTaskService) Create(ctx context.Context, r *taskAPI.CreateTas
 		ContainerID: r.ID,
 		Bundle:      r.Bundle,
 		// Rootfs:      r.Rootfs,
-		// IO:          &eventstypes.TaskIO{},
+		IO: &eventstypes.TaskIO{
+			Stdin:    r.Stdin,
+			Stdout:   r.Stdout,
+			Stderr:   r.Stderr,
+			Terminal: r.Terminal,
+		},
 		// Checkpoint:  "",
 		Pid: uint32(pid),
 	})
@@ -142,6 +183,14 @@ func listMounts(req *taskAPI.CreateTaskRequest) []mount.Mount {
 // Start the primary user process inside the container
 func (s *remoteprocTaskService) Start(ctx context.Context, r *taskAPI.StartRequest) (*taskAPI.StartResponse, error) {
 	s.logPayload("-> service.Start", r)
+	if r.ExecID != "" {
+		response, err := s.startExec(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		s.logPayload("<- service.Start", response)
+		return response, nil
+	}
 	err := executeStart(r.ID)
 	if err != nil {
 		return nil, err
@@ -169,6 +218,14 @@ func (s *remoteprocTaskService) Start(ctx context.Context, r *taskAPI.StartReque
 // Delete a process or container
 func (s *remoteprocTaskService) Delete(ctx context.Context, r *taskAPI.DeleteRequest) (*taskAPI.DeleteResponse, error) {
 	s.logPayload("-> service.Delete", r)
+	if r.ExecID != "" {
+		response, err := s.deleteExec(r)
+		if err != nil {
+			return nil, err
+		}
+		s.logPayload("<- service.Delete", response)
+		return response, nil
+	}
 
 	pid, err := getPid(r.ID)
 	if err != nil {
@@ -178,6 +235,7 @@ func (s *remoteprocTaskService) Delete(ctx context.Context, r *taskAPI.DeleteReq
 	if err := executeDelete(r.ID); err != nil {
 		return nil, err
 	}
+	s.closeTaskIO(r.ID)
 
 	s.send(&eventstypes.TaskDelete{
 		ContainerID: r.ID,
@@ -195,18 +253,57 @@ func (s *remoteprocTaskService) Delete(ctx context.Context, r *taskAPI.DeleteReq
 // Exec an additional process inside the container
 func (s *remoteprocTaskService) Exec(ctx context.Context, r *taskAPI.ExecProcessRequest) (*ptypes.Empty, error) {
 	s.logPayload("-> service.Exec", r)
-	return nil, errdefs.ErrNotImplemented
+	process, err := newExecProcess(r)
+	if err != nil {
+		return nil, err
+	}
+	s.execsMu.Lock()
+	if _, ok := s.execs[r.ExecID]; ok {
+		s.execsMu.Unlock()
+		return nil, fmt.Errorf("exec %s: %w", r.ExecID, errdefs.ErrAlreadyExists)
+	}
+	s.execs[r.ExecID] = process
+	s.execsMu.Unlock()
+
+	s.send(&eventstypes.TaskExecAdded{
+		ContainerID: r.ID,
+		ExecID:      r.ExecID,
+	})
+
+	response := &ptypes.Empty{}
+	s.logPayload("<- service.Exec", response)
+	return response, nil
 }
 
 // ResizePty of a process
 func (s *remoteprocTaskService) ResizePty(ctx context.Context, r *taskAPI.ResizePtyRequest) (*ptypes.Empty, error) {
 	s.logPayload("-> service.ResizePty", r)
-	return nil, errdefs.ErrNotImplemented
+	if r.ExecID != "" {
+		return nil, errdefs.ErrNotImplemented
+	}
+	taskIO, err := s.taskIO(r.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := taskIO.Resize(r.Width, r.Height); err != nil {
+		return nil, fmt.Errorf("failed to resize terminal: %w", err)
+	}
+	response := &ptypes.Empty{}
+	s.logPayload("<- service.ResizePty", response)
+	return response, nil
 }
 
 // State returns runtime state of a process
 func (s *remoteprocTaskService) State(ctx context.Context, r *taskAPI.StateRequest) (*taskAPI.StateResponse, error) {
 	s.logPayload("-> service.State", r)
+	if r.ExecID != "" {
+		response, err := s.execState(r)
+		if err != nil {
+			return nil, err
+		}
+		s.logPayload("<- service.State", response)
+		return response, nil
+	}
 	state, err := executeState(r.ID)
 	if err != nil {
 		return nil, err
@@ -230,6 +327,9 @@ func (s *remoteprocTaskService) State(ctx context.Context, r *taskAPI.StateReque
 	default:
 		response.Status = ttypes.Status_UNKNOWN
 	}
+	if state.Status == specs.StateStopped {
+		response.ExitStatus, _ = exitOf(state)
+	}
 
 	s.logPayload("<- service.State", response)
 	return response, nil
@@ -250,6 +350,18 @@ func (s *remoteprocTaskService) Resume(ctx context.Context, r *taskAPI.ResumeReq
 // Kill a process
 func (s *remoteprocTaskService) Kill(ctx context.Context, r *taskAPI.KillRequest) (*ptypes.Empty, error) {
 	s.logPayload("-> service.Kill", r)
+	if r.ExecID != "" {
+		process, err := s.execProcess(r.ExecID)
+		if err != nil {
+			return nil, err
+		}
+		if err := process.kill(syscall.Signal(r.Signal)); err != nil {
+			return nil, fmt.Errorf("failed to kill exec %s: %w", r.ExecID, err)
+		}
+		response := &ptypes.Empty{}
+		s.logPayload("<- service.Kill", response)
+		return response, nil
+	}
 
 	s.stopProcessWatcher()
 
@@ -264,6 +376,9 @@ func (s *remoteprocTaskService) Kill(ctx context.Context, r *taskAPI.KillRequest
 		signal = syscall.SIGKILL
 	case 15:
 		signal = syscall.SIGTERM
+	case 12:
+		// Detaches the firmware, leaving it running, e.g. with docker's --stop-signal SIGUSR2.
+		signal = syscall.SIGUSR2
 	default:
 		signal = syscall.SIGTERM
 	}
@@ -295,7 +410,22 @@ func (s *remoteprocTaskService) Pids(ctx context.Context, r *taskAPI.PidsRequest
 // CloseIO of a process
 func (s *remoteprocTaskService) CloseIO(ctx context.Context, r *taskAPI.CloseIORequest) (*ptypes.Empty, error) {
 	s.logPayload("-> service.CloseIO", r)
-	return nil, errdefs.ErrNotImplemented
+	if r.ExecID != "" {
+		// Execs don't take input, there's nothing to close.
+		response := &ptypes.Empty{}
+		s.logPayload("<- service.CloseIO", response)
+		return response, nil
+	}
+	taskIO, err := s.taskIO(r.ID)
+	if err != nil {
+		return nil, err
+	}
+	if r.Stdin {
+		taskIO.CloseStdin()
+	}
+	response := &ptypes.Empty{}
+	s.logPayload("<- service.CloseIO", response)
+	return response, nil
 }
 
 // Checkpoint the container
@@ -343,6 +473,14 @@ func (s *remoteprocTaskService) Update(ctx context.Context, r *taskAPI.UpdateTas
 // Wait for a process to exit
 func (s *remoteprocTaskService) Wait(ctx context.Context, r *taskAPI.WaitRequest) (*taskAPI.WaitResponse, error) {
 	s.logPayload("-> service.Wait", r)
+	if r.ExecID != "" {
+		response, err := s.waitExec(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		s.logPayload("<- service.Wait", response)
+		return response, nil
+	}
 	const interval = 1 * time.Second
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -356,8 +494,9 @@ func (s *remoteprocTaskService) Wait(ctx context.Context, r *taskAPI.WaitRequest
 				return nil, err
 			}
 			if state.Status == specs.StateStopped {
+				exitStatus, _ := exitOf(state)
 				response := &taskAPI.WaitResponse{
-					ExitStatus: 0,
+					ExitStatus: exitStatus,
 					ExitedAt:   protobuf.ToTimestamp(time.Now().UTC()),
 				}
 				s.logPayload("<- service.Wait", response)
@@ -405,10 +544,24 @@ func (s *remoteprocTaskService) startProcessWatcher(containerID string, pid int)
 		reason := watcher.WaitForExit()
 
 		if reason == ProcessExited {
+			// containerd's exit event only has room for the exit status, so why the firmware
+			// crashed and where its coredump went are written to the task's stderr and logged.
+			var exitStatus uint32
+			if state, err := executeState(containerID); err == nil {
+				var message string
+				exitStatus, message = exitOf(state)
+				if message != "" {
+					s.logger.Warnf("container %s: %s", containerID, message)
+					if taskIO, err := s.taskIO(containerID); err == nil {
+						taskIO.Report(message)
+					}
+				}
+			}
 			s.send(&eventstypes.TaskExit{
 				ContainerID: containerID,
 				ID:          containerID,
 				Pid:         uint32(pid),
+				ExitStatus:  exitStatus,
 				ExitedAt:    protobuf.ToTimestamp(time.Now().UTC()),
 			})
 
@@ -418,6 +571,48 @@ func (s *remoteprocTaskService) startProcessWatcher(containerID string, pid int)
 	}()
 }
 
+// crashExitStatus is the exit status of a task whose firmware crashed.
+const crashExitStatus = 1
+
+// exitOf returns the exit status of the stopped task and, if its firmware crashed, a message
+// telling why and where the coredump was saved.
+func exitOf(state *specs.State) (uint32, string) {
+	reason, crashed := state.Annotations[oci.OptionalStateCrashReason]
+	if !crashed {
+		return 0, ""
+	}
+	message := "firmware crashed: " + reason
+	if coredump, ok := state.Annotations[oci.OptionalStateCoredump]; ok {
+		message += ", coredump saved to " + coredump
+	}
+	return crashExitStatus, message
+}
+
+func (s *remoteprocTaskService) taskIO(containerID string) (*TaskIO, error) {
+	s.ioMu.Lock()
+	defer s.ioMu.Unlock()
+	taskIO, ok := s.io[containerID]
+	if !ok {
+		return nil, fmt.Errorf("no IO for container %s: %w", containerID, errdefs.ErrNotFound)
+	}
+	return taskIO, nil
+}
+
+// closeTaskIO flushes what the proxy wrote before exiting and closes the task's stdio.
+func (s *remoteprocTaskService) closeTaskIO(containerID string) {
+	s.ioMu.Lock()
+	taskIO, ok := s.io[containerID]
+	delete(s.io, containerID)
+	s.ioMu.Unlock()
+	if !ok {
+		return
+	}
+	if !taskIO.Wait(taskIODrainTimeout) {
+		s.logger.Warnf("proxy output of container %s still open after %s, closing it", containerID, taskIODrainTimeout)
+	}
+	taskIO.Close()
+}
+
 func (s *remoteprocTaskService) stopProcessWatcher() {
 	s.processWatcherMu.Lock()
 	defer s.processWatcherMu.Unlock()